@@ -0,0 +1,44 @@
+package lazyledger
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "errors"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ErrInvalidAddress is returned for an address that isn't addressSize bytes long.
+var ErrInvalidAddress = errors.New("invalid address")
+
+// ErrPublicKeyNotRevealed is returned when an address signs a transaction before its public key is known.
+var ErrPublicKeyNotRevealed = errors.New("public key of address not revealed")
+
+// ErrAddressMismatch is returned when a revealed public key does not belong to the address it was revealed for.
+var ErrAddressMismatch = errors.New("public key does not match address")
+
+// Address returns the address of a public key, which is the truncated hash of the marshalled key.
+func Address(pubKey crypto.PubKey) []byte {
+    pubKeyBytes, _ := pubKey.Bytes()
+    return addressOf(pubKeyBytes)
+}
+
+func addressOf(pubKeyBytes []byte) []byte {
+    hash := sha256.Sum256(pubKeyBytes)
+    return hash[:addressSize]
+}
+
+// keyForAddress returns the public key of an address: the key already known for it if there is one,
+// or else a marshalled key revealed by a transaction, if it matches the address.
+func keyForAddress(address []byte, known crypto.PubKey, revealed []byte) (crypto.PubKey, error) {
+    if known != nil {
+        return known, nil
+    }
+    if revealed == nil {
+        return nil, ErrPublicKeyNotRevealed
+    }
+    if bytes.Compare(addressOf(revealed), address) != 0 {
+        return nil, ErrAddressMismatch
+    }
+    return crypto.UnmarshalPublicKey(revealed)
+}
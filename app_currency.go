@@ -1,7 +1,11 @@
 package lazyledger
 
 import (
+    "bytes"
     "encoding/binary"
+    "encoding/hex"
+    "fmt"
+    "sort"
 
     "github.com/golang/protobuf/proto"
     "github.com/libp2p/go-libp2p-crypto"
@@ -25,52 +29,225 @@ func NewCurrency(state MapStore, b *Blockchain) Application {
 }
 
 // ProcessMessage processes a message.
-func (c *Currency) ProcessMessage(message Message) {
-    transaction := &CurrencyTransaction{}
+func (c *Currency) ProcessMessage(message Message) *Receipt {
+    transaction := &CurrencyAppTransaction{}
     err := proto.Unmarshal(message.Data(), transaction)
     if err != nil {
-        return
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
     }
-    if transaction.Dependency != nil {
-        block, err := c.b.Block(c.BlockHead())
-        if err != nil {
-            return
-        }
-        dependencyProven := block.DependencyProven(transaction.Dependency)
-        if !dependencyProven {
-            return
-        }
+    transfer := transaction.GetTransfer()
+    if transfer != nil {
+        return c.processTransfer(transfer)
+    }
+    mint := transaction.GetMint()
+    if mint != nil {
+        return c.processMint(mint)
+    }
+    createMultisig := transaction.GetCreateMultisig()
+    if createMultisig != nil {
+        return c.processCreateMultisig(createMultisig)
+    }
+    return NewFailureReceipt(ReceiptInvalidMessage, "empty transaction")
+}
+
+func (c *Currency) processTransfer(transaction *CurrencyTransaction) *Receipt {
+    // Coins sent to a malformed address could never be spent.
+    if len(transaction.To) != addressSize {
+        return NewFailureReceipt(ReceiptRejected, ErrInvalidAddress.Error())
+    }
+    if transaction.Dependency == nil && (transaction.DependencyBlock != nil || transaction.DependencyIndex != nil) {
+        return NewFailureReceipt(ReceiptInvalidMessage, "dependency reference without a dependency")
+    }
+    // Without a blockchain, there is nothing to prove a dependency against.
+    if transaction.Dependency != nil && (c.b == nil || !c.b.DependencyProven(transferDependency(transaction))) {
+        return NewFailureReceipt(ReceiptUnprovenDependency, "dependency not proven")
     }
     transactionMessage := &CurrencyTransactionMessage{
         To: transaction.To,
         Amount: transaction.Amount,
         Dependency: transaction.Dependency,
+        Nonce: transaction.Nonce,
+        Fee: transaction.Fee,
+        DependencyBlock: transaction.DependencyBlock,
+        DependencyIndex: transaction.DependencyIndex,
+    }
+    account := c.multisigAccount(transaction.From)
+    if account != nil {
+        // Multisig signers sign the sending address too, so their signatures can't be reused by another account they are part of.
+        transactionMessage.From = transaction.From
     }
     signedData, err := proto.Marshal(transactionMessage)
-    fromKey, err := crypto.UnmarshalPublicKey(transaction.From)
-    ok, err := fromKey.Verify(signedData, transaction.Signature)
-    fromBalanceBytes, err := c.state.Get(transaction.From)
     if err != nil {
-        return
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
     }
-    fromBalance := binary.BigEndian.Uint64(fromBalanceBytes)
-    if ok && fromBalance >= *transaction.Amount {
-        toBalanceBytes, err := c.state.Get(transaction.To)
-        var toBalance uint64
+    if account != nil {
+        receipt := verifyMultisig(account, signedData, transaction.MultisigSignatures)
+        if receipt != nil {
+            return receipt
+        }
+    } else {
+        fromKey, err := keyForAddress(transaction.From, c.publicKey(transaction.From), transaction.PublicKey)
         if err != nil {
-            toBalance = 0
+            return NewFailureReceipt(ReceiptInvalidSignature, err.Error())
+        }
+        ok, err := fromKey.Verify(signedData, transaction.Signature)
+        if !ok || err != nil {
+            return NewFailureReceipt(ReceiptInvalidSignature, "signature does not match sender")
+        }
+    }
+    // Each account's transactions must be applied in sequence, so a transaction can't be replayed.
+    nonce := c.nonce(transaction.From)
+    if *transaction.Nonce != nonce {
+        return NewFailureReceipt(ReceiptInvalidNonce, fmt.Sprintf("expected nonce %d, got %d", nonce, *transaction.Nonce))
+    }
+    fee := transaction.GetFee()
+    if *transaction.Amount + fee < *transaction.Amount {
+        return NewFailureReceipt(ReceiptRejected, "amount plus fee overflows")
+    }
+    fromBalance := c.balance(transaction.From)
+    if fromBalance < *transaction.Amount + fee {
+        return NewFailureReceipt(ReceiptInsufficientBalance, fmt.Sprintf("balance %d is less than amount %d plus fee %d", fromBalance, *transaction.Amount, fee))
+    }
+    var collector []byte
+    if c.b != nil {
+        collector = c.b.FeeCollector()
+    }
+    // Balances written without a genesis, such as by an older version, aren't counted in the supply, which must not underflow.
+    supply := c.TotalSupply()
+    if collector == nil && fee > supply {
+        return NewFailureReceipt(ReceiptRejected, fmt.Sprintf("fee %d exceeds total supply %d", fee, supply))
+    }
+    newNonceBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(newNonceBytes, nonce + 1)
+
+    // All balances are updated in one batch so that a failed write can't leave a half-applied transfer.
+    batch := NewBatch(c.state)
+    putBalance(batch, transaction.From, fromBalance - *transaction.Amount - fee)
+    putBalance(batch, transaction.To, getBalance(batch, transaction.To) + *transaction.Amount)
+    if fee > 0 {
+        if collector != nil {
+            putBalance(batch, collector, getBalance(batch, collector) + fee)
         } else {
-            toBalance = binary.BigEndian.Uint64(toBalanceBytes)
+            // Without a collector, fees are burned.
+            supplyBytes := make([]byte, binary.MaxVarintLen64)
+            binary.BigEndian.PutUint64(supplyBytes, supply - fee)
+            batch.Put([]byte("__supply__"), supplyBytes)
         }
-        newFromBalanceBytes := make([]byte, binary.MaxVarintLen64)
-        binary.BigEndian.PutUint64(newFromBalanceBytes, fromBalance - *transaction.Amount)
-        newToBalanceBytes := make([]byte, binary.MaxVarintLen64)
-        binary.BigEndian.PutUint64(newToBalanceBytes, toBalance + *transaction.Amount)
+    }
+    batch.Put(append([]byte("nonce__"), transaction.From...), newNonceBytes)
+    if account == nil && c.publicKey(transaction.From) == nil {
+        batch.Put(append([]byte("pubkey__"), transaction.From...), transaction.PublicKey)
+    }
+    if err := batch.Commit(); err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    c.triggerTransferCallbacks(transaction.From, transaction.To, int(*transaction.Amount))
+    if fee > 0 && collector != nil {
+        c.triggerTransferCallbacks(transaction.From, collector, int(fee))
+    }
+    return NewReceipt(NewEvent("transfer", "from", transaction.From, "to", transaction.To, "amount", *transaction.Amount, "fee", fee))
+}
 
-        c.state.Put(transaction.From, newFromBalanceBytes)
-        c.state.Put(transaction.To, newToBalanceBytes)
-        c.triggerTransferCallbacks(transaction.From, transaction.To, int(*transaction.Amount))
+func (c *Currency) processMint(transaction *MintTransaction) *Receipt {
+    if len(transaction.To) != addressSize {
+        return NewFailureReceipt(ReceiptRejected, ErrInvalidAddress.Error())
     }
+    minter, err := c.state.Get([]byte("__minter__"))
+    if err != nil || bytes.Compare(minter, transaction.Minter) != 0 {
+        return NewFailureReceipt(ReceiptUnauthorized, "sender is not the minter")
+    }
+    transactionMessage := &MintTransactionMessage{
+        To: transaction.To,
+        Amount: transaction.Amount,
+        Nonce: transaction.Nonce,
+    }
+    signedData, err := proto.Marshal(transactionMessage)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    minterKey, err := keyForAddress(transaction.Minter, c.publicKey(transaction.Minter), transaction.PublicKey)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, err.Error())
+    }
+    ok, err := minterKey.Verify(signedData, transaction.Signature)
+    if !ok || err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, "signature does not match minter")
+    }
+    nonce := c.nonce(transaction.Minter)
+    if *transaction.Nonce != nonce {
+        return NewFailureReceipt(ReceiptInvalidNonce, fmt.Sprintf("expected nonce %d, got %d", nonce, *transaction.Nonce))
+    }
+    supply := c.TotalSupply()
+    if supply + *transaction.Amount < supply {
+        return NewFailureReceipt(ReceiptRejected, ErrSupplyOverflow.Error())
+    }
+
+    newSupplyBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(newSupplyBytes, supply + *transaction.Amount)
+    newNonceBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(newNonceBytes, nonce + 1)
+
+    batch := NewBatch(c.state)
+    batch.Put([]byte("__supply__"), newSupplyBytes)
+    putBalance(batch, transaction.To, getBalance(batch, transaction.To) + *transaction.Amount)
+    batch.Put(append([]byte("nonce__"), transaction.Minter...), newNonceBytes)
+    if c.publicKey(transaction.Minter) == nil {
+        batch.Put(append([]byte("pubkey__"), transaction.Minter...), transaction.PublicKey)
+    }
+    if err := batch.Commit(); err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return NewReceipt(NewEvent("mint", "to", transaction.To, "amount", *transaction.Amount))
+}
+
+// Dependencies returns a reference to the message that a transfer depends on, if any.
+func (c *Currency) Dependencies(message Message) []DependencyReference {
+    transaction := &CurrencyAppTransaction{}
+    if err := proto.Unmarshal(message.Data(), transaction); err != nil {
+        return nil
+    }
+    transfer := transaction.GetTransfer()
+    if transfer == nil || transfer.Dependency == nil {
+        return nil
+    }
+    return []DependencyReference{transferDependency(transfer)}
+}
+
+// transferDependency returns the reference to the message a transfer depends on.
+func transferDependency(transfer *CurrencyTransaction) DependencyReference {
+    ref := DependencyReference{
+        Block: transfer.DependencyBlock,
+        Index: -1,
+        Hash: transfer.Dependency,
+    }
+    if transfer.DependencyIndex != nil {
+        ref.Index = int(*transfer.DependencyIndex)
+    }
+    return ref
+}
+
+// MessageSender returns the account that sends a transfer or mint and the transaction's nonce. It can be used as a Mempool SenderFunc.
+func (c *Currency) MessageSender(message Message) ([]byte, uint64, bool) {
+    transaction := &CurrencyAppTransaction{}
+    if err := proto.Unmarshal(message.Data(), transaction); err != nil {
+        return nil, 0, false
+    }
+    if transfer := transaction.GetTransfer(); transfer != nil && transfer.From != nil {
+        return transfer.From, transfer.GetNonce(), true
+    }
+    if mint := transaction.GetMint(); mint != nil && mint.Minter != nil {
+        return mint.Minter, mint.GetNonce(), true
+    }
+    return nil, 0, false
+}
+
+// MessageFee returns the fee paid by a transfer, or 0 for other messages. It can be used as a Mempool FeeFunc.
+func (c *Currency) MessageFee(message Message) uint64 {
+    transaction := &CurrencyAppTransaction{}
+    if err := proto.Unmarshal(message.Data(), transaction); err != nil {
+        return 0
+    }
+    return transaction.GetTransfer().GetFee()
 }
 
 // Namespace returns the application's namespace ID.
@@ -91,38 +268,239 @@ func (c *Currency) BlockHead() []byte {
     return head
 }
 
+// State returns the store that holds the application's state.
+func (c *Currency) State() MapStore {
+    return c.state
+}
+
+// SetState replaces the store that holds the application's state.
+func (c *Currency) SetState(state MapStore) {
+    c.state = state
+}
+
 // GenerateTransaction generates a transaction message.
-func (c *Currency) GenerateTransaction(fromPrivKey crypto.PrivKey, toPubKey crypto.PubKey, amount uint64, dependency []byte) Message {
-    toPubKeyBytes, _ := toPubKey.Bytes()
-    fromPubKeyBytes, _ := fromPrivKey.GetPublic().Bytes()
+// The nonce must be the sender's next nonce at the time the transaction is processed; see Nonce.
+// The sender's public key is revealed by its first transaction, the one with nonce 0, and is known from then on.
+func (c *Currency) GenerateTransaction(fromPrivKey crypto.PrivKey, toPubKey crypto.PubKey, amount uint64, nonce uint64, dependency []byte) Message {
+    return c.GenerateTransactionWithFee(fromPrivKey, toPubKey, amount, 0, nonce, dependency)
+}
+
+// GenerateTransactionWithFee generates a transaction message that pays a fee to the producer of the block it is processed in.
+func (c *Currency) GenerateTransactionWithFee(fromPrivKey crypto.PrivKey, toPubKey crypto.PubKey, amount uint64, fee uint64, nonce uint64, dependency []byte) Message {
+    return c.GenerateTransactionToAddress(fromPrivKey, Address(toPubKey), amount, fee, nonce, dependency)
+}
+
+// GenerateTransactionToAddress generates a transaction message that sends coins to an address, such as a multisig account's.
+func (c *Currency) GenerateTransactionToAddress(fromPrivKey crypto.PrivKey, to []byte, amount uint64, fee uint64, nonce uint64, dependency []byte) Message {
+    var ref *DependencyReference
+    if dependency != nil {
+        ref = &DependencyReference{Hash: dependency}
+    }
+    return c.generateTransfer(fromPrivKey, to, amount, fee, nonce, ref)
+}
+
+// GenerateTransactionWithReference generates a transaction message that depends on a message in an earlier block.
+// The reference names the block and the message's index in it, so the block can be any number of blocks back.
+func (c *Currency) GenerateTransactionWithReference(fromPrivKey crypto.PrivKey, toPubKey crypto.PubKey, amount uint64, nonce uint64, ref DependencyReference) Message {
+    return c.generateTransfer(fromPrivKey, Address(toPubKey), amount, 0, nonce, &ref)
+}
+
+func (c *Currency) generateTransfer(fromPrivKey crypto.PrivKey, to []byte, amount uint64, fee uint64, nonce uint64, ref *DependencyReference) Message {
+    var feeField *uint64
+    if fee > 0 {
+        feeField = &fee
+    }
+    var dependency []byte
+    var dependencyBlock []byte
+    var dependencyIndex *uint64
+    if ref != nil {
+        dependency = ref.Hash
+        if ref.Block != nil {
+            index := uint64(ref.Index)
+            dependencyBlock = ref.Block
+            dependencyIndex = &index
+        }
+    }
     transactionMessage := &CurrencyTransactionMessage{
-        To: toPubKeyBytes,
+        To: to,
         Amount: &amount,
         Dependency: dependency,
+        Nonce: &nonce,
+        Fee: feeField,
+        DependencyBlock: dependencyBlock,
+        DependencyIndex: dependencyIndex,
     }
     signedData, _ := proto.Marshal(transactionMessage)
     signature, _ := fromPrivKey.Sign(signedData)
     transaction := &CurrencyTransaction{
-        To: toPubKeyBytes,
-        From: fromPubKeyBytes,
+        To: to,
+        From: Address(fromPrivKey.GetPublic()),
         Amount: &amount,
         Signature: signature,
         Dependency: dependency,
+        Nonce: &nonce,
+        Fee: feeField,
+        DependencyBlock: dependencyBlock,
+        DependencyIndex: dependencyIndex,
+    }
+    if nonce == 0 {
+        transaction.PublicKey, _ = fromPrivKey.GetPublic().Bytes()
     }
-    messageData, _ := proto.Marshal(transaction)
+    appTransaction := &CurrencyAppTransaction{
+        Message: &CurrencyAppTransaction_Transfer{Transfer: transaction},
+    }
+    messageData, _ := proto.Marshal(appTransaction)
+    return *NewMessage(c.Namespace(), messageData)
+}
+
+// GenerateMintTransaction generates a message that mints new coins to a public key.
+// It is only valid if signed by the currency's minter.
+func (c *Currency) GenerateMintTransaction(minterPrivKey crypto.PrivKey, toPubKey crypto.PubKey, amount uint64, nonce uint64) Message {
+    to := Address(toPubKey)
+    transactionMessage := &MintTransactionMessage{
+        To: to,
+        Amount: &amount,
+        Nonce: &nonce,
+    }
+    signedData, _ := proto.Marshal(transactionMessage)
+    signature, _ := minterPrivKey.Sign(signedData)
+    transaction := &MintTransaction{
+        To: to,
+        Minter: Address(minterPrivKey.GetPublic()),
+        Amount: &amount,
+        Nonce: &nonce,
+        Signature: signature,
+    }
+    if nonce == 0 {
+        transaction.PublicKey, _ = minterPrivKey.GetPublic().Bytes()
+    }
+    appTransaction := &CurrencyAppTransaction{
+        Message: &CurrencyAppTransaction_Mint{Mint: transaction},
+    }
+    messageData, _ := proto.Marshal(appTransaction)
     return *NewMessage(c.Namespace(), messageData)
 }
 
 // Balance gets the balance of a public key.
 func (c *Currency) Balance(pubKey crypto.PubKey) uint64 {
-    pubKeyBytes, _ := pubKey.Bytes()
-    balance, err := c.state.Get(pubKeyBytes)
+    return c.balance(Address(pubKey))
+}
+
+// AddressBalance gets the balance of an address, such as a multisig account's.
+func (c *Currency) AddressBalance(address []byte) uint64 {
+    return c.balance(address)
+}
+
+func (c *Currency) balance(address []byte) uint64 {
+    return getBalance(c.state, address)
+}
+
+func getBalance(state MapStore, address []byte) uint64 {
+    balance, err := state.Get(append([]byte("balance__"), address...))
     if err != nil {
         return 0
     }
     return binary.BigEndian.Uint64(balance)
 }
 
+func putBalance(state MapStore, address []byte, balance uint64) {
+    balanceBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(balanceBytes, balance)
+    state.Put(append([]byte("balance__"), address...), balanceBytes)
+}
+
+// TotalSupply returns the number of coins allocated at genesis plus those minted since.
+func (c *Currency) TotalSupply() uint64 {
+    supply, err := c.state.Get([]byte("__supply__"))
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(supply)
+}
+
+// SetMinter sets the public key that is authorized to mint new coins.
+func (c *Currency) SetMinter(pubKey crypto.PubKey) {
+    c.state.Put([]byte("__minter__"), Address(pubKey))
+}
+
+// LoadGenesis credits the initial balances of a genesis allocation.
+// A currency can only have one genesis allocation.
+func (c *Currency) LoadGenesis(genesis CurrencyGenesis) error {
+    if _, err := c.state.Get([]byte("__supply__")); err == nil {
+        return ErrGenesisLoaded
+    }
+
+    // Credit the allocations in a deterministic order.
+    var keys []string
+    for key := range genesis {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    batch := NewBatch(c.state)
+    var supply uint64
+    for _, key := range keys {
+        address, err := hex.DecodeString(key)
+        if err != nil {
+            return err
+        }
+        if len(address) != addressSize {
+            return ErrInvalidAddress
+        }
+        if supply + genesis[key] < supply {
+            return ErrSupplyOverflow
+        }
+        supply += genesis[key]
+        putBalance(batch, address, genesis[key])
+    }
+    supplyBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(supplyBytes, supply)
+    batch.Put([]byte("__supply__"), supplyBytes)
+    return batch.Commit()
+}
+
+// LoadGenesisFile credits the initial balances of a genesis allocation read from a JSON file.
+func (c *Currency) LoadGenesisFile(path string) error {
+    genesis, err := ReadCurrencyGenesis(path)
+    if err != nil {
+        return err
+    }
+    return c.LoadGenesis(genesis)
+}
+
+// Nonce gets the nonce that the next transaction sent by a public key must have.
+func (c *Currency) Nonce(pubKey crypto.PubKey) uint64 {
+    return c.nonce(Address(pubKey))
+}
+
+// AddressNonce gets the nonce that the next transaction sent by an address, such as a multisig account's, must have.
+func (c *Currency) AddressNonce(address []byte) uint64 {
+    return c.nonce(address)
+}
+
+func (c *Currency) nonce(address []byte) uint64 {
+    nonce, err := c.state.Get(append([]byte("nonce__"), address...))
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(nonce)
+}
+
+// publicKey returns the public key revealed for an address, or nil if it hasn't been revealed.
+func (c *Currency) publicKey(address []byte) crypto.PubKey {
+    pubKeyBytes, err := c.state.Get(append([]byte("pubkey__"), address...))
+    if err != nil {
+        return nil
+    }
+    pubKey, err := crypto.UnmarshalPublicKey(pubKeyBytes)
+    if err != nil {
+        return nil
+    }
+    return pubKey
+}
+
+// AddTransferCallback registers a function that is called in-process on every transfer.
+// Applications on the same Blockchain should subscribe to the currency's transfer events instead; see EventConsumer.
 func (c *Currency) AddTransferCallback(fn TransferCallback) {
     c.transferCallbacks = append(c.transferCallbacks, fn)
 }
@@ -20,22 +20,123 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type CurrencyAppTransaction struct {
+	// Types that are valid to be assigned to Message:
+	//	*CurrencyAppTransaction_Transfer
+	//	*CurrencyAppTransaction_Mint
+	//	*CurrencyAppTransaction_CreateMultisig
+	Message              isCurrencyAppTransaction_Message `protobuf_oneof:"message"`
+	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
+	XXX_unrecognized     []byte                           `json:"-"`
+	XXX_sizecache        int32                            `json:"-"`
+}
+
+func (m *CurrencyAppTransaction) Reset()         { *m = CurrencyAppTransaction{} }
+func (m *CurrencyAppTransaction) String() string { return proto.CompactTextString(m) }
+func (*CurrencyAppTransaction) ProtoMessage()    {}
+func (*CurrencyAppTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_616dce597ab00d2c, []int{0}
+}
+
+func (m *CurrencyAppTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CurrencyAppTransaction.Unmarshal(m, b)
+}
+func (m *CurrencyAppTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CurrencyAppTransaction.Marshal(b, m, deterministic)
+}
+func (m *CurrencyAppTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CurrencyAppTransaction.Merge(m, src)
+}
+func (m *CurrencyAppTransaction) XXX_Size() int {
+	return xxx_messageInfo_CurrencyAppTransaction.Size(m)
+}
+func (m *CurrencyAppTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_CurrencyAppTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CurrencyAppTransaction proto.InternalMessageInfo
+
+type isCurrencyAppTransaction_Message interface {
+	isCurrencyAppTransaction_Message()
+}
+
+type CurrencyAppTransaction_Transfer struct {
+	Transfer *CurrencyTransaction `protobuf:"bytes,1,opt,name=transfer,oneof"`
+}
+
+type CurrencyAppTransaction_Mint struct {
+	Mint *MintTransaction `protobuf:"bytes,2,opt,name=mint,oneof"`
+}
+
+type CurrencyAppTransaction_CreateMultisig struct {
+	CreateMultisig *MultisigAccount `protobuf:"bytes,3,opt,name=create_multisig,json=createMultisig,oneof"`
+}
+
+func (*CurrencyAppTransaction_Transfer) isCurrencyAppTransaction_Message() {}
+
+func (*CurrencyAppTransaction_Mint) isCurrencyAppTransaction_Message() {}
+
+func (*CurrencyAppTransaction_CreateMultisig) isCurrencyAppTransaction_Message() {}
+
+func (m *CurrencyAppTransaction) GetMessage() isCurrencyAppTransaction_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *CurrencyAppTransaction) GetTransfer() *CurrencyTransaction {
+	if x, ok := m.GetMessage().(*CurrencyAppTransaction_Transfer); ok {
+		return x.Transfer
+	}
+	return nil
+}
+
+func (m *CurrencyAppTransaction) GetMint() *MintTransaction {
+	if x, ok := m.GetMessage().(*CurrencyAppTransaction_Mint); ok {
+		return x.Mint
+	}
+	return nil
+}
+
+func (m *CurrencyAppTransaction) GetCreateMultisig() *MultisigAccount {
+	if x, ok := m.GetMessage().(*CurrencyAppTransaction_CreateMultisig); ok {
+		return x.CreateMultisig
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*CurrencyAppTransaction) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*CurrencyAppTransaction_Transfer)(nil),
+		(*CurrencyAppTransaction_Mint)(nil),
+		(*CurrencyAppTransaction_CreateMultisig)(nil),
+	}
+}
+
 type CurrencyTransaction struct {
-	To                   []byte   `protobuf:"bytes,1,req,name=to" json:"to,omitempty"`
-	From                 []byte   `protobuf:"bytes,2,req,name=from" json:"from,omitempty"`
-	Amount               *uint64  `protobuf:"varint,3,req,name=amount" json:"amount,omitempty"`
-	Signature            []byte   `protobuf:"bytes,4,req,name=signature" json:"signature,omitempty"`
-	Dependency           []byte   `protobuf:"bytes,5,opt,name=dependency" json:"dependency,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	To                   []byte               `protobuf:"bytes,1,req,name=to" json:"to,omitempty"`
+	From                 []byte               `protobuf:"bytes,2,req,name=from" json:"from,omitempty"`
+	Amount               *uint64              `protobuf:"varint,3,req,name=amount" json:"amount,omitempty"`
+	Signature            []byte               `protobuf:"bytes,4,req,name=signature" json:"signature,omitempty"`
+	Dependency           []byte               `protobuf:"bytes,5,opt,name=dependency" json:"dependency,omitempty"`
+	Nonce                *uint64              `protobuf:"varint,6,req,name=nonce" json:"nonce,omitempty"`
+	Fee                  *uint64              `protobuf:"varint,7,opt,name=fee" json:"fee,omitempty"`
+	MultisigSignatures   []*MultisigSignature `protobuf:"bytes,8,rep,name=multisig_signatures,json=multisigSignatures" json:"multisig_signatures,omitempty"`
+	PublicKey            []byte               `protobuf:"bytes,9,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	DependencyBlock      []byte               `protobuf:"bytes,10,opt,name=dependency_block,json=dependencyBlock" json:"dependency_block,omitempty"`
+	DependencyIndex      *uint64              `protobuf:"varint,11,opt,name=dependency_index,json=dependencyIndex" json:"dependency_index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *CurrencyTransaction) Reset()         { *m = CurrencyTransaction{} }
 func (m *CurrencyTransaction) String() string { return proto.CompactTextString(m) }
 func (*CurrencyTransaction) ProtoMessage()    {}
 func (*CurrencyTransaction) Descriptor() ([]byte, []int) {
-	return fileDescriptor_616dce597ab00d2c, []int{0}
+	return fileDescriptor_616dce597ab00d2c, []int{1}
 }
 
 func (m *CurrencyTransaction) XXX_Unmarshal(b []byte) error {
@@ -91,10 +192,57 @@ func (m *CurrencyTransaction) GetDependency() []byte {
 	return nil
 }
 
+func (m *CurrencyTransaction) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+func (m *CurrencyTransaction) GetFee() uint64 {
+	if m != nil && m.Fee != nil {
+		return *m.Fee
+	}
+	return 0
+}
+
+func (m *CurrencyTransaction) GetMultisigSignatures() []*MultisigSignature {
+	if m != nil {
+		return m.MultisigSignatures
+	}
+	return nil
+}
+
+func (m *CurrencyTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *CurrencyTransaction) GetDependencyBlock() []byte {
+	if m != nil {
+		return m.DependencyBlock
+	}
+	return nil
+}
+
+func (m *CurrencyTransaction) GetDependencyIndex() uint64 {
+	if m != nil && m.DependencyIndex != nil {
+		return *m.DependencyIndex
+	}
+	return 0
+}
+
 type CurrencyTransactionMessage struct {
 	To                   []byte   `protobuf:"bytes,1,req,name=to" json:"to,omitempty"`
 	Amount               *uint64  `protobuf:"varint,2,req,name=amount" json:"amount,omitempty"`
 	Dependency           []byte   `protobuf:"bytes,3,opt,name=dependency" json:"dependency,omitempty"`
+	Nonce                *uint64  `protobuf:"varint,4,req,name=nonce" json:"nonce,omitempty"`
+	Fee                  *uint64  `protobuf:"varint,5,opt,name=fee" json:"fee,omitempty"`
+	From                 []byte   `protobuf:"bytes,6,opt,name=from" json:"from,omitempty"`
+	DependencyBlock      []byte   `protobuf:"bytes,7,opt,name=dependency_block,json=dependencyBlock" json:"dependency_block,omitempty"`
+	DependencyIndex      *uint64  `protobuf:"varint,8,opt,name=dependency_index,json=dependencyIndex" json:"dependency_index,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -104,7 +252,7 @@ func (m *CurrencyTransactionMessage) Reset()         { *m = CurrencyTransactionM
 func (m *CurrencyTransactionMessage) String() string { return proto.CompactTextString(m) }
 func (*CurrencyTransactionMessage) ProtoMessage()    {}
 func (*CurrencyTransactionMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_616dce597ab00d2c, []int{1}
+	return fileDescriptor_616dce597ab00d2c, []int{2}
 }
 
 func (m *CurrencyTransactionMessage) XXX_Unmarshal(b []byte) error {
@@ -146,25 +294,314 @@ func (m *CurrencyTransactionMessage) GetDependency() []byte {
 	return nil
 }
 
+func (m *CurrencyTransactionMessage) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+func (m *CurrencyTransactionMessage) GetFee() uint64 {
+	if m != nil && m.Fee != nil {
+		return *m.Fee
+	}
+	return 0
+}
+
+func (m *CurrencyTransactionMessage) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *CurrencyTransactionMessage) GetDependencyBlock() []byte {
+	if m != nil {
+		return m.DependencyBlock
+	}
+	return nil
+}
+
+func (m *CurrencyTransactionMessage) GetDependencyIndex() uint64 {
+	if m != nil && m.DependencyIndex != nil {
+		return *m.DependencyIndex
+	}
+	return 0
+}
+
+type MintTransaction struct {
+	To                   []byte   `protobuf:"bytes,1,req,name=to" json:"to,omitempty"`
+	Minter               []byte   `protobuf:"bytes,2,req,name=minter" json:"minter,omitempty"`
+	Amount               *uint64  `protobuf:"varint,3,req,name=amount" json:"amount,omitempty"`
+	Nonce                *uint64  `protobuf:"varint,4,req,name=nonce" json:"nonce,omitempty"`
+	Signature            []byte   `protobuf:"bytes,5,req,name=signature" json:"signature,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,6,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MintTransaction) Reset()         { *m = MintTransaction{} }
+func (m *MintTransaction) String() string { return proto.CompactTextString(m) }
+func (*MintTransaction) ProtoMessage()    {}
+func (*MintTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_616dce597ab00d2c, []int{3}
+}
+
+func (m *MintTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MintTransaction.Unmarshal(m, b)
+}
+func (m *MintTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MintTransaction.Marshal(b, m, deterministic)
+}
+func (m *MintTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MintTransaction.Merge(m, src)
+}
+func (m *MintTransaction) XXX_Size() int {
+	return xxx_messageInfo_MintTransaction.Size(m)
+}
+func (m *MintTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_MintTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MintTransaction proto.InternalMessageInfo
+
+func (m *MintTransaction) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *MintTransaction) GetMinter() []byte {
+	if m != nil {
+		return m.Minter
+	}
+	return nil
+}
+
+func (m *MintTransaction) GetAmount() uint64 {
+	if m != nil && m.Amount != nil {
+		return *m.Amount
+	}
+	return 0
+}
+
+func (m *MintTransaction) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+func (m *MintTransaction) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *MintTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+type MintTransactionMessage struct {
+	To                   []byte   `protobuf:"bytes,1,req,name=to" json:"to,omitempty"`
+	Amount               *uint64  `protobuf:"varint,2,req,name=amount" json:"amount,omitempty"`
+	Nonce                *uint64  `protobuf:"varint,3,req,name=nonce" json:"nonce,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MintTransactionMessage) Reset()         { *m = MintTransactionMessage{} }
+func (m *MintTransactionMessage) String() string { return proto.CompactTextString(m) }
+func (*MintTransactionMessage) ProtoMessage()    {}
+func (*MintTransactionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_616dce597ab00d2c, []int{4}
+}
+
+func (m *MintTransactionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MintTransactionMessage.Unmarshal(m, b)
+}
+func (m *MintTransactionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MintTransactionMessage.Marshal(b, m, deterministic)
+}
+func (m *MintTransactionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MintTransactionMessage.Merge(m, src)
+}
+func (m *MintTransactionMessage) XXX_Size() int {
+	return xxx_messageInfo_MintTransactionMessage.Size(m)
+}
+func (m *MintTransactionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_MintTransactionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MintTransactionMessage proto.InternalMessageInfo
+
+func (m *MintTransactionMessage) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *MintTransactionMessage) GetAmount() uint64 {
+	if m != nil && m.Amount != nil {
+		return *m.Amount
+	}
+	return 0
+}
+
+func (m *MintTransactionMessage) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+type MultisigAccount struct {
+	Threshold            *uint32  `protobuf:"varint,1,req,name=threshold" json:"threshold,omitempty"`
+	Keys                 [][]byte `protobuf:"bytes,2,rep,name=keys" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MultisigAccount) Reset()         { *m = MultisigAccount{} }
+func (m *MultisigAccount) String() string { return proto.CompactTextString(m) }
+func (*MultisigAccount) ProtoMessage()    {}
+func (*MultisigAccount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_616dce597ab00d2c, []int{5}
+}
+
+func (m *MultisigAccount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultisigAccount.Unmarshal(m, b)
+}
+func (m *MultisigAccount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultisigAccount.Marshal(b, m, deterministic)
+}
+func (m *MultisigAccount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultisigAccount.Merge(m, src)
+}
+func (m *MultisigAccount) XXX_Size() int {
+	return xxx_messageInfo_MultisigAccount.Size(m)
+}
+func (m *MultisigAccount) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultisigAccount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultisigAccount proto.InternalMessageInfo
+
+func (m *MultisigAccount) GetThreshold() uint32 {
+	if m != nil && m.Threshold != nil {
+		return *m.Threshold
+	}
+	return 0
+}
+
+func (m *MultisigAccount) GetKeys() [][]byte {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type MultisigSignature struct {
+	Index                *uint32  `protobuf:"varint,1,req,name=index" json:"index,omitempty"`
+	Signature            []byte   `protobuf:"bytes,2,req,name=signature" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MultisigSignature) Reset()         { *m = MultisigSignature{} }
+func (m *MultisigSignature) String() string { return proto.CompactTextString(m) }
+func (*MultisigSignature) ProtoMessage()    {}
+func (*MultisigSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_616dce597ab00d2c, []int{6}
+}
+
+func (m *MultisigSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultisigSignature.Unmarshal(m, b)
+}
+func (m *MultisigSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultisigSignature.Marshal(b, m, deterministic)
+}
+func (m *MultisigSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultisigSignature.Merge(m, src)
+}
+func (m *MultisigSignature) XXX_Size() int {
+	return xxx_messageInfo_MultisigSignature.Size(m)
+}
+func (m *MultisigSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultisigSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultisigSignature proto.InternalMessageInfo
+
+func (m *MultisigSignature) GetIndex() uint32 {
+	if m != nil && m.Index != nil {
+		return *m.Index
+	}
+	return 0
+}
+
+func (m *MultisigSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterType((*CurrencyAppTransaction)(nil), "lazyledger.CurrencyAppTransaction")
 	proto.RegisterType((*CurrencyTransaction)(nil), "lazyledger.CurrencyTransaction")
 	proto.RegisterType((*CurrencyTransactionMessage)(nil), "lazyledger.CurrencyTransactionMessage")
+	proto.RegisterType((*MintTransaction)(nil), "lazyledger.MintTransaction")
+	proto.RegisterType((*MintTransactionMessage)(nil), "lazyledger.MintTransactionMessage")
+	proto.RegisterType((*MultisigAccount)(nil), "lazyledger.MultisigAccount")
+	proto.RegisterType((*MultisigSignature)(nil), "lazyledger.MultisigSignature")
 }
 
 func init() { proto.RegisterFile("app_currency.proto", fileDescriptor_616dce597ab00d2c) }
 
 var fileDescriptor_616dce597ab00d2c = []byte{
-	// 187 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x8e, 0xc1, 0xca, 0x82, 0x40,
-	0x10, 0x80, 0x71, 0xf5, 0xff, 0xa1, 0x41, 0x3a, 0x4c, 0x10, 0x4b, 0x44, 0x88, 0x27, 0x4f, 0xbd,
-	0x44, 0xe7, 0x2e, 0xd2, 0x3d, 0x16, 0x77, 0x12, 0x41, 0x77, 0x97, 0xd9, 0xf5, 0x60, 0x2f, 0xd1,
-	0x2b, 0x47, 0x22, 0x98, 0xd5, 0x6d, 0xe6, 0x1b, 0x86, 0xef, 0x03, 0x54, 0xce, 0x5d, 0xab, 0x9e,
-	0x99, 0x4c, 0x35, 0x1c, 0x1d, 0xdb, 0x60, 0x11, 0x5a, 0x75, 0x1f, 0x5a, 0xd2, 0x35, 0x71, 0xfe,
-	0x88, 0x60, 0x73, 0x9a, 0xce, 0x17, 0x56, 0xc6, 0xab, 0x2a, 0x34, 0xd6, 0xe0, 0x1a, 0x44, 0xb0,
-	0x32, 0xca, 0x44, 0x91, 0x96, 0x22, 0x58, 0x44, 0x48, 0x6e, 0x6c, 0x3b, 0x29, 0x46, 0x32, 0xce,
-	0xb8, 0x85, 0x7f, 0xd5, 0xd9, 0xde, 0x04, 0x19, 0x67, 0xa2, 0x48, 0xca, 0x69, 0xc3, 0x3d, 0xac,
-	0x7c, 0x53, 0x1b, 0x15, 0x7a, 0x26, 0x99, 0x8c, 0x0f, 0x33, 0xc0, 0x03, 0x80, 0x26, 0x47, 0x46,
-	0xbf, 0x94, 0xf2, 0x2f, 0x8b, 0x8a, 0xb4, 0x7c, 0x23, 0xb9, 0x86, 0xdd, 0x8f, 0xa0, 0x33, 0x79,
-	0xaf, 0x6a, 0xfa, 0xea, 0x9a, 0x1b, 0xc4, 0xa2, 0x61, 0x69, 0x89, 0x3f, 0x2d, 0xcf, 0x00, 0x00,
-	0x00, 0xff, 0xff, 0x7d, 0x86, 0xba, 0x4f, 0x18, 0x01, 0x00, 0x00,
+	// 515 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0xad, 0xd7, 0xce, 0xd7, 0xa4, 0x34, 0x65, 0x8b, 0xa2, 0x15, 0x50, 0xb0, 0x7c, 0x32, 0x97,
+	0x48, 0xf4, 0xce, 0xa1, 0xad, 0x04, 0x45, 0xa8, 0x1c, 0x0c, 0xe2, 0x1a, 0xb9, 0xce, 0x24, 0xb5,
+	0x62, 0xef, 0x5a, 0xeb, 0x8d, 0x44, 0xf8, 0x3d, 0xfc, 0x2d, 0xfe, 0x06, 0x27, 0x0e, 0x68, 0xd7,
+	0x76, 0xfc, 0x51, 0x57, 0x82, 0xdb, 0xcc, 0xf3, 0xbc, 0xd9, 0x99, 0xf7, 0xc6, 0x40, 0xc3, 0x2c,
+	0x5b, 0x46, 0x3b, 0x29, 0x91, 0x47, 0xfb, 0x45, 0x26, 0x85, 0x12, 0x14, 0x92, 0xf0, 0xc7, 0x3e,
+	0xc1, 0xd5, 0x06, 0xa5, 0xf7, 0xcb, 0x82, 0xf9, 0x75, 0xf9, 0xf9, 0x32, 0xcb, 0xbe, 0xca, 0x90,
+	0xe7, 0x61, 0xa4, 0x62, 0xc1, 0xe9, 0x3b, 0x18, 0x2b, 0x9d, 0xae, 0x51, 0x32, 0xcb, 0xb5, 0xfc,
+	0xe9, 0xc5, 0xeb, 0x45, 0xcd, 0x5c, 0x54, 0xac, 0x06, 0xe5, 0xe6, 0x28, 0x38, 0x50, 0xe8, 0x5b,
+	0x70, 0xd2, 0x98, 0x2b, 0x46, 0x0c, 0xf5, 0x45, 0x93, 0x7a, 0x1b, 0x73, 0xd5, 0xa6, 0x99, 0x52,
+	0xfa, 0x1e, 0x66, 0x91, 0xc4, 0x50, 0xe1, 0x32, 0xdd, 0x25, 0x2a, 0xce, 0xe3, 0x0d, 0xb3, 0x7b,
+	0xd8, 0xe5, 0xb7, 0xcb, 0x28, 0x12, 0x3b, 0xae, 0x6e, 0x8e, 0x82, 0x93, 0x82, 0x55, 0x7d, 0xb8,
+	0x9a, 0xc0, 0x28, 0xc5, 0x3c, 0x0f, 0x37, 0xe8, 0xfd, 0x21, 0x70, 0xd6, 0x33, 0x29, 0x3d, 0x01,
+	0xa2, 0x04, 0xb3, 0x5c, 0xe2, 0x1f, 0x07, 0x44, 0x09, 0x4a, 0xc1, 0x59, 0x4b, 0x91, 0x32, 0x62,
+	0x10, 0x13, 0xd3, 0x39, 0x0c, 0xc3, 0x54, 0x3f, 0xc1, 0x6c, 0x97, 0xf8, 0x4e, 0x50, 0x66, 0xf4,
+	0x25, 0x4c, 0xf2, 0x78, 0xc3, 0x43, 0xb5, 0x93, 0xc8, 0x1c, 0x43, 0xa8, 0x01, 0xfa, 0x0a, 0x60,
+	0x85, 0x19, 0xf2, 0x95, 0x7e, 0x92, 0x0d, 0x5c, 0xcb, 0x3f, 0x0e, 0x1a, 0x08, 0x7d, 0x06, 0x03,
+	0x2e, 0x78, 0x84, 0x6c, 0x68, 0x9a, 0x16, 0x09, 0x3d, 0x05, 0x7b, 0x8d, 0xc8, 0x46, 0xae, 0xe5,
+	0x3b, 0x81, 0x0e, 0xe9, 0x67, 0x38, 0xab, 0x54, 0x58, 0x1e, 0xba, 0xe7, 0x6c, 0xec, 0xda, 0xfe,
+	0xf4, 0xe2, 0xbc, 0x4f, 0x90, 0x2f, 0x55, 0x55, 0x40, 0xd3, 0x2e, 0x94, 0xd3, 0x73, 0x80, 0x6c,
+	0x77, 0x97, 0xc4, 0xd1, 0x72, 0x8b, 0x7b, 0x36, 0x31, 0x73, 0x4d, 0x0a, 0xe4, 0x13, 0xee, 0xe9,
+	0x1b, 0x38, 0xad, 0x87, 0x5c, 0xde, 0x25, 0x22, 0xda, 0x32, 0x30, 0x45, 0xb3, 0x1a, 0xbf, 0xd2,
+	0x70, 0xa7, 0x34, 0xe6, 0x2b, 0xfc, 0xce, 0xa6, 0x66, 0xf0, 0x46, 0xe9, 0x47, 0x0d, 0x7b, 0xbf,
+	0x2d, 0x78, 0xde, 0x23, 0xff, 0x6d, 0xe1, 0xce, 0x03, 0x17, 0x6a, 0xc5, 0x49, 0x4b, 0xf1, 0xb6,
+	0xa6, 0xf6, 0xe3, 0x9a, 0x3a, 0x3d, 0x9a, 0x0e, 0x6a, 0x4d, 0x2b, 0x97, 0x87, 0xa6, 0x83, 0x89,
+	0x7b, 0x17, 0x1f, 0xfd, 0xfb, 0xe2, 0xe3, 0xfe, 0xc5, 0x7f, 0x5a, 0x30, 0xeb, 0x9c, 0x79, 0xdf,
+	0xb6, 0xfa, 0xec, 0x51, 0x96, 0x57, 0x57, 0x66, 0x8f, 0xde, 0x5d, 0xff, 0x96, 0xad, 0x6b, 0x1c,
+	0x74, 0xaf, 0xb1, 0xed, 0xfa, 0xb0, 0xe3, 0xba, 0xf7, 0x0d, 0xe6, 0x9d, 0x29, 0xff, 0xd7, 0x9a,
+	0xc3, 0x50, 0x76, 0x63, 0x28, 0xef, 0x1a, 0x66, 0x9d, 0xdf, 0x54, 0xcf, 0xa9, 0xee, 0x25, 0xe6,
+	0xf7, 0x22, 0x59, 0x99, 0xbe, 0x4f, 0x82, 0x1a, 0xd0, 0xce, 0x6c, 0x71, 0x9f, 0x33, 0xe2, 0xda,
+	0xda, 0x19, 0x1d, 0x7b, 0x1f, 0xe0, 0xe9, 0x83, 0xd3, 0xd6, 0xef, 0x15, 0xc2, 0x17, 0x2d, 0x8a,
+	0xa4, 0x2d, 0x02, 0xe9, 0x88, 0xf0, 0x37, 0x00, 0x00, 0xff, 0xff, 0xbd, 0x7c, 0xf0, 0x43, 0x05,
+	0x05, 0x00, 0x00,
 }
@@ -0,0 +1,58 @@
+package lazyledger
+
+import (
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "io/ioutil"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ErrGenesisLoaded is returned when a genesis allocation is loaded into a currency that already has one.
+var ErrGenesisLoaded = errors.New("genesis already loaded")
+
+// ErrSupplyOverflow is returned when an allocation would overflow the total supply of a currency.
+var ErrSupplyOverflow = errors.New("total supply overflow")
+
+// CurrencyGenesis is the initial allocation of a currency.
+// It maps hex-encoded addresses to balances, and is stored as a JSON object of the same form.
+type CurrencyGenesis map[string]uint64
+
+// NewCurrencyGenesis returns a new empty genesis allocation.
+func NewCurrencyGenesis() CurrencyGenesis {
+    return make(CurrencyGenesis)
+}
+
+// ReadCurrencyGenesis reads a genesis allocation from a JSON file.
+func ReadCurrencyGenesis(path string) (CurrencyGenesis, error) {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    genesis := NewCurrencyGenesis()
+    err = json.Unmarshal(data, &genesis)
+    if err != nil {
+        return nil, err
+    }
+    return genesis, nil
+}
+
+// Add allocates a balance to the address of a public key.
+func (g CurrencyGenesis) Add(pubKey crypto.PubKey, balance uint64) {
+    g.AddAddress(Address(pubKey), balance)
+}
+
+// AddAddress allocates a balance to an address, such as a multisig account's.
+func (g CurrencyGenesis) AddAddress(address []byte, balance uint64) {
+    g[hex.EncodeToString(address)] = balance
+}
+
+// WriteFile writes the genesis allocation to a JSON file.
+func (g CurrencyGenesis) WriteFile(path string) error {
+    data, err := json.MarshalIndent(g, "", "    ")
+    if err != nil {
+        return err
+    }
+    return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,188 @@
+package lazyledger
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "errors"
+    "fmt"
+    "sort"
+
+    "github.com/golang/protobuf/proto"
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ErrInvalidMultisig is returned for a multisig account whose threshold or key set is invalid.
+var ErrInvalidMultisig = errors.New("invalid multisig account")
+
+const maxMultisigKeys = 16
+
+// NewMultisigAccount returns a new m-of-n multisig account that requires threshold signatures from keys.
+// The keys are sorted, so the account doesn't depend on their order.
+func NewMultisigAccount(threshold uint32, keys []crypto.PubKey) (*MultisigAccount, error) {
+    account := &MultisigAccount{
+        Threshold: &threshold,
+    }
+    for _, key := range keys {
+        keyBytes, err := key.Bytes()
+        if err != nil {
+            return nil, err
+        }
+        account.Keys = append(account.Keys, keyBytes)
+    }
+    sort.Slice(account.Keys, func(i, j int) bool {
+        return bytes.Compare(account.Keys[i], account.Keys[j]) < 0
+    })
+    err := validateMultisigAccount(account)
+    if err != nil {
+        return nil, err
+    }
+    return account, nil
+}
+
+// MultisigAddress returns the address of a multisig account, which is the hash of its threshold and keys.
+func MultisigAddress(account *MultisigAccount) []byte {
+    data := []byte("multisig__")
+    data = appendUvarint(data, uint64(*account.Threshold))
+    for _, key := range account.Keys {
+        data = appendUvarint(data, uint64(len(key)))
+        data = append(data, key...)
+    }
+    hash := sha256.Sum256(data)
+    return hash[:addressSize]
+}
+
+// validateMultisigAccount checks that an account's keys are valid, sorted and unique, and that its threshold can be met.
+func validateMultisigAccount(account *MultisigAccount) error {
+    if account.Threshold == nil || *account.Threshold == 0 || int(*account.Threshold) > len(account.Keys) || len(account.Keys) > maxMultisigKeys {
+        return ErrInvalidMultisig
+    }
+    for i, key := range account.Keys {
+        if i > 0 && bytes.Compare(account.Keys[i - 1], key) >= 0 {
+            return ErrInvalidMultisig
+        }
+        if _, err := crypto.UnmarshalPublicKey(key); err != nil {
+            return ErrInvalidMultisig
+        }
+    }
+    return nil
+}
+
+// verifyMultisig checks that signedData is signed by at least the threshold of an account's keys.
+// It returns a failure receipt if it isn't, or nil if it is.
+func verifyMultisig(account *MultisigAccount, signedData []byte, signatures []*MultisigSignature) *Receipt {
+    signed := make(map[uint32]bool)
+    for _, signature := range signatures {
+        index := signature.GetIndex()
+        if int(index) >= len(account.Keys) || signed[index] {
+            return NewFailureReceipt(ReceiptInvalidSignature, fmt.Sprintf("invalid or repeated signer index %d", index))
+        }
+        key, err := crypto.UnmarshalPublicKey(account.Keys[index])
+        if err != nil {
+            return NewFailureReceipt(ReceiptInvalidSignature, "invalid signer key")
+        }
+        ok, err := key.Verify(signedData, signature.Signature)
+        if !ok || err != nil {
+            return NewFailureReceipt(ReceiptInvalidSignature, fmt.Sprintf("signature does not match signer %d", index))
+        }
+        signed[index] = true
+    }
+    if len(signed) < int(*account.Threshold) {
+        return NewFailureReceipt(ReceiptInvalidSignature, fmt.Sprintf("%d of %d required signatures", len(signed), *account.Threshold))
+    }
+    return nil
+}
+
+func (c *Currency) processCreateMultisig(account *MultisigAccount) *Receipt {
+    if err := validateMultisigAccount(account); err != nil {
+        return NewFailureReceipt(ReceiptRejected, err.Error())
+    }
+    address := MultisigAddress(account)
+    if c.multisigAccount(address) != nil {
+        return NewFailureReceipt(ReceiptRejected, "multisig account already exists")
+    }
+    accountBytes, err := proto.Marshal(account)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    err = c.state.Put(append([]byte("multisig__"), address...), accountBytes)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return NewReceipt(NewEvent("create_multisig", "address", address, "threshold", *account.Threshold))
+}
+
+// multisigAccount returns the multisig account at an address, or nil if there is none.
+func (c *Currency) multisigAccount(address []byte) *MultisigAccount {
+    accountBytes, err := c.state.Get(append([]byte("multisig__"), address...))
+    if err != nil {
+        return nil
+    }
+    account := &MultisigAccount{}
+    if err := proto.Unmarshal(accountBytes, account); err != nil {
+        return nil
+    }
+    return account
+}
+
+// MultisigAccount returns the multisig account at an address, or nil if it hasn't been created.
+func (c *Currency) MultisigAccount(address []byte) *MultisigAccount {
+    return c.multisigAccount(address)
+}
+
+// GenerateCreateMultisigTransaction generates a message that creates a multisig account.
+// Coins can be sent to the account's address before it is created, but can only be spent from it afterwards.
+func (c *Currency) GenerateCreateMultisigTransaction(account *MultisigAccount) Message {
+    appTransaction := &CurrencyAppTransaction{
+        Message: &CurrencyAppTransaction_CreateMultisig{CreateMultisig: account},
+    }
+    messageData, _ := proto.Marshal(appTransaction)
+    return *NewMessage(c.Namespace(), messageData)
+}
+
+// GenerateMultisigTransaction generates a transaction message sent from a multisig account and signed by some of its keys.
+// The nonce must be the account's next nonce at the time the transaction is processed; see AddressNonce.
+func (c *Currency) GenerateMultisigTransaction(account *MultisigAccount, signers []crypto.PrivKey, to []byte, amount uint64, fee uint64, nonce uint64, dependency []byte) Message {
+    from := MultisigAddress(account)
+    var feeField *uint64
+    if fee > 0 {
+        feeField = &fee
+    }
+    transactionMessage := &CurrencyTransactionMessage{
+        To: to,
+        Amount: &amount,
+        Dependency: dependency,
+        Nonce: &nonce,
+        Fee: feeField,
+        From: from,
+    }
+    signedData, _ := proto.Marshal(transactionMessage)
+    var signatures []*MultisigSignature
+    for _, signer := range signers {
+        signerBytes, _ := signer.GetPublic().Bytes()
+        for i, key := range account.Keys {
+            if bytes.Compare(key, signerBytes) == 0 {
+                signature, _ := signer.Sign(signedData)
+                index := uint32(i)
+                signatures = append(signatures, &MultisigSignature{
+                    Index: &index,
+                    Signature: signature,
+                })
+            }
+        }
+    }
+    transaction := &CurrencyTransaction{
+        To: to,
+        From: from,
+        Amount: &amount,
+        Signature: []byte{},
+        Dependency: dependency,
+        Nonce: &nonce,
+        Fee: feeField,
+        MultisigSignatures: signatures,
+    }
+    appTransaction := &CurrencyAppTransaction{
+        Message: &CurrencyAppTransaction_Transfer{Transfer: transaction},
+    }
+    messageData, _ := proto.Marshal(appTransaction)
+    return *NewMessage(c.Namespace(), messageData)
+}
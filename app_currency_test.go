@@ -2,7 +2,9 @@ package lazyledger
 
 import (
     "crypto/rand"
-    "encoding/binary"
+    "io/ioutil"
+    "os"
+    "path/filepath"
     "testing"
 
     "github.com/libp2p/go-libp2p-crypto"
@@ -20,12 +22,11 @@ func TestAppCurrencySimpleBlock(t *testing.T) {
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
 
-    sb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, nil))
+    sb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, nil))
     b.ProcessBlock(sb)
 
     if app.(*Currency).Balance(pubA) != 900 || app.(*Currency).Balance(pubB) != 100 {
@@ -45,14 +46,13 @@ func TestAppCurrencySimpleBlockDependency(t *testing.T) {
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
 
     sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("foo")))
     hash, _, _ := sb.ProveDependency(0)
-    sb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, hash))
+    sb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, hash))
     _, proof, _ := sb.ProveDependency(0)
     sb.VerifyDependency(0, hash, proof)
     b.ProcessBlock(sb)
@@ -74,14 +74,13 @@ func TestAppCurrencyProbabilisticBlockDependency(t *testing.T) {
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
 
     pb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("foo")))
     hash, _, _ := pb.ProveDependency(0)
-    pb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, hash))
+    pb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, hash))
     _, proof, _ := pb.ProveDependency(0)
     pb.VerifyDependency(0, hash, proof)
     b.ProcessBlock(pb)
@@ -103,15 +102,389 @@ func TestAppCurrencyProbabilisticBlock(t *testing.T) {
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
 
-    pb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, nil))
+    pb.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, nil))
     b.ProcessBlock(pb)
 
     if app.(*Currency).Balance(pubA) != 900 || app.(*Currency).Balance(pubB) != 100 {
         t.Error("test tranasaction failed: invalid post-balances")
     }
 }
+
+func TestAppCurrencyReplay(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
+
+    transaction := app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, nil)
+    sb1 := NewSimpleBlock([]byte{0})
+    sb1.AddMessage(transaction)
+    sb1.AddMessage(transaction)
+    b.ProcessBlock(sb1)
+
+    if app.(*Currency).Balance(pubA) != 900 || app.(*Currency).Balance(pubB) != 100 {
+        t.Error("transaction replayed within a block")
+    }
+    if app.(*Currency).Nonce(pubA) != 1 {
+        t.Error("nonce not incremented")
+    }
+
+    sb2 := NewSimpleBlock(sb1.Digest())
+    sb2.AddMessage(transaction)
+    sb2.AddMessage(app.(*Currency).GenerateTransaction(privA, pubB, 50, 1, nil))
+    b.ProcessBlock(sb2)
+
+    if app.(*Currency).Balance(pubA) != 850 || app.(*Currency).Balance(pubB) != 150 {
+        t.Error("transaction replayed in a later block")
+    }
+}
+
+func TestAppCurrencyGenesisFile(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+
+    _, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    genesis.Add(pubB, 500)
+
+    dir, _ := ioutil.TempDir("", "genesis")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "genesis.json")
+    genesis.WriteFile(path)
+
+    if err := app.(*Currency).LoadGenesisFile(path); err != nil {
+        t.Fatal(err)
+    }
+    if app.(*Currency).Balance(pubA) != 1000 || app.(*Currency).Balance(pubB) != 500 {
+        t.Error("genesis balances not allocated")
+    }
+    if app.(*Currency).TotalSupply() != 1500 {
+        t.Error("invalid total supply after genesis")
+    }
+    if app.(*Currency).LoadGenesisFile(path) != ErrGenesisLoaded {
+        t.Error("genesis loaded twice")
+    }
+}
+
+func TestAppCurrencyMint(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    sb := NewSimpleBlock([]byte{0})
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    app.(*Currency).SetMinter(pubA)
+
+    sb.AddMessage(app.(*Currency).GenerateMintTransaction(privA, pubB, 100, 0))
+    sb.AddMessage(app.(*Currency).GenerateMintTransaction(privB, pubB, 100, 0))
+    sb.AddMessage(app.(*Currency).GenerateMintTransaction(privA, pubB, 100, 0))
+    b.ProcessBlock(sb)
+
+    if app.(*Currency).Balance(pubB) != 100 {
+        t.Error("invalid balance after mint")
+    }
+    if app.(*Currency).TotalSupply() != 100 {
+        t.Error("invalid total supply after mint")
+    }
+}
+
+func TestAppCurrencyReceipts(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    sb := NewSimpleBlock([]byte{0})
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
+
+    transfer := app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, nil)
+    overspend := app.(*Currency).GenerateTransaction(privA, pubB, 5000, 1, nil)
+    reused := app.(*Currency).GenerateTransaction(privA, pubB, 50, 0, nil)
+    malformed := app.(*Currency).GenerateTransactionToAddress(privA, Address(pubB)[:addressSize - 1], 50, 0, 1, nil)
+    sb.AddMessage(transfer)
+    sb.AddMessage(overspend)
+    sb.AddMessage(reused)
+    sb.AddMessage(malformed)
+    b.ProcessBlock(sb)
+
+    receipts := b.BlockReceipts(sb.Digest())
+    if len(receipts) != 4 {
+        t.Fatalf("expected 4 receipts, got %d", len(receipts))
+    }
+
+    receipt, err := b.Receipt(transfer.Hash())
+    if err != nil || !receipt.Success() {
+        t.Error("expected transfer to succeed")
+    }
+    if len(receipt.Events) != 1 || receipt.Events[0].Type != "transfer" || string(receipt.Events[0].Attribute("amount")) != "100" {
+        t.Error("expected transfer event")
+    }
+
+    receipt, err = b.Receipt(overspend.Hash())
+    if err != nil || receipt.Code != ReceiptInsufficientBalance {
+        t.Error("expected overspend to fail with insufficient balance")
+    }
+
+    receipt, err = b.Receipt(reused.Hash())
+    if err != nil || receipt.Code != ReceiptInvalidNonce {
+        t.Error("expected reused nonce to fail")
+    }
+
+    receipt, err = b.Receipt(malformed.Hash())
+    if err != nil || receipt.Code != ReceiptRejected {
+        t.Error("expected transfer to a malformed address to be rejected")
+    }
+
+    if app.(*Currency).Balance(pubA) != 900 || app.(*Currency).Balance(pubB) != 100 {
+        t.Error("failed transactions changed balances")
+    }
+
+    _, err = b.Receipt([]byte("foo"))
+    if err == nil {
+        t.Error("expected error for unknown message")
+    }
+}
+
+func TestAppCurrencyFees(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubC, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
+
+    var collected []int
+    app.(*Currency).AddTransferCallback(func(from []byte, to []byte, value int) {
+        addrC := Address(pubC)
+        if string(to) == string(addrC) {
+            collected = append(collected, value)
+        }
+    })
+
+    addrC := Address(pubC)
+    b.SetFeeCollector(addrC)
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(app.(*Currency).GenerateTransactionWithFee(privA, pubB, 100, 10, 0, nil))
+    sb.AddMessage(app.(*Currency).GenerateTransactionWithFee(privA, pubB, 880, 11, 1, nil))
+    b.ProcessBlock(sb)
+
+    if app.(*Currency).Balance(pubA) != 890 || app.(*Currency).Balance(pubB) != 100 || app.(*Currency).Balance(pubC) != 10 {
+        t.Error("fee was not credited to the collector")
+    }
+    if len(collected) != 1 || collected[0] != 10 {
+        t.Error("expected transfer callback for the fee")
+    }
+
+    b.SetFeeCollector(nil)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(app.(*Currency).GenerateTransactionWithFee(privA, pubB, 100, 10, 1, nil))
+    b.ProcessBlock(sb)
+
+    if app.(*Currency).Balance(pubA) != 780 || app.(*Currency).TotalSupply() != 990 {
+        t.Error("fee was not burned without a collector")
+    }
+
+    // A balance that isn't counted in the supply can't burn more than the supply.
+    privD, pubD, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    putBalance(ms, Address(pubD), 5000)
+    burn := app.(*Currency).GenerateTransactionWithFee(privD, pubB, 100, 2000, 0, nil)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(burn)
+    b.ProcessBlock(sb)
+
+    receipt, err := b.Receipt(burn.Hash())
+    if err != nil || receipt.Code != ReceiptRejected {
+        t.Error("fee larger than the total supply was burned")
+    }
+    if app.(*Currency).Balance(pubD) != 5000 || app.(*Currency).TotalSupply() != 990 {
+        t.Error("rejected transfer changed balances or supply")
+    }
+}
+
+func TestAppCurrencyProducerFees(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privP1, pubP1, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privP2, pubP2, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubC, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    b.AddProducer(pubP1)
+    b.AddProducer(pubP2)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    // The fees of a signed block go to its producer, rather than the configured collector.
+    b.SetFeeCollector(Address(pubC))
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(currency.GenerateTransactionWithFee(privA, pubB, 100, 10, 0, nil))
+    sb.AddMessage(currency.GenerateTransactionWithFee(privA, pubB, 100, 5, 1, nil))
+    SignBlock(sb, privP1, 1)
+    if err := b.ProcessBlock(sb); err != nil {
+        t.Fatal(err)
+    }
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(currency.GenerateTransactionWithFee(privA, pubB, 100, 7, 2, nil))
+    SignBlock(sb, privP2, 2)
+    if err := b.ProcessBlock(sb); err != nil {
+        t.Fatal(err)
+    }
+
+    if currency.Balance(pubP1) != 15 || currency.Balance(pubP2) != 7 || currency.Balance(pubC) != 0 {
+        t.Error("fees not credited to the producers of their blocks")
+    }
+    if currency.Balance(pubA) != 1000 - 300 - 22 || currency.TotalSupply() != 1000 {
+        t.Error("invalid balance or supply after paying fees to producers")
+    }
+}
+
+func TestAppCurrencyWithoutBlockchain(t *testing.T) {
+    app := NewCurrency(NewSimpleMap(), nil)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    receipt := currency.ProcessMessage(currency.GenerateTransaction(privA, pubB, 100, 0, []byte("dependency")))
+    if receipt == nil || receipt.Code != ReceiptUnprovenDependency {
+        t.Error("transfer with a dependency processed without a blockchain")
+    }
+    receipt = currency.ProcessMessage(currency.GenerateTransaction(privA, pubB, 100, 0, nil))
+    if (receipt != nil && !receipt.Success()) || currency.Balance(pubB) != 100 {
+        t.Error("transfer without a dependency failed without a blockchain")
+    }
+}
+
+func TestAppCurrencyMultisig(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privC, pubC, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubD, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    app.(*Currency).LoadGenesis(genesis)
+
+    account, err := NewMultisigAccount(2, []crypto.PubKey{pubA, pubB, pubC})
+    if err != nil {
+        t.Fatal(err)
+    }
+    reordered, _ := NewMultisigAccount(2, []crypto.PubKey{pubC, pubA, pubB})
+    if string(MultisigAddress(account)) != string(MultisigAddress(reordered)) {
+        t.Error("multisig address depends on key order")
+    }
+    if _, err := NewMultisigAccount(4, []crypto.PubKey{pubA, pubB, pubC}); err != ErrInvalidMultisig {
+        t.Error("expected threshold above key count to be rejected")
+    }
+    address := MultisigAddress(account)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(app.(*Currency).GenerateTransactionToAddress(privA, address, 500, 0, 0, nil))
+    sb.AddMessage(app.(*Currency).GenerateCreateMultisigTransaction(account))
+    oneSignature := app.(*Currency).GenerateMultisigTransaction(account, []crypto.PrivKey{privB}, address, 100, 0, 0, nil)
+    sb.AddMessage(oneSignature)
+    sb.AddMessage(app.(*Currency).GenerateMultisigTransaction(account, []crypto.PrivKey{privB, privC}, Address(pubD), 100, 0, 0, nil))
+    b.ProcessBlock(sb)
+
+    receipt, _ := b.Receipt(oneSignature.Hash())
+    if receipt == nil || receipt.Code != ReceiptInvalidSignature {
+        t.Error("expected transaction below threshold to be rejected")
+    }
+    if app.(*Currency).AddressBalance(address) != 400 || app.(*Currency).Balance(pubD) != 100 || app.(*Currency).AddressNonce(address) != 1 {
+        t.Error("multisig transaction failed: invalid post-balances")
+    }
+}
+
+
+func TestAppCurrencyAddressPublicKeyReveal(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    genesis.Add(pubB, 1000)
+    app.(*Currency).LoadGenesis(genesis)
+
+    if len(Address(pubA)) != addressSize {
+        t.Error("invalid address size")
+    }
+
+    sb := NewSimpleBlock([]byte{0})
+    // B's key is only revealed by a nonce 0 transaction, so this one can't be verified.
+    unrevealed := app.(*Currency).GenerateTransaction(privB, pubA, 100, 1, nil)
+    sb.AddMessage(unrevealed)
+    first := app.(*Currency).GenerateTransaction(privA, pubB, 100, 0, nil)
+    second := app.(*Currency).GenerateTransaction(privA, pubB, 100, 1, nil)
+    sb.AddMessage(first)
+    sb.AddMessage(second)
+    b.ProcessBlock(sb)
+
+    receipt, _ := b.Receipt(unrevealed.Hash())
+    if receipt == nil || receipt.Code != ReceiptInvalidSignature {
+        t.Error("expected transaction from an unrevealed key to fail")
+    }
+    if len(second.Data()) >= len(first.Data()) {
+        t.Error("expected transaction from a revealed key to be smaller")
+    }
+    if app.(*Currency).Balance(pubA) != 800 || app.(*Currency).Balance(pubB) != 1200 {
+        t.Error("test tranasaction failed: invalid post-balances")
+    }
+}
@@ -1,11 +1,23 @@
 package lazyledger
 
 import (
+    "bytes"
+    "crypto/sha256"
+    "fmt"
+    "sort"
+
     "github.com/golang/protobuf/proto"
+    "gitlab.com/NebulousLabs/merkletree"
 )
 
+// dummyHeadKey is the key DummyApp stores the block head under, next to the stored key-value pairs.
+// Keys are stored unprefixed, so that the storage a DummyApp takes is that of its key-value pairs,
+// and transactions that write the head key are rejected.
+const dummyHeadKey = "__head__"
+
 type DummyApp struct {
     state MapStore
+    cachedTree *dummyTree
 }
 
 func NewDummyApp(state MapStore) Application {
@@ -14,15 +26,102 @@ func NewDummyApp(state MapStore) Application {
     }
 }
 
-func (app *DummyApp) ProcessMessage(message Message) {
+// ProcessMessage applies a transaction's puts in key order, followed by its operations in the order they are listed.
+// The writes are batched, so if a compare-and-swap fails, none of the transaction's writes are applied.
+// A transaction that writes the key the block head is stored under is rejected.
+func (app *DummyApp) ProcessMessage(message Message) *Receipt {
     transaction := &DummyAppTransaction{}
     err := proto.Unmarshal(message.Data(), transaction)
     if err != nil {
-        return
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+
+    keys := make([]string, 0, len(transaction.Puts))
+    for k := range transaction.Puts {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    for _, k := range keys {
+        if k == dummyHeadKey {
+            return NewFailureReceipt(ReceiptRejected, fmt.Sprintf("key %s is reserved", k))
+        }
+    }
+    for _, operation := range transaction.Operations {
+        if operation.GetKey() == dummyHeadKey {
+            return NewFailureReceipt(ReceiptRejected, fmt.Sprintf("key %s is reserved", dummyHeadKey))
+        }
+    }
+
+    batch := NewBatch(app.state)
+    var events []Event
+    for _, k := range keys {
+        err := batch.Put(dummyKey(k), []byte(transaction.Puts[k]))
+        if err != nil {
+            return NewFailureReceipt(ReceiptStorageError, err.Error())
+        }
+        events = append(events, NewEvent("put", "key", k))
+    }
+
+    for _, operation := range transaction.Operations {
+        event, receipt := processDummyOperation(batch, operation)
+        if receipt != nil {
+            return receipt
+        }
+        events = append(events, event)
     }
-    for k, v := range transaction.Puts {
-        app.state.Put([]byte(k), []byte(v))
+    app.cachedTree = nil
+    if err := batch.Commit(); err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
     }
+    return NewReceipt(events...)
+}
+
+func processDummyOperation(state MapStore, operation *DummyOperation) (Event, *Receipt) {
+    key := dummyKey(operation.GetKey())
+    switch operation.GetType() {
+    case DummyOperationType_PUT:
+        if operation.Value == nil {
+            return Event{}, NewFailureReceipt(ReceiptInvalidMessage, "put without a value")
+        }
+        err := state.Put(key, []byte(*operation.Value))
+        if err != nil {
+            return Event{}, NewFailureReceipt(ReceiptStorageError, err.Error())
+        }
+        return NewEvent("put", "key", operation.GetKey()), nil
+    case DummyOperationType_DELETE:
+        err := deleteDummyKey(state, key)
+        if err != nil {
+            return Event{}, NewFailureReceipt(ReceiptStorageError, err.Error())
+        }
+        return NewEvent("delete", "key", operation.GetKey()), nil
+    case DummyOperationType_COMPARE_AND_SWAP:
+        current, getErr := state.Get(key)
+        if operation.Expected == nil && getErr == nil {
+            return Event{}, NewFailureReceipt(ReceiptRejected, fmt.Sprintf("compare-and-swap of %s: key exists", operation.GetKey()))
+        }
+        if operation.Expected != nil && (getErr != nil || bytes.Compare(current, []byte(*operation.Expected)) != 0) {
+            return Event{}, NewFailureReceipt(ReceiptRejected, fmt.Sprintf("compare-and-swap of %s: value does not match", operation.GetKey()))
+        }
+        var err error
+        if operation.Value == nil {
+            err = deleteDummyKey(state, key)
+        } else {
+            err = state.Put(key, []byte(*operation.Value))
+        }
+        if err != nil {
+            return Event{}, NewFailureReceipt(ReceiptStorageError, err.Error())
+        }
+        return NewEvent("compare_and_swap", "key", operation.GetKey()), nil
+    }
+    return Event{}, NewFailureReceipt(ReceiptInvalidMessage, "unknown operation type")
+}
+
+// deleteDummyKey deletes a key if it exists; deleting a missing key is not an error.
+func deleteDummyKey(state MapStore, key []byte) error {
+    if _, err := state.Get(key); err != nil {
+        return nil
+    }
+    return state.Del(key)
 }
 
 func (app *DummyApp) Namespace() [namespaceSize]byte {
@@ -32,22 +131,167 @@ func (app *DummyApp) Namespace() [namespaceSize]byte {
 }
 
 func (app *DummyApp) SetBlockHead(hash []byte) {
-    app.state.Put([]byte("__head__"), hash)
+    app.state.Put([]byte(dummyHeadKey), hash)
 }
 
 func (app *DummyApp) BlockHead() []byte {
-    head, _ := app.state.Get([]byte("__head__"))
+    head, _ := app.state.Get([]byte(dummyHeadKey))
     return head
 }
 
+func (app *DummyApp) State() MapStore {
+    return app.state
+}
+
+func (app *DummyApp) SetState(state MapStore) {
+    app.state = state
+    app.cachedTree = nil
+}
+
 func (app *DummyApp) Get(key string) string {
-    value, err := app.state.Get([]byte(key))
+    value, err := app.state.Get(dummyKey(key))
     if err != nil {
         return ""
     }
     return string(value)
 }
 
+// DummyProof proves that a key has a value in a DummyApp state with a given root.
+type DummyProof struct {
+    Key string
+    Value string
+    Index uint64
+    NumLeaves uint64
+    Proof [][]byte
+}
+
+// Root returns the Merkle root of the stored key-value pairs, ordered by key.
+func (app *DummyApp) Root() ([]byte, error) {
+    tree, err := app.merkleTree()
+    if err != nil {
+        return nil, err
+    }
+    return tree.root(), nil
+}
+
+// GetWithProof returns the value of a key along with a proof of it against the state root.
+func (app *DummyApp) GetWithProof(key string) (*DummyProof, []byte, error) {
+    tree, err := app.merkleTree()
+    if err != nil {
+        return nil, nil, err
+    }
+    index, ok := tree.indexes[key]
+    if !ok {
+        return nil, nil, &InvalidKeyError{Key: []byte(key)}
+    }
+    return &DummyProof{
+        Key: key,
+        Value: app.Get(key),
+        Index: uint64(index),
+        NumLeaves: uint64(len(tree.leaves)),
+        Proof: tree.prove(index),
+    }, tree.root(), nil
+}
+
+// merkleTree returns the Merkle tree of the stored key-value pairs.
+// The tree is cached until the application writes to its state, or its state is swapped.
+func (app *DummyApp) merkleTree() (*dummyTree, error) {
+    if app.cachedTree != nil {
+        return app.cachedTree, nil
+    }
+    stored, err := scanPrefix(app.state, nil)
+    if err != nil {
+        return nil, err
+    }
+    tree := &dummyTree{
+        indexes: make(map[string]int),
+        nodes: make(map[[2]int][]byte),
+    }
+    for _, k := range stored {
+        if string(k) == dummyHeadKey {
+            continue
+        }
+        value, err := app.state.Get(k)
+        if err != nil {
+            return nil, err
+        }
+        tree.indexes[string(k)] = len(tree.leaves)
+        tree.leaves = append(tree.leaves, dummyLeaf(string(k), string(value)))
+    }
+    app.cachedTree = tree
+    return tree, nil
+}
+
+// dummyTree is a Merkle tree over leaves, with the same shape as a merkletree.Tree,
+// that keeps the roots of its subtrees so that proofs don't hash the whole tree again.
+type dummyTree struct {
+    indexes map[string]int
+    leaves [][]byte
+    nodes map[[2]int][]byte
+}
+
+func (t *dummyTree) root() []byte {
+    if len(t.leaves) == 0 {
+        return nil
+    }
+    return t.subtreeRoot(0, len(t.leaves))
+}
+
+// subtreeRoot returns the root of the subtree of leaves[lo:hi].
+func (t *dummyTree) subtreeRoot(lo int, hi int) []byte {
+    if node, ok := t.nodes[[2]int{lo, hi}]; ok {
+        return node
+    }
+    var node []byte
+    if hi - lo == 1 {
+        node = leafSum(sha256.New(), t.leaves[lo])
+    } else {
+        k := lo + largestPowerOfTwoBelow(hi - lo)
+        node = nodeSum(sha256.New(), t.subtreeRoot(lo, k), t.subtreeRoot(k, hi))
+    }
+    t.nodes[[2]int{lo, hi}] = node
+    return node
+}
+
+// prove returns the proof set of a leaf in the format of merkletree.Tree.Prove: the leaf followed by its siblings from the bottom up.
+func (t *dummyTree) prove(index int) [][]byte {
+    var siblings [][]byte
+    lo, hi := 0, len(t.leaves)
+    for hi - lo > 1 {
+        k := lo + largestPowerOfTwoBelow(hi - lo)
+        if index < k {
+            siblings = append(siblings, t.subtreeRoot(k, hi))
+            hi = k
+        } else {
+            siblings = append(siblings, t.subtreeRoot(lo, k))
+            lo = k
+        }
+    }
+    proof := [][]byte{t.leaves[index]}
+    for i := len(siblings) - 1; i >= 0; i-- {
+        proof = append(proof, siblings[i])
+    }
+    return proof
+}
+
+// VerifyDummyProof checks a proof returned by GetWithProof against a state root.
+func VerifyDummyProof(root []byte, proof *DummyProof) bool {
+    if len(proof.Proof) == 0 || bytes.Compare(proof.Proof[0], dummyLeaf(proof.Key, proof.Value)) != 0 {
+        return false
+    }
+    return merkletree.VerifyProof(sha256.New(), root, proof.Proof, proof.Index, proof.NumLeaves)
+}
+
+func dummyKey(key string) []byte {
+    return []byte(key)
+}
+
+func dummyLeaf(key string, value string) []byte {
+    leaf := appendUvarint(nil, uint64(len(key)))
+    leaf = append(leaf, key...)
+    return append(leaf, value...)
+}
+
 func (app *DummyApp) GenerateTransaction(puts map[string]string) Message {
     transaction := &DummyAppTransaction{
         Puts: puts,
@@ -56,6 +300,51 @@ func (app *DummyApp) GenerateTransaction(puts map[string]string) Message {
     return *NewMessage(app.Namespace(), data)
 }
 
+// GenerateOperationsTransaction generates a transaction that applies operations in order.
+func (app *DummyApp) GenerateOperationsTransaction(operations ...*DummyOperation) Message {
+    transaction := &DummyAppTransaction{
+        Operations: operations,
+    }
+    data, _ := proto.Marshal(transaction)
+    return *NewMessage(app.Namespace(), data)
+}
+
+// NewPutOperation returns an operation that sets the value of a key.
+func NewPutOperation(key string, value string) *DummyOperation {
+    return &DummyOperation{
+        Type: DummyOperationType_PUT.Enum(),
+        Key: &key,
+        Value: &value,
+    }
+}
+
+// NewDeleteOperation returns an operation that deletes a key.
+func NewDeleteOperation(key string) *DummyOperation {
+    return &DummyOperation{
+        Type: DummyOperationType_DELETE.Enum(),
+        Key: &key,
+    }
+}
+
+// NewCompareAndSwapOperation returns an operation that sets the value of a key only if its current value is expected.
+func NewCompareAndSwapOperation(key string, expected string, value string) *DummyOperation {
+    return &DummyOperation{
+        Type: DummyOperationType_COMPARE_AND_SWAP.Enum(),
+        Key: &key,
+        Expected: &expected,
+        Value: &value,
+    }
+}
+
+// NewCreateOperation returns an operation that sets the value of a key only if the key doesn't exist.
+func NewCreateOperation(key string, value string) *DummyOperation {
+    return &DummyOperation{
+        Type: DummyOperationType_COMPARE_AND_SWAP.Enum(),
+        Key: &key,
+        Value: &value,
+    }
+}
+
 func (app *DummyApp) StorageSize() int {
     return app.state.storageSize()
 }
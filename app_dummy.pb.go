@@ -20,8 +20,52 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type DummyOperationType int32
+
+const (
+	DummyOperationType_PUT              DummyOperationType = 0
+	DummyOperationType_DELETE           DummyOperationType = 1
+	DummyOperationType_COMPARE_AND_SWAP DummyOperationType = 2
+)
+
+var DummyOperationType_name = map[int32]string{
+	0: "PUT",
+	1: "DELETE",
+	2: "COMPARE_AND_SWAP",
+}
+
+var DummyOperationType_value = map[string]int32{
+	"PUT":              0,
+	"DELETE":           1,
+	"COMPARE_AND_SWAP": 2,
+}
+
+func (x DummyOperationType) Enum() *DummyOperationType {
+	p := new(DummyOperationType)
+	*p = x
+	return p
+}
+
+func (x DummyOperationType) String() string {
+	return proto.EnumName(DummyOperationType_name, int32(x))
+}
+
+func (x *DummyOperationType) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(DummyOperationType_value, data, "DummyOperationType")
+	if err != nil {
+		return err
+	}
+	*x = DummyOperationType(value)
+	return nil
+}
+
+func (DummyOperationType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6fabccd89524743b, []int{0}
+}
+
 type DummyAppTransaction struct {
 	Puts                 map[string]string `protobuf:"bytes,1,rep,name=puts" json:"puts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Operations           []*DummyOperation `protobuf:"bytes,2,rep,name=operations" json:"operations,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
 	XXX_sizecache        int32             `json:"-"`
@@ -59,23 +103,103 @@ func (m *DummyAppTransaction) GetPuts() map[string]string {
 	return nil
 }
 
+func (m *DummyAppTransaction) GetOperations() []*DummyOperation {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+type DummyOperation struct {
+	Type                 *DummyOperationType `protobuf:"varint,1,req,name=type,enum=lazyledger.DummyOperationType" json:"type,omitempty"`
+	Key                  *string             `protobuf:"bytes,2,req,name=key" json:"key,omitempty"`
+	Value                *string             `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	Expected             *string             `protobuf:"bytes,4,opt,name=expected" json:"expected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *DummyOperation) Reset()         { *m = DummyOperation{} }
+func (m *DummyOperation) String() string { return proto.CompactTextString(m) }
+func (*DummyOperation) ProtoMessage()    {}
+func (*DummyOperation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6fabccd89524743b, []int{1}
+}
+
+func (m *DummyOperation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DummyOperation.Unmarshal(m, b)
+}
+func (m *DummyOperation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DummyOperation.Marshal(b, m, deterministic)
+}
+func (m *DummyOperation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DummyOperation.Merge(m, src)
+}
+func (m *DummyOperation) XXX_Size() int {
+	return xxx_messageInfo_DummyOperation.Size(m)
+}
+func (m *DummyOperation) XXX_DiscardUnknown() {
+	xxx_messageInfo_DummyOperation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DummyOperation proto.InternalMessageInfo
+
+func (m *DummyOperation) GetType() DummyOperationType {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return DummyOperationType_PUT
+}
+
+func (m *DummyOperation) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *DummyOperation) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+func (m *DummyOperation) GetExpected() string {
+	if m != nil && m.Expected != nil {
+		return *m.Expected
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("lazyledger.DummyOperationType", DummyOperationType_name, DummyOperationType_value)
 	proto.RegisterType((*DummyAppTransaction)(nil), "lazyledger.DummyAppTransaction")
 	proto.RegisterMapType((map[string]string)(nil), "lazyledger.DummyAppTransaction.PutsEntry")
+	proto.RegisterType((*DummyOperation)(nil), "lazyledger.DummyOperation")
 }
 
 func init() { proto.RegisterFile("app_dummy.proto", fileDescriptor_6fabccd89524743b) }
 
 var fileDescriptor_6fabccd89524743b = []byte{
-	// 150 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4f, 0x2c, 0x28, 0x88,
-	0x4f, 0x29, 0xcd, 0xcd, 0xad, 0xd4, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0xca, 0x49, 0xac,
-	0xaa, 0xcc, 0x49, 0x4d, 0x49, 0x4f, 0x2d, 0x52, 0xea, 0x65, 0xe4, 0x12, 0x76, 0x01, 0xc9, 0x39,
-	0x16, 0x14, 0x84, 0x14, 0x25, 0xe6, 0x15, 0x27, 0x26, 0x97, 0x64, 0xe6, 0xe7, 0x09, 0xd9, 0x72,
-	0xb1, 0x14, 0x94, 0x96, 0x14, 0x4b, 0x30, 0x2a, 0x30, 0x6b, 0x70, 0x1b, 0x69, 0xea, 0x21, 0xb4,
-	0xe8, 0x61, 0x51, 0xae, 0x17, 0x50, 0x5a, 0x52, 0xec, 0x9a, 0x57, 0x52, 0x54, 0x19, 0x04, 0xd6,
-	0x26, 0x65, 0xce, 0xc5, 0x09, 0x17, 0x12, 0x12, 0xe0, 0x62, 0xce, 0x4e, 0xad, 0x94, 0x60, 0x54,
-	0x60, 0xd4, 0xe0, 0x0c, 0x02, 0x31, 0x85, 0x44, 0xb8, 0x58, 0xcb, 0x12, 0x73, 0x4a, 0x53, 0x25,
-	0x98, 0xc0, 0x62, 0x10, 0x8e, 0x15, 0x93, 0x05, 0x23, 0x20, 0x00, 0x00, 0xff, 0xff, 0xa7, 0xfd,
-	0x97, 0xf6, 0xad, 0x00, 0x00, 0x00,
+	// 281 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x8e, 0xcf, 0x4a, 0xc3, 0x40,
+	0x10, 0xc6, 0xdd, 0x4d, 0xfd, 0xd3, 0x11, 0x6a, 0x18, 0x7b, 0x58, 0x72, 0x90, 0xd0, 0x53, 0xf4,
+	0x90, 0x43, 0x2e, 0x4a, 0x41, 0x24, 0x98, 0xdc, 0xd4, 0x86, 0x18, 0xf1, 0x18, 0x96, 0x66, 0x11,
+	0x31, 0x4d, 0x96, 0x64, 0x23, 0xae, 0x4f, 0xe0, 0xab, 0xf9, 0x56, 0x92, 0x95, 0xd6, 0x4a, 0xf4,
+	0xb6, 0xb3, 0xdf, 0x6f, 0x7e, 0xdf, 0xc0, 0x11, 0x97, 0x32, 0x2f, 0xba, 0xd5, 0x4a, 0xfb, 0xb2,
+	0xa9, 0x55, 0x8d, 0x50, 0xf2, 0x77, 0x5d, 0x8a, 0xe2, 0x49, 0x34, 0xb3, 0x4f, 0x02, 0xc7, 0x51,
+	0x9f, 0x85, 0x52, 0x66, 0x0d, 0xaf, 0x5a, 0xbe, 0x54, 0xcf, 0x75, 0x85, 0x97, 0x30, 0x92, 0x9d,
+	0x6a, 0x19, 0x71, 0x2d, 0xef, 0x30, 0x38, 0xf5, 0x7f, 0x56, 0xfc, 0x3f, 0x70, 0x3f, 0xe9, 0x54,
+	0x1b, 0x57, 0xaa, 0xd1, 0xa9, 0x59, 0xc3, 0x39, 0x40, 0x2d, 0x45, 0xc3, 0xfb, 0xb0, 0x65, 0xd4,
+	0x48, 0x9c, 0x81, 0x64, 0xb1, 0x46, 0xd2, 0x2d, 0xda, 0x39, 0x87, 0xf1, 0x46, 0x87, 0x36, 0x58,
+	0x2f, 0x42, 0x33, 0xe2, 0x12, 0x6f, 0x9c, 0xf6, 0x4f, 0x9c, 0xc2, 0xee, 0x2b, 0x2f, 0x3b, 0xc1,
+	0xa8, 0xf9, 0xfb, 0x1e, 0xe6, 0xf4, 0x82, 0xcc, 0x3e, 0x08, 0x4c, 0x7e, 0x7b, 0x31, 0x80, 0x91,
+	0xd2, 0x52, 0x30, 0xe2, 0x52, 0x6f, 0x12, 0x9c, 0xfc, 0x7f, 0x41, 0xa6, 0xa5, 0x48, 0x0d, 0xbb,
+	0xae, 0xa4, 0x2e, 0x1d, 0x54, 0x5a, 0x5b, 0x95, 0xe8, 0xc0, 0x81, 0x78, 0x93, 0x62, 0xa9, 0x44,
+	0xc1, 0x46, 0x26, 0xd8, 0xcc, 0x67, 0x57, 0x80, 0x43, 0x3f, 0xee, 0x83, 0x95, 0x3c, 0x64, 0xf6,
+	0x0e, 0x02, 0xec, 0x45, 0xf1, 0x4d, 0x9c, 0xc5, 0x36, 0xc1, 0x29, 0xd8, 0xd7, 0x8b, 0xdb, 0x24,
+	0x4c, 0xe3, 0x3c, 0xbc, 0x8b, 0xf2, 0xfb, 0xc7, 0x30, 0xb1, 0xe9, 0x57, 0x00, 0x00, 0x00, 0xff,
+	0xff, 0xdb, 0x21, 0xfd, 0x84, 0xb5, 0x01, 0x00, 0x00,
 }
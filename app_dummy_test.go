@@ -1,7 +1,11 @@
 package lazyledger
 
 import (
+    "bytes"
+    "crypto/sha256"
     "testing"
+
+    "gitlab.com/NebulousLabs/merkletree"
 )
 
 
@@ -26,3 +30,171 @@ func TestAppDummySimpleBlock(t *testing.T) {
         t.Error("dummy app state update failed")
     }
 }
+
+func TestAppDummyOperations(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewDummyApp(ms)
+    b.RegisterApplication(&app)
+    dummy := app.(*DummyApp)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(dummy.GenerateTransaction(map[string]string{"foo": "bar", "goo": "tar"}))
+    sb.AddMessage(dummy.GenerateOperationsTransaction(
+        NewPutOperation("foo", "baz"),
+        NewDeleteOperation("goo"),
+        NewCreateOperation("hoo", "car"),
+    ))
+    failed := dummy.GenerateOperationsTransaction(
+        NewPutOperation("ioo", "jar"),
+        NewCompareAndSwapOperation("foo", "bar", "qux"),
+    )
+    sb.AddMessage(failed)
+    b.ProcessBlock(sb)
+
+    if dummy.Get("foo") != "baz" || dummy.Get("goo") != "" || dummy.Get("hoo") != "car" {
+        t.Error("dummy app operations not applied in order")
+    }
+    if dummy.Get("ioo") != "" {
+        t.Error("writes of a transaction with a failed compare-and-swap were applied")
+    }
+    receipt, err := b.Receipt(failed.Hash())
+    if err != nil || receipt.Success() || receipt.Code != ReceiptRejected {
+        t.Error("failed compare-and-swap did not produce a rejected receipt")
+    }
+
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(dummy.GenerateOperationsTransaction(
+        NewCompareAndSwapOperation("foo", "baz", "qux"),
+        NewCreateOperation("hoo", "dar"),
+    ))
+    sb.AddMessage(dummy.GenerateOperationsTransaction(NewCompareAndSwapOperation("foo", "baz", "quux")))
+    b.ProcessBlock(sb)
+
+    if dummy.Get("hoo") != "car" {
+        t.Error("create of an existing key did not fail the transaction")
+    }
+    if dummy.Get("foo") != "quux" {
+        t.Error("compare-and-swap after a failed transaction did not see the original value")
+    }
+
+    // Outside of a blockchain, a failed compare-and-swap doesn't apply the transaction's writes either.
+    standalone := NewDummyApp(NewSimpleMap()).(*DummyApp)
+    receipt = standalone.ProcessMessage(standalone.GenerateOperationsTransaction(
+        NewPutOperation("ioo", "jar"),
+        NewCompareAndSwapOperation("foo", "bar", "qux"),
+    ))
+    if receipt.Success() || standalone.Get("ioo") != "" {
+        t.Error("writes of a standalone transaction with a failed compare-and-swap were applied")
+    }
+
+    // The key the block head is stored under can't be written by transactions.
+    sb = NewSimpleBlock(sb.Digest())
+    putHead := dummy.GenerateTransaction(map[string]string{dummyHeadKey: "forged", "joo": "kar"})
+    deleteHead := dummy.GenerateOperationsTransaction(NewDeleteOperation(dummyHeadKey))
+    sb.AddMessage(putHead)
+    sb.AddMessage(deleteHead)
+    b.ProcessBlock(sb)
+
+    for _, message := range []Message{putHead, deleteHead} {
+        receipt, err = b.Receipt(message.Hash())
+        if err != nil || receipt.Code != ReceiptRejected {
+            t.Error("write to the head key was not rejected")
+        }
+    }
+    if string(dummy.BlockHead()) != string(sb.Digest()) || dummy.Get("joo") != "" {
+        t.Error("write to the head key was applied")
+    }
+}
+
+func TestAppDummyProofs(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewDummyApp(ms)
+    b.RegisterApplication(&app)
+    dummy := app.(*DummyApp)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(dummy.GenerateTransaction(map[string]string{"foo": "bar", "goo": "tar", "hoo": "car"}))
+    b.ProcessBlock(sb)
+
+    root, err := dummy.Root()
+    if err != nil {
+        t.Fatal(err)
+    }
+    proof, proofRoot, err := dummy.GetWithProof("goo")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if proof.Value != "tar" || bytes.Compare(root, proofRoot) != 0 {
+        t.Error("proof returned the wrong value or root")
+    }
+    if !VerifyDummyProof(root, proof) {
+        t.Error("valid proof failed to verify")
+    }
+
+    proof.Value = "bar"
+    if VerifyDummyProof(root, proof) {
+        t.Error("proof of the wrong value verified")
+    }
+
+    if _, _, err := dummy.GetWithProof("ioo"); err == nil {
+        t.Error("got a proof for a missing key")
+    }
+    if proof, _, _ := dummy.GetWithProof("goo"); proof.NumLeaves != 3 {
+        t.Error("block head included in the stored key-value pairs")
+    }
+
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(dummy.GenerateOperationsTransaction(NewDeleteOperation("foo")))
+    b.ProcessBlock(sb)
+
+    newRoot, _ := dummy.Root()
+    if bytes.Compare(root, newRoot) == 0 {
+        t.Error("root did not change after a delete")
+    }
+    proof, _, _ = dummy.GetWithProof("goo")
+    if VerifyDummyProof(root, proof) || !VerifyDummyProof(newRoot, proof) {
+        t.Error("proof verified against the wrong root")
+    }
+}
+
+func TestAppDummyProofCache(t *testing.T) {
+    dummy := NewDummyApp(NewSimpleMap()).(*DummyApp)
+    var keys []string
+    for i := 0; i < 9; i++ {
+        key := string([]byte{'a' + byte(i)})
+        keys = append(keys, key)
+        dummy.ProcessMessage(dummy.GenerateTransaction(map[string]string{key: "value"}))
+
+        // The cached tree has the same root and proofs as a merkletree.Tree of the same leaves.
+        tree := merkletree.New(sha256.New())
+        for _, k := range keys {
+            tree.Push(dummyLeaf(k, "value"))
+        }
+        root, err := dummy.Root()
+        if err != nil || bytes.Compare(root, tree.Root()) != 0 {
+            t.Fatalf("root of %d keys does not match merkletree root", len(keys))
+        }
+        for _, k := range keys {
+            proof, _, err := dummy.GetWithProof(k)
+            if err != nil || !VerifyDummyProof(root, proof) {
+                t.Fatalf("proof of %s among %d keys failed to verify", k, len(keys))
+            }
+        }
+    }
+
+    cached := dummy.cachedTree
+    dummy.GetWithProof("a")
+    if cached == nil || dummy.cachedTree != cached {
+        t.Error("tree rebuilt without a write")
+    }
+    dummy.ProcessMessage(dummy.GenerateOperationsTransaction(NewDeleteOperation("a")))
+    if _, _, err := dummy.GetWithProof("a"); err == nil {
+        t.Error("cached tree not invalidated by a write")
+    }
+}
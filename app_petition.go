@@ -2,6 +2,7 @@ package lazyledger
 
 import (
     "encoding/binary"
+    "fmt"
 
     "github.com/golang/protobuf/proto"
     "github.com/libp2p/go-libp2p-crypto"
@@ -9,6 +10,7 @@ import (
 
 type PetitionApp struct {
     state MapStore
+    namespace [namespaceSize]byte
 }
 
 func NewPetitionApp(state MapStore) Application {
@@ -17,45 +19,156 @@ func NewPetitionApp(state MapStore) Application {
     }
 }
 
-func (app *PetitionApp) ProcessMessage(message Message) {
+func (app *PetitionApp) ProcessMessage(message Message) *Receipt {
     transaction := &PetitionAppTransaction{}
     err := proto.Unmarshal(message.Data(), transaction)
     if err != nil {
-        return
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
     }
     apm := transaction.GetApm()
     if apm != nil {
-        app.ProcessAddPetitionMessage(apm)
+        return app.ProcessAddPetitionMessage(apm)
     }
     spm := transaction.GetSpm()
     if spm != nil {
-        app.ProcessSignPetitionMessage(spm)
+        return app.ProcessSignPetitionMessage(spm)
     }
+    cpm := transaction.GetCpm()
+    if cpm != nil {
+        return app.ProcessClosePetitionMessage(cpm)
+    }
+    return NewFailureReceipt(ReceiptInvalidMessage, "empty transaction")
 }
 
-func (app *PetitionApp) ProcessAddPetitionMessage(apm *AddPetitionMessage) {
-    app.addPetition(*apm.Text)
+func (app *PetitionApp) ProcessAddPetitionMessage(apm *AddPetitionMessage) *Receipt {
+    record := &PetitionRecord{
+        Deadline: apm.Deadline,
+    }
+    if apm.Creator != nil {
+        // A creator can close the petition, so they must have signed it.
+        key, err := crypto.UnmarshalPublicKey(apm.Creator)
+        if err != nil {
+            return NewFailureReceipt(ReceiptInvalidSignature, "invalid creator key")
+        }
+        ok, err := key.Verify(app.addPetitionSignedData(*apm.Text, apm.GetDeadline(), apm.GetNonce()), apm.Signature)
+        if !ok || err != nil {
+            return NewFailureReceipt(ReceiptInvalidSignature, "signature does not match creator")
+        }
+        // The creator's petitions are numbered in sequence, so that adding one can't be replayed.
+        nonce := app.CreatorNonce(key)
+        if apm.GetNonce() != nonce {
+            return NewFailureReceipt(ReceiptInvalidNonce, fmt.Sprintf("expected nonce %d, got %d", nonce, apm.GetNonce()))
+        }
+        record.Creator = apm.Creator
+    }
+    if apm.GetDeadline() != 0 && apm.GetDeadline() <= app.Height() {
+        return NewFailureReceipt(ReceiptRejected, "deadline has passed")
+    }
+    if record.Creator != nil {
+        app.state.Put(app.creatorNonceKey(record.Creator), petitionId(apm.GetNonce() + 1))
+    }
+    id := app.addPetition(*apm.Text, record)
+    return NewReceipt(NewEvent("add_petition", "id", id))
 }
 
-func (app *PetitionApp) ProcessSignPetitionMessage(spm *SignPetitionMessage) {
-    key, _ := crypto.UnmarshalPublicKey(spm.Signer)
-    signedData := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(signedData, *spm.Id)
-    ok, err := key.Verify(signedData, spm.Signature)
+func (app *PetitionApp) ProcessSignPetitionMessage(spm *SignPetitionMessage) *Receipt {
+    key, err := crypto.UnmarshalPublicKey(spm.Signer)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, "invalid signer key")
+    }
+    ok, err := key.Verify(app.petitionSignedData("sign", *spm.Id), spm.Signature)
     if !ok || err != nil {
-        return
+        return NewFailureReceipt(ReceiptInvalidSignature, "signature does not match signer")
     }
+    receipt := app.checkOpen(*spm.Id)
+    if receipt != nil {
+        return receipt
+    }
+    signerKey := app.signerKey(*spm.Id, addressOf(spm.Signer))
+    if _, err := app.state.Get(signerKey); err == nil {
+        return NewFailureReceipt(ReceiptRejected, "signer has already signed the petition")
+    }
+    app.state.Put(signerKey, []byte{})
+    // Signers are also listed in the order they signed, so they can be paged through.
+    app.state.Put(app.signerIndexKey(*spm.Id, app.Petition(*spm.Id)), spm.Signer)
     app.incrementPetition(*spm.Id)
+    return NewReceipt(NewEvent("sign_petition", "id", *spm.Id, "signer", spm.Signer))
+}
+
+func (app *PetitionApp) ProcessClosePetitionMessage(cpm *ClosePetitionMessage) *Receipt {
+    receipt := app.checkOpen(*cpm.Id)
+    if receipt != nil {
+        return receipt
+    }
+    record := app.petitionRecord(*cpm.Id)
+    if record.Creator == nil {
+        return NewFailureReceipt(ReceiptUnauthorized, "petition has no creator")
+    }
+    key, err := crypto.UnmarshalPublicKey(record.Creator)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, "invalid creator key")
+    }
+    ok, err := key.Verify(app.petitionSignedData("close", *cpm.Id), cpm.Signature)
+    if !ok || err != nil {
+        return NewFailureReceipt(ReceiptUnauthorized, "signature does not match creator")
+    }
+    app.state.Put(append([]byte("closed__"), petitionId(*cpm.Id)...), []byte{})
+    return NewReceipt(NewEvent("close_petition", "id", *cpm.Id))
+}
+
+// checkOpen returns a failure receipt if a petition doesn't exist or can no longer be signed, or nil if it can.
+func (app *PetitionApp) checkOpen(petition uint64) *Receipt {
+    if !app.Exists(petition) {
+        return NewFailureReceipt(ReceiptRejected, fmt.Sprintf("unknown petition %d", petition))
+    }
+    if app.Closed(petition) {
+        return NewFailureReceipt(ReceiptRejected, fmt.Sprintf("petition %d is closed", petition))
+    }
+    return nil
+}
+
+// petitionSignedData returns the data signed to act on a petition, which binds the signature to the action and the app's namespace.
+func (app *PetitionApp) petitionSignedData(action string, petition uint64) []byte {
+    namespace := app.Namespace()
+    signedData := append(namespace[:], []byte(action)...)
+    return append(signedData, petitionId(petition)...)
+}
+
+func (app *PetitionApp) addPetitionSignedData(text string, deadline uint64, nonce uint64) []byte {
+    signedData := app.petitionSignedData("add", deadline)
+    signedData = append(signedData, petitionId(nonce)...)
+    return append(signedData, []byte(text)...)
 }
 
 func (app *PetitionApp) Namespace() [namespaceSize]byte {
-    var namespace [namespaceSize]byte
-    copy(namespace[:], []byte("pet"))
-    return namespace
+    var empty [namespaceSize]byte
+    if app.namespace == empty {
+        var namespace [namespaceSize]byte
+        copy(namespace[:], []byte("pet"))
+        return namespace
+    }
+    return app.namespace
+}
+
+func (app *PetitionApp) SetNamespace(namespace [namespaceSize]byte) {
+    app.namespace = namespace
 }
 
 func (app *PetitionApp) SetBlockHead(hash []byte) {
     app.state.Put([]byte("__head__"), hash)
+
+    heightBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(heightBytes, app.Height() + 1)
+    app.state.Put([]byte("__height__"), heightBytes)
+}
+
+// Height returns the number of blocks that the app has processed as the head of the chain.
+func (app *PetitionApp) Height() uint64 {
+    height, err := app.state.Get([]byte("__height__"))
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(height)
 }
 
 func (app *PetitionApp) BlockHead() []byte {
@@ -63,10 +176,16 @@ func (app *PetitionApp) BlockHead() []byte {
     return head
 }
 
+func (app *PetitionApp) State() MapStore {
+    return app.state
+}
+
+func (app *PetitionApp) SetState(state MapStore) {
+    app.state = state
+}
+
 func (app *PetitionApp) Petition(petition uint64) uint64 {
-    id := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(id, petition)
-    c, err := app.state.Get(id)
+    c, err := app.state.Get(petitionId(petition))
     if err != nil {
         return 0
     }
@@ -77,30 +196,146 @@ func (app *PetitionApp) incrementPetition(petition uint64) {
     v := app.Petition(petition)
     newValue := make([]byte, binary.MaxVarintLen64)
     binary.BigEndian.PutUint64(newValue, v + 1)
+    app.state.Put(petitionId(petition), newValue)
+}
 
-    id := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(id, petition)
-    app.state.Put(id, newValue)
+// Exists returns true if a petition has been added.
+func (app *PetitionApp) Exists(petition uint64) bool {
+    _, err := app.state.Get(append([]byte("text__"), petitionId(petition)...))
+    return err == nil
+}
+
+// Closed returns true if a petition has been closed by its creator or its deadline has passed.
+func (app *PetitionApp) Closed(petition uint64) bool {
+    if _, err := app.state.Get(append([]byte("closed__"), petitionId(petition)...)); err == nil {
+        return true
+    }
+    deadline := app.petitionRecord(petition).GetDeadline()
+    return deadline != 0 && app.Height() > deadline
+}
+
+// Signed returns true if a public key has signed a petition.
+func (app *PetitionApp) Signed(petition uint64, signer crypto.PubKey) bool {
+    signerBytes, _ := signer.Bytes()
+    _, err := app.state.Get(app.signerKey(petition, addressOf(signerBytes)))
+    return err == nil
+}
+
+// Text returns the text of a petition, or an empty string if it doesn't exist.
+func (app *PetitionApp) Text(petition uint64) string {
+    text, err := app.state.Get(append([]byte("text__"), petitionId(petition)...))
+    if err != nil {
+        return ""
+    }
+    return string(text)
+}
+
+// Creator returns the public key of a petition's creator, or nil if it has none.
+func (app *PetitionApp) Creator(petition uint64) crypto.PubKey {
+    creator := app.petitionRecord(petition).Creator
+    if creator == nil {
+        return nil
+    }
+    key, err := crypto.UnmarshalPublicKey(creator)
+    if err != nil {
+        return nil
+    }
+    return key
+}
+
+// CreatorNonce returns the nonce that the next petition added by a creator must have.
+func (app *PetitionApp) CreatorNonce(creator crypto.PubKey) uint64 {
+    creatorBytes, _ := creator.Bytes()
+    nonce, err := app.state.Get(app.creatorNonceKey(creatorBytes))
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(nonce)
+}
+
+// Deadline returns the height after which a petition can't be signed, or 0 if it has no deadline.
+func (app *PetitionApp) Deadline(petition uint64) uint64 {
+    return app.petitionRecord(petition).GetDeadline()
+}
+
+// Signers returns up to limit of the public keys that signed a petition, in the order they signed, starting from offset.
+func (app *PetitionApp) Signers(petition uint64, offset uint64, limit uint64) []crypto.PubKey {
+    var signers []crypto.PubKey
+    count := app.Petition(petition)
+    for i := offset; i < count && uint64(len(signers)) < limit; i++ {
+        signer, err := app.state.Get(app.signerIndexKey(petition, i))
+        if err != nil {
+            continue
+        }
+        key, err := crypto.UnmarshalPublicKey(signer)
+        if err != nil {
+            continue
+        }
+        signers = append(signers, key)
+    }
+    return signers
 }
 
-func (app *PetitionApp) addPetition(text string) {
+// ListPetitions returns the IDs of all petitions that have been added, in the order they were added.
+func (app *PetitionApp) ListPetitions() []uint64 {
+    var petitions []uint64
+    last := app.lastPetition()
+    for id := uint64(1); id <= last; id++ {
+        petitions = append(petitions, id)
+    }
+    return petitions
+}
+
+func (app *PetitionApp) lastPetition() uint64 {
     latestIdBytes, err := app.state.Get([]byte("__last__"))
-    var latestId uint64
     if err != nil {
-        latestId = 0
-    } else {
-        latestId = binary.BigEndian.Uint64(latestIdBytes)
+        return 0
+    }
+    return binary.BigEndian.Uint64(latestIdBytes)
+}
+
+func (app *PetitionApp) signerIndexKey(petition uint64, index uint64) []byte {
+    key := append([]byte("signers__"), petitionId(petition)...)
+    return append(key, petitionId(index)...)
+}
+
+func (app *PetitionApp) creatorNonceKey(creator []byte) []byte {
+    return append([]byte("nonce__"), addressOf(creator)...)
+}
+
+func (app *PetitionApp) signerKey(petition uint64, signer []byte) []byte {
+    key := append([]byte("signer__"), petitionId(petition)...)
+    return append(key, signer...)
+}
+
+func (app *PetitionApp) petitionRecord(petition uint64) *PetitionRecord {
+    record := &PetitionRecord{}
+    value, err := app.state.Get(append([]byte("record__"), petitionId(petition)...))
+    if err == nil {
+        proto.Unmarshal(value, record)
     }
+    return record
+}
+
+func petitionId(petition uint64) []byte {
+    id := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(id, petition)
+    return id
+}
+
+func (app *PetitionApp) addPetition(text string, record *PetitionRecord) uint64 {
+    latestId := app.lastPetition()
     newValue := make([]byte, binary.MaxVarintLen64)
     binary.BigEndian.PutUint64(newValue, latestId + 1)
     app.state.Put([]byte("__last__"), newValue)
     app.state.Put(append([]byte("text__"), newValue...), []byte(text))
+    recordBytes, _ := proto.Marshal(record)
+    app.state.Put(append([]byte("record__"), newValue...), recordBytes)
+    return latestId + 1
 }
 
 func (app *PetitionApp) GenerateSignPetitionTransaction(key crypto.PrivKey, petition uint64) Message {
-    signedData := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(signedData, petition)
-    sig, _ := key.Sign(signedData)
+    sig, _ := key.Sign(app.petitionSignedData("sign", petition))
     kb, _ := key.GetPublic().Bytes()
     spm := &SignPetitionMessage{
         Id: &petition,
@@ -126,3 +361,39 @@ func (app *PetitionApp) GenerateAddPetitionTransaction(text string) Message {
     d, _ := proto.Marshal(t)
     return *NewMessage(app.Namespace(), d)
 }
+
+// GenerateCreatePetitionTransaction generates a transaction that adds a petition that its creator can close.
+// If deadline is not 0, the petition can't be signed after the block at that height.
+// The nonce must be the creator's CreatorNonce when the transaction is processed.
+func (app *PetitionApp) GenerateCreatePetitionTransaction(creator crypto.PrivKey, text string, deadline uint64, nonce uint64) Message {
+    sig, _ := creator.Sign(app.addPetitionSignedData(text, deadline, nonce))
+    kb, _ := creator.GetPublic().Bytes()
+    apm := &AddPetitionMessage{
+        Text: &text,
+        Creator: kb,
+        Signature: sig,
+        Nonce: &nonce,
+    }
+    if deadline != 0 {
+        apm.Deadline = &deadline
+    }
+    t := &PetitionAppTransaction{
+        Message: &PetitionAppTransaction_Apm{Apm: apm},
+    }
+    d, _ := proto.Marshal(t)
+    return *NewMessage(app.Namespace(), d)
+}
+
+// GenerateClosePetitionTransaction generates a transaction that closes a petition, signed by its creator.
+func (app *PetitionApp) GenerateClosePetitionTransaction(creator crypto.PrivKey, petition uint64) Message {
+    sig, _ := creator.Sign(app.petitionSignedData("close", petition))
+    cpm := &ClosePetitionMessage{
+        Id: &petition,
+        Signature: sig,
+    }
+    t := &PetitionAppTransaction{
+        Message: &PetitionAppTransaction_Cpm{Cpm: cpm},
+    }
+    d, _ := proto.Marshal(t)
+    return *NewMessage(app.Namespace(), d)
+}
@@ -24,6 +24,7 @@ type PetitionAppTransaction struct {
 	// Types that are valid to be assigned to Message:
 	//	*PetitionAppTransaction_Apm
 	//	*PetitionAppTransaction_Spm
+	//	*PetitionAppTransaction_Cpm
 	Message              isPetitionAppTransaction_Message `protobuf_oneof:"message"`
 	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
 	XXX_unrecognized     []byte                           `json:"-"`
@@ -67,10 +68,16 @@ type PetitionAppTransaction_Spm struct {
 	Spm *SignPetitionMessage `protobuf:"bytes,2,opt,name=spm,oneof"`
 }
 
+type PetitionAppTransaction_Cpm struct {
+	Cpm *ClosePetitionMessage `protobuf:"bytes,3,opt,name=cpm,oneof"`
+}
+
 func (*PetitionAppTransaction_Apm) isPetitionAppTransaction_Message() {}
 
 func (*PetitionAppTransaction_Spm) isPetitionAppTransaction_Message() {}
 
+func (*PetitionAppTransaction_Cpm) isPetitionAppTransaction_Message() {}
+
 func (m *PetitionAppTransaction) GetMessage() isPetitionAppTransaction_Message {
 	if m != nil {
 		return m.Message
@@ -92,16 +99,28 @@ func (m *PetitionAppTransaction) GetSpm() *SignPetitionMessage {
 	return nil
 }
 
+func (m *PetitionAppTransaction) GetCpm() *ClosePetitionMessage {
+	if x, ok := m.GetMessage().(*PetitionAppTransaction_Cpm); ok {
+		return x.Cpm
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*PetitionAppTransaction) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*PetitionAppTransaction_Apm)(nil),
 		(*PetitionAppTransaction_Spm)(nil),
+		(*PetitionAppTransaction_Cpm)(nil),
 	}
 }
 
 type AddPetitionMessage struct {
 	Text                 *string  `protobuf:"bytes,1,req,name=text" json:"text,omitempty"`
+	Creator              []byte   `protobuf:"bytes,2,opt,name=creator" json:"creator,omitempty"`
+	Deadline             *uint64  `protobuf:"varint,3,opt,name=deadline" json:"deadline,omitempty"`
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature" json:"signature,omitempty"`
+	Nonce                *uint64  `protobuf:"varint,5,opt,name=nonce" json:"nonce,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -139,6 +158,34 @@ func (m *AddPetitionMessage) GetText() string {
 	return ""
 }
 
+func (m *AddPetitionMessage) GetCreator() []byte {
+	if m != nil {
+		return m.Creator
+	}
+	return nil
+}
+
+func (m *AddPetitionMessage) GetDeadline() uint64 {
+	if m != nil && m.Deadline != nil {
+		return *m.Deadline
+	}
+	return 0
+}
+
+func (m *AddPetitionMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *AddPetitionMessage) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
 type SignPetitionMessage struct {
 	Id                   *uint64  `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
 	Signature            []byte   `protobuf:"bytes,2,req,name=signature" json:"signature,omitempty"`
@@ -194,28 +241,130 @@ func (m *SignPetitionMessage) GetSigner() []byte {
 	return nil
 }
 
+type ClosePetitionMessage struct {
+	Id                   *uint64  `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
+	Signature            []byte   `protobuf:"bytes,2,req,name=signature" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClosePetitionMessage) Reset()         { *m = ClosePetitionMessage{} }
+func (m *ClosePetitionMessage) String() string { return proto.CompactTextString(m) }
+func (*ClosePetitionMessage) ProtoMessage()    {}
+func (*ClosePetitionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e792406857c1b4a0, []int{3}
+}
+
+func (m *ClosePetitionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClosePetitionMessage.Unmarshal(m, b)
+}
+func (m *ClosePetitionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClosePetitionMessage.Marshal(b, m, deterministic)
+}
+func (m *ClosePetitionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClosePetitionMessage.Merge(m, src)
+}
+func (m *ClosePetitionMessage) XXX_Size() int {
+	return xxx_messageInfo_ClosePetitionMessage.Size(m)
+}
+func (m *ClosePetitionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClosePetitionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClosePetitionMessage proto.InternalMessageInfo
+
+func (m *ClosePetitionMessage) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *ClosePetitionMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type PetitionRecord struct {
+	Creator              []byte   `protobuf:"bytes,1,opt,name=creator" json:"creator,omitempty"`
+	Deadline             *uint64  `protobuf:"varint,2,opt,name=deadline" json:"deadline,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PetitionRecord) Reset()         { *m = PetitionRecord{} }
+func (m *PetitionRecord) String() string { return proto.CompactTextString(m) }
+func (*PetitionRecord) ProtoMessage()    {}
+func (*PetitionRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e792406857c1b4a0, []int{4}
+}
+
+func (m *PetitionRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PetitionRecord.Unmarshal(m, b)
+}
+func (m *PetitionRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PetitionRecord.Marshal(b, m, deterministic)
+}
+func (m *PetitionRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PetitionRecord.Merge(m, src)
+}
+func (m *PetitionRecord) XXX_Size() int {
+	return xxx_messageInfo_PetitionRecord.Size(m)
+}
+func (m *PetitionRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_PetitionRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PetitionRecord proto.InternalMessageInfo
+
+func (m *PetitionRecord) GetCreator() []byte {
+	if m != nil {
+		return m.Creator
+	}
+	return nil
+}
+
+func (m *PetitionRecord) GetDeadline() uint64 {
+	if m != nil && m.Deadline != nil {
+		return *m.Deadline
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*PetitionAppTransaction)(nil), "lazyledger.PetitionAppTransaction")
 	proto.RegisterType((*AddPetitionMessage)(nil), "lazyledger.AddPetitionMessage")
 	proto.RegisterType((*SignPetitionMessage)(nil), "lazyledger.SignPetitionMessage")
+	proto.RegisterType((*ClosePetitionMessage)(nil), "lazyledger.ClosePetitionMessage")
+	proto.RegisterType((*PetitionRecord)(nil), "lazyledger.PetitionRecord")
 }
 
 func init() { proto.RegisterFile("app_petition.proto", fileDescriptor_e792406857c1b4a0) }
 
 var fileDescriptor_e792406857c1b4a0 = []byte{
-	// 216 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x8c, 0xb1, 0x4a, 0x04, 0x31,
-	0x10, 0x86, 0xdd, 0xec, 0xa1, 0xec, 0x28, 0x16, 0x23, 0x1c, 0x29, 0x44, 0x97, 0xad, 0x52, 0x6d,
-	0x71, 0x3e, 0xc1, 0x59, 0xd9, 0x08, 0x12, 0xed, 0x2c, 0x24, 0x98, 0x21, 0x04, 0x2e, 0xd9, 0x21,
-	0x89, 0xa0, 0x3e, 0x83, 0x0f, 0x2d, 0x1b, 0x4f, 0x4e, 0x70, 0xbb, 0xf9, 0xe6, 0xff, 0xbf, 0x1f,
-	0xd0, 0x30, 0xbf, 0x30, 0x15, 0x5f, 0xfc, 0x14, 0x47, 0x4e, 0x53, 0x99, 0x10, 0x76, 0xe6, 0xf3,
-	0x63, 0x47, 0xd6, 0x51, 0x1a, 0xbe, 0x1a, 0x58, 0x3f, 0xec, 0xe3, 0x2d, 0xf3, 0x53, 0x32, 0x31,
-	0x9b, 0xd7, 0x99, 0x70, 0x03, 0xad, 0xe1, 0x20, 0x9b, 0xbe, 0x51, 0xa7, 0x9b, 0xab, 0xf1, 0x20,
-	0x8d, 0x5b, 0x6b, 0x7f, 0x9d, 0x7b, 0xca, 0xd9, 0x38, 0xba, 0x3b, 0xd2, 0x73, 0x19, 0x6f, 0xa0,
-	0xcd, 0x1c, 0xa4, 0xa8, 0xce, 0xf5, 0x5f, 0xe7, 0xd1, 0xbb, 0xb8, 0x20, 0x65, 0x0e, 0xb7, 0x1d,
-	0x9c, 0x84, 0x9f, 0xcf, 0xa0, 0x00, 0xff, 0x8f, 0x23, 0xc2, 0xaa, 0xd0, 0x7b, 0x91, 0x4d, 0x2f,
-	0x54, 0xa7, 0xeb, 0x3d, 0x3c, 0xc3, 0xc5, 0xc2, 0x24, 0x9e, 0x83, 0xf0, 0xb6, 0x16, 0x57, 0x5a,
-	0x78, 0x8b, 0x97, 0xd0, 0x65, 0xef, 0xa2, 0x29, 0x6f, 0x89, 0xa4, 0xe8, 0x85, 0x3a, 0xd3, 0x87,
-	0x07, 0xae, 0xe1, 0x78, 0x06, 0x4a, 0xb2, 0xad, 0xd1, 0x9e, 0xbe, 0x03, 0x00, 0x00, 0xff, 0xff,
-	0x85, 0x47, 0xe6, 0xf1, 0x36, 0x01, 0x00, 0x00,
+	// 309 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x90, 0xc1, 0x4a, 0x03, 0x31,
+	0x10, 0x86, 0xdd, 0xec, 0xd6, 0xda, 0x51, 0x7a, 0x18, 0x4b, 0x09, 0x22, 0xba, 0xec, 0xa9, 0xa7,
+	0x1e, 0xaa, 0x2f, 0x50, 0x15, 0xf1, 0x22, 0xc8, 0xea, 0xcd, 0x83, 0x84, 0xcd, 0xb0, 0x04, 0x76,
+	0x93, 0x90, 0x44, 0x50, 0x1f, 0xc3, 0x47, 0xf2, 0xc9, 0xa4, 0x69, 0x6b, 0x5b, 0x5c, 0x0f, 0xde,
+	0xf2, 0xcf, 0xe4, 0xfb, 0xf9, 0xe7, 0x07, 0x14, 0xd6, 0xbe, 0x58, 0x0a, 0x2a, 0x28, 0xa3, 0xa7,
+	0xd6, 0x99, 0x60, 0x10, 0x1a, 0xf1, 0xf1, 0xde, 0x90, 0xac, 0xc9, 0x15, 0x5f, 0x09, 0x8c, 0x1f,
+	0x56, 0xeb, 0xb9, 0xb5, 0x4f, 0x4e, 0x68, 0x2f, 0xaa, 0x85, 0xc2, 0x19, 0xa4, 0xc2, 0xb6, 0x3c,
+	0xc9, 0x93, 0xc9, 0xe1, 0xec, 0x6c, 0xba, 0x81, 0xa6, 0x73, 0x29, 0xd7, 0xcc, 0x3d, 0x79, 0x2f,
+	0x6a, 0xba, 0xdb, 0x2b, 0x17, 0x9f, 0xf1, 0x02, 0x52, 0x6f, 0x5b, 0xce, 0x22, 0x73, 0xbe, 0xcd,
+	0x3c, 0xaa, 0x5a, 0x77, 0x40, 0xde, 0xb6, 0x78, 0x09, 0x69, 0x65, 0x5b, 0x9e, 0x46, 0x28, 0xdf,
+	0x86, 0xae, 0x1b, 0xe3, 0xa9, 0x83, 0xaa, 0x6c, 0x7b, 0x35, 0x80, 0x7e, 0xbb, 0x9c, 0x14, 0x9f,
+	0x09, 0xe0, 0xef, 0x4c, 0x88, 0x90, 0x05, 0x7a, 0x0b, 0x3c, 0xc9, 0xd9, 0x64, 0x50, 0xc6, 0x37,
+	0x72, 0xe8, 0x57, 0x8e, 0x44, 0x30, 0x2e, 0x86, 0x3c, 0x2a, 0xd7, 0x12, 0x4f, 0xe0, 0x40, 0x92,
+	0x90, 0x8d, 0xd2, 0x14, 0xa3, 0x64, 0xe5, 0x8f, 0xc6, 0x53, 0x18, 0x78, 0x55, 0x6b, 0x11, 0x5e,
+	0x1d, 0xf1, 0x2c, 0x72, 0x9b, 0x01, 0x8e, 0xa0, 0xa7, 0x8d, 0xae, 0x88, 0xf7, 0x22, 0xb6, 0x14,
+	0xc5, 0x33, 0x1c, 0x77, 0xdc, 0x8c, 0x43, 0x60, 0x4a, 0xc6, 0x48, 0x59, 0xc9, 0x94, 0xdc, 0xb5,
+	0x66, 0x39, 0xdb, 0xb5, 0x1e, 0xc3, 0xfe, 0x42, 0x90, 0xe3, 0x69, 0x5c, 0xad, 0x54, 0x71, 0x03,
+	0xa3, 0xae, 0x6e, 0xfe, 0xe7, 0x5e, 0xdc, 0xc2, 0x70, 0x6d, 0x50, 0x52, 0x65, 0x9c, 0xdc, 0xae,
+	0x27, 0xf9, 0xbb, 0x1e, 0xb6, 0x5b, 0xcf, 0x77, 0x00, 0x00, 0x00, 0xff, 0xff, 0x78, 0xee, 0x7c,
+	0x39, 0x65, 0x02, 0x00, 0x00,
 }
@@ -20,10 +20,176 @@ func TestAppPetitionSimpleBlock(t *testing.T) {
     privA, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
 
     sb.AddMessage(app.(*PetitionApp).GenerateAddPetitionTransaction("foo"))
-    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 0))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 1))
     b.ProcessBlock(sb)
 
-    if app.(*PetitionApp).Petition(0) != 1 {
+    if app.(*PetitionApp).Petition(1) != 1 {
         t.Error("failed to sign petition")
     }
 }
+
+func TestAppPetitionSignatures(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    sb := NewSimpleBlock([]byte{0})
+
+    ms := NewSimpleMap()
+    app := NewPetitionApp(ms)
+    b.RegisterApplication(&app)
+
+    ms2 := NewSimpleMap()
+    otherApp := NewPetitionApp(ms2)
+    var otherNamespace [namespaceSize]byte
+    copy(otherNamespace[:], []byte("pet2"))
+    otherApp.(*PetitionApp).SetNamespace(otherNamespace)
+    b.RegisterApplication(&otherApp)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+
+    sb.AddMessage(app.(*PetitionApp).GenerateAddPetitionTransaction("foo"))
+    sb.AddMessage(otherApp.(*PetitionApp).GenerateAddPetitionTransaction("foo"))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privB, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 2))
+    // A signature made for one deployment of the app doesn't count in another.
+    replayed := app.(*PetitionApp).GenerateSignPetitionTransaction(privB, 1)
+    replayed.namespace = otherNamespace
+    sb.AddMessage(replayed)
+    b.ProcessBlock(sb)
+
+    if app.(*PetitionApp).Petition(1) != 2 || !app.(*PetitionApp).Signed(1, pubA) {
+        t.Error("expected one signature per signer")
+    }
+    if app.(*PetitionApp).Petition(2) != 0 || app.(*PetitionApp).Exists(2) {
+        t.Error("signed a petition that doesn't exist")
+    }
+    if otherApp.(*PetitionApp).Petition(1) != 0 {
+        t.Error("signature was replayed in another namespace")
+    }
+}
+
+func TestAppPetitionClosing(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewPetitionApp(ms)
+    b.RegisterApplication(&app)
+
+    privCreator, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privA, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+
+    // Height 1: petition 1 can be closed by its creator, and petition 2 can be signed until height 2.
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(app.(*PetitionApp).GenerateCreatePetitionTransaction(privCreator, "foo", 0, 0))
+    sb.AddMessage(app.(*PetitionApp).GenerateCreatePetitionTransaction(privCreator, "bar", 2, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateClosePetitionTransaction(privA, 1))
+    b.ProcessBlock(sb)
+    if app.(*PetitionApp).Closed(1) {
+        t.Error("petition was closed by someone other than its creator")
+    }
+
+    // Height 2: the creator closes petition 1.
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(app.(*PetitionApp).GenerateClosePetitionTransaction(privCreator, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privB, 1))
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privA, 2))
+    b.ProcessBlock(sb)
+
+    // Height 3: petition 2's deadline has passed.
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(privB, 2))
+    b.ProcessBlock(sb)
+
+    if !app.(*PetitionApp).Closed(1) || app.(*PetitionApp).Petition(1) != 1 {
+        t.Error("closed petition was signed")
+    }
+    if !app.(*PetitionApp).Closed(2) || app.(*PetitionApp).Petition(2) != 1 {
+        t.Error("petition was signed after its deadline")
+    }
+}
+
+func TestAppPetitionReplay(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewPetitionApp(ms)
+    b.RegisterApplication(&app)
+    petitionApp := app.(*PetitionApp)
+
+    privCreator, pubCreator, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    create := petitionApp.GenerateCreatePetitionTransaction(privCreator, "foo", 0, 0)
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(create)
+    b.ProcessBlock(sb)
+
+    // Replaying the creator's signed transaction in a later block doesn't add the petition again.
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(create)
+    b.ProcessBlock(sb)
+
+    receipts := b.BlockReceipts(sb.Digest())
+    if len(receipts) != 1 || receipts[0].Code != ReceiptInvalidNonce {
+        t.Error("replayed petition was not rejected")
+    }
+    if len(petitionApp.ListPetitions()) != 1 || petitionApp.CreatorNonce(pubCreator) != 1 {
+        t.Error("replayed petition was added")
+    }
+
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(petitionApp.GenerateCreatePetitionTransaction(privCreator, "foo", 0, 1))
+    b.ProcessBlock(sb)
+    if len(petitionApp.ListPetitions()) != 2 {
+        t.Error("petition with the next nonce was not added")
+    }
+}
+
+func TestAppPetitionQueries(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    sb := NewSimpleBlock([]byte{0})
+
+    ms := NewSimpleMap()
+    app := NewPetitionApp(ms)
+    b.RegisterApplication(&app)
+
+    privCreator, pubCreator, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    var signers []crypto.PubKey
+    sb.AddMessage(app.(*PetitionApp).GenerateAddPetitionTransaction("foo"))
+    sb.AddMessage(app.(*PetitionApp).GenerateCreatePetitionTransaction(privCreator, "bar", 10, 0))
+    for i := 0; i < 5; i++ {
+        priv, pub, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+        signers = append(signers, pub)
+        sb.AddMessage(app.(*PetitionApp).GenerateSignPetitionTransaction(priv, 2))
+    }
+    b.ProcessBlock(sb)
+
+    petitions := app.(*PetitionApp).ListPetitions()
+    if len(petitions) != 2 || petitions[0] != 1 || petitions[1] != 2 {
+        t.Error("failed to list petitions")
+    }
+    if app.(*PetitionApp).Text(1) != "foo" || app.(*PetitionApp).Text(2) != "bar" || app.(*PetitionApp).Text(3) != "" {
+        t.Error("failed to get petition text")
+    }
+    if app.(*PetitionApp).Creator(1) != nil || !app.(*PetitionApp).Creator(2).Equals(pubCreator) || app.(*PetitionApp).Deadline(2) != 10 {
+        t.Error("failed to get petition creator")
+    }
+
+    page := app.(*PetitionApp).Signers(2, 0, 3)
+    page = append(page, app.(*PetitionApp).Signers(2, 3, 3)...)
+    if len(page) != len(signers) {
+        t.Fatalf("expected %d signers, got %d", len(signers), len(page))
+    }
+    for i, signer := range page {
+        if !signer.Equals(signers[i]) {
+            t.Error("signers are not in signing order")
+        }
+    }
+}
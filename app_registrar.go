@@ -3,16 +3,26 @@ package lazyledger
 import (
     "encoding/binary"
     "bytes"
+    "crypto/sha256"
+    "fmt"
+    "sort"
 
     "github.com/golang/protobuf/proto"
     "github.com/libp2p/go-libp2p-crypto"
 )
 
+const defaultRegistrationPrice = 5
+const defaultRegistrationPeriod = 1000
+const maxTextRecordSize = 256
+const maxCommitmentAge = 100
+
 type Registrar struct {
     state MapStore
     currency *Currency
     owner []byte
     namespace [namespaceSize]byte
+    registrationPeriod uint64
+    price RegistrationPriceFunc
 }
 
 func NewRegistrar(state MapStore, currency *Currency, owner []byte) Application {
@@ -20,40 +30,363 @@ func NewRegistrar(state MapStore, currency *Currency, owner []byte) Application
         state: state,
         currency: currency,
         owner: owner,
+        registrationPeriod: defaultRegistrationPeriod,
+        price: FlatPrice(defaultRegistrationPrice),
     }
-    currency.AddTransferCallback(app.transferCallback)
     return app
 }
 
-func (app *Registrar) ProcessMessage(message Message) {
-    transaction := &RegisterTransaction{}
+func (app *Registrar) ProcessMessage(message Message) *Receipt {
+    transaction := &RegistrarTransaction{}
     err := proto.Unmarshal(message.Data(), transaction)
     if err != nil {
-        return
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    register := transaction.GetRegister()
+    if register != nil {
+        return app.processRegister(register)
+    }
+    transfer := transaction.GetTransfer()
+    if transfer != nil {
+        return app.processTransfer(transfer)
+    }
+    renew := transaction.GetRenew()
+    if renew != nil {
+        return app.processRenew(renew)
+    }
+    updateRecord := transaction.GetUpdateRecord()
+    if updateRecord != nil {
+        return app.processUpdateRecord(updateRecord)
+    }
+    withdraw := transaction.GetWithdraw()
+    if withdraw != nil {
+        return app.processWithdraw(withdraw)
+    }
+    commit := transaction.GetCommit()
+    if commit != nil {
+        return app.processCommit(commit)
+    }
+    return NewFailureReceipt(ReceiptInvalidMessage, "empty transaction")
+}
+
+func (app *Registrar) processCommit(transaction *CommitNameTransaction) *Receipt {
+    if len(transaction.Commitment) != sha256.Size {
+        return NewFailureReceipt(ReceiptInvalidMessage, "invalid commitment")
+    }
+    commitmentKey := append([]byte("commitment__"), transaction.Commitment...)
+    if _, err := app.state.Get(commitmentKey); err == nil {
+        return NewFailureReceipt(ReceiptRejected, "commitment already exists")
+    }
+    err := app.putUint64(commitmentKey, app.Height())
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    err = app.schedule("commitmentexpiry__", app.Height() + maxCommitmentAge, transaction.Commitment)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return NewReceipt(NewEvent("commit", "commitment", transaction.Commitment))
+}
+
+func (app *Registrar) processRegister(transaction *RegisterTransaction) *Receipt {
+    // A name can only be registered by revealing a commitment to it that was made in an earlier block,
+    // so a registration can't be front-run by someone who copies the name from it.
+    commitment := app.Commitment(transaction.Name, transaction.Owner, transaction.Salt)
+    commitmentKey := append([]byte("commitment__"), commitment...)
+    if _, err := app.state.Get(commitmentKey); err != nil {
+        return NewFailureReceipt(ReceiptRejected, "no commitment to name")
+    }
+    committed := app.getUint64(commitmentKey)
+    if committed >= app.Height() {
+        return NewFailureReceipt(ReceiptRejected, "commitment must be made in an earlier block")
+    }
+    if committed + maxCommitmentAge < app.Height() {
+        return NewFailureReceipt(ReceiptRejected, "commitment has expired")
     }
     transactionMessage := &RegisterTransactionMessage{
         Name: transaction.Name,
+        Salt: transaction.Salt,
+    }
+    signedData, err := app.signedData(transactionMessage)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
     }
-    signedData, err := proto.Marshal(transactionMessage)
-    ownerKey, err := crypto.UnmarshalPublicKey(transaction.Owner)
-    ok, err := ownerKey.Verify(signedData, transaction.Signature)
-    if !ok {
-        return
+    receipt := app.verify(transaction.Owner, transaction.PublicKey, signedData, transaction.Signature)
+    if receipt != nil {
+        return receipt
     }
     if bytes.Compare(app.Name(transaction.Name), []byte{}) != 0 { // check name is available
-        return
+        return NewFailureReceipt(ReceiptRejected, "name is already registered")
     }
 
     // check and subtract balance
-    balance := app.Balance(transaction.Owner)
-    if balance < 5 {
-        return
+    receipt = app.charge(transaction.Owner, transaction.Name)
+    if receipt != nil {
+        return receipt
+    }
+    err = app.state.Del(commitmentKey)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+
+    record := &NameRecord{
+        Owner: transaction.Owner,
+        Expiry: proto.Uint64(app.Height() + app.registrationPeriod),
+        Sequence: proto.Uint64(0),
+    }
+    receipt = app.putRecord(transaction.Name, record)
+    if receipt != nil {
+        return receipt
+    }
+    return NewReceipt(NewEvent("register", "name", transaction.Name, "owner", transaction.Owner, "expiry", *record.Expiry))
+}
+
+func (app *Registrar) processTransfer(transaction *TransferNameTransaction) *Receipt {
+    record := app.record(transaction.Name)
+    if record == nil {
+        return NewFailureReceipt(ReceiptRejected, "name is not registered")
+    }
+    if len(transaction.NewOwner) != addressSize {
+        return NewFailureReceipt(ReceiptInvalidMessage, ErrInvalidAddress.Error())
+    }
+    // The record's expiry and sequence are signed so that the transfer can't be replayed once the name changes hands again.
+    transactionMessage := &TransferNameTransactionMessage{
+        Name: transaction.Name,
+        NewOwner: transaction.NewOwner,
+        Expiry: record.Expiry,
+        Sequence: record.Sequence,
+    }
+    signedData, err := app.signedData(transactionMessage)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    receipt := app.verify(record.Owner, transaction.PublicKey, signedData, transaction.Signature)
+    if receipt != nil {
+        return receipt
+    }
+
+    previousOwner := record.Owner
+    record.Owner = transaction.NewOwner
+    record.Sequence = proto.Uint64(*record.Sequence + 1)
+    receipt = app.putRecord(transaction.Name, record)
+    if receipt != nil {
+        return receipt
+    }
+    return NewReceipt(NewEvent("transfer_name", "name", transaction.Name, "from", previousOwner, "to", transaction.NewOwner))
+}
+
+func (app *Registrar) processRenew(transaction *RenewNameTransaction) *Receipt {
+    record := app.record(transaction.Name)
+    if record == nil {
+        return NewFailureReceipt(ReceiptRejected, "name is not registered")
+    }
+    transactionMessage := &RenewNameTransactionMessage{
+        Name: transaction.Name,
+        Expiry: record.Expiry,
+        Sequence: record.Sequence,
+    }
+    signedData, err := app.signedData(transactionMessage)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    receipt := app.verify(record.Owner, transaction.PublicKey, signedData, transaction.Signature)
+    if receipt != nil {
+        return receipt
+    }
+    receipt = app.charge(record.Owner, transaction.Name)
+    if receipt != nil {
+        return receipt
+    }
+
+    record.Expiry = proto.Uint64(*record.Expiry + app.registrationPeriod)
+    record.Sequence = proto.Uint64(*record.Sequence + 1)
+    receipt = app.putRecord(transaction.Name, record)
+    if receipt != nil {
+        return receipt
+    }
+    return NewReceipt(NewEvent("renew", "name", transaction.Name, "expiry", *record.Expiry))
+}
+
+func (app *Registrar) processUpdateRecord(transaction *UpdateRecordTransaction) *Receipt {
+    record := app.record(transaction.Name)
+    if record == nil {
+        return NewFailureReceipt(ReceiptRejected, "name is not registered")
+    }
+    err := validateTypedRecord(*transaction.Type, transaction.Value)
+    if err != nil {
+        return NewFailureReceipt(ReceiptRejected, err.Error())
+    }
+    transactionMessage := &UpdateRecordTransactionMessage{
+        Name: transaction.Name,
+        Type: transaction.Type,
+        Value: transaction.Value,
+        Expiry: record.Expiry,
+        Sequence: record.Sequence,
+    }
+    signedData, err := app.signedData(transactionMessage)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    receipt := app.verify(record.Owner, transaction.PublicKey, signedData, transaction.Signature)
+    if receipt != nil {
+        return receipt
+    }
+
+    // Records are kept sorted by type, with at most one of each; an empty value deletes the record.
+    var records []*TypedRecord
+    for _, typedRecord := range record.Records {
+        if *typedRecord.Type != *transaction.Type {
+            records = append(records, typedRecord)
+        }
+    }
+    if len(transaction.Value) > 0 {
+        records = append(records, &TypedRecord{
+            Type: transaction.Type,
+            Value: transaction.Value,
+        })
+        sort.Slice(records, func(i, j int) bool {
+            return *records[i].Type < *records[j].Type
+        })
+    }
+    record.Records = records
+    record.Sequence = proto.Uint64(*record.Sequence + 1)
+    receipt = app.putRecord(transaction.Name, record)
+    if receipt != nil {
+        return receipt
+    }
+    return NewReceipt(NewEvent("update_record", "name", transaction.Name, "type", transaction.Type.String(), "value", transaction.Value))
+}
+
+// validateTypedRecord checks that a record's value has the format of its type. An empty value is always valid.
+func validateTypedRecord(recordType RecordType, value []byte) error {
+    if len(value) == 0 {
+        return nil
+    }
+    switch recordType {
+    case RecordType_ADDRESS:
+        if len(value) != addressSize {
+            return ErrInvalidAddress
+        }
+    case RecordType_NAMESPACE:
+        if len(value) != namespaceSize {
+            return fmt.Errorf("namespace record must be %d bytes", namespaceSize)
+        }
+    case RecordType_TEXT:
+        if len(value) > maxTextRecordSize {
+            return fmt.Errorf("text record must be at most %d bytes", maxTextRecordSize)
+        }
+    default:
+        return fmt.Errorf("unknown record type %d", recordType)
+    }
+    return nil
+}
+
+// signedData returns the data that is signed for a transaction message, which binds the signature to the registrar's namespace.
+func (app *Registrar) signedData(transactionMessage proto.Message) ([]byte, error) {
+    data, err := proto.Marshal(transactionMessage)
+    if err != nil {
+        return nil, err
+    }
+    namespace := app.Namespace()
+    return append(namespace[:], data...), nil
+}
+
+// Commitment returns the commitment that must be made before an address can register a name with a salt.
+func (app *Registrar) Commitment(name []byte, owner []byte, salt []byte) []byte {
+    namespace := app.Namespace()
+    data := append([]byte(nil), namespace[:]...)
+    data = appendUvarint(data, uint64(len(name)))
+    data = append(data, name...)
+    data = appendUvarint(data, uint64(len(owner)))
+    data = append(data, owner...)
+    data = append(data, salt...)
+    hash := sha256.Sum256(data)
+    return hash[:]
+}
+
+// verify checks that signedData is signed by an address, using the public key the currency knows for it or else a revealed one.
+// It returns a failure receipt if it isn't, or nil if it is.
+func (app *Registrar) verify(address []byte, revealedKey []byte, signedData []byte, signature []byte) *Receipt {
+    key, err := keyForAddress(address, app.currency.publicKey(address), revealedKey)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, err.Error())
+    }
+    ok, err := key.Verify(signedData, signature)
+    if !ok || err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, "signature does not match owner")
+    }
+    return nil
+}
+
+func (app *Registrar) processWithdraw(transaction *WithdrawTransaction) *Receipt {
+    transactionMessage := &WithdrawTransactionMessage{
+        Amount: transaction.Amount,
+        Nonce: transaction.Nonce,
+    }
+    signedData, err := app.signedData(transactionMessage)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    receipt := app.verify(transaction.Address, transaction.PublicKey, signedData, transaction.Signature)
+    if receipt != nil {
+        return receipt
+    }
+    nonce := app.nonce(transaction.Address)
+    if *transaction.Nonce != nonce {
+        return NewFailureReceipt(ReceiptInvalidNonce, fmt.Sprintf("expected nonce %d, got %d", nonce, *transaction.Nonce))
+    }
+    balance := app.Balance(transaction.Address)
+    if balance < *transaction.Amount {
+        return NewFailureReceipt(ReceiptInsufficientBalance, fmt.Sprintf("balance %d is less than amount %d", balance, *transaction.Amount))
     }
-    newBalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(newBalanceBytes, balance - 5)
-    app.state.Put(transaction.Owner, append([]byte("balance__"), newBalanceBytes...))
 
-    app.state.Put(append([]byte("name__"), transaction.Name...), transaction.Owner)
+    err = app.putUint64(append([]byte("balance__"), transaction.Address...), balance - *transaction.Amount)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    err = app.putUint64(append([]byte("nonce__"), transaction.Address...), nonce + 1)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    // The prepaid balance was paid into the owner's currency account, so the refund is owed by the owner,
+    // who pays it with a currency transfer of their own; see GenerateRefundTransaction.
+    err = app.putUint64(append([]byte("refund__"), transaction.Address...), app.Refund(transaction.Address) + *transaction.Amount)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return NewReceipt(NewEvent("withdraw", "address", transaction.Address, "amount", *transaction.Amount))
+}
+
+// charge subtracts the price of a name from an address's prepaid balance and adds it to the registrar's revenue.
+func (app *Registrar) charge(address []byte, name []byte) *Receipt {
+    price := app.price(name)
+    balance := app.Balance(address)
+    if balance < price {
+        return NewFailureReceipt(ReceiptInsufficientBalance, fmt.Sprintf("balance %d is less than price %d", balance, price))
+    }
+    err := app.putUint64(append([]byte("balance__"), address...), balance - price)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    err = app.putUint64([]byte("__revenue__"), app.Revenue() + price)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return nil
+}
+
+func (app *Registrar) putUint64(key []byte, value uint64) error {
+    valueBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(valueBytes, value)
+    return app.state.Put(key, valueBytes)
+}
+
+func (app *Registrar) getUint64(key []byte) uint64 {
+    value, err := app.state.Get(key)
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(value)
 }
 
 func (app *Registrar) Namespace() [namespaceSize]byte {
@@ -70,8 +403,68 @@ func (app *Registrar) SetNamespace(namespace [namespaceSize]byte) {
     app.namespace = namespace
 }
 
+// SetPrice sets the function that prices the registration and renewal of names, such as FlatPrice or LengthPrice.
+func (app *Registrar) SetPrice(price RegistrationPriceFunc) {
+    app.price = price
+}
+
+// Price returns the price of registering or renewing a name.
+func (app *Registrar) Price(name []byte) uint64 {
+    return app.price(name)
+}
+
+// SetRegistrationPeriod sets the number of blocks that a registration or renewal lasts for.
+func (app *Registrar) SetRegistrationPeriod(blocks uint64) {
+    app.registrationPeriod = blocks
+}
+
+// SetBlockHead sets the hash of the latest block that has been processed, advances the height and releases the names that expire at it.
 func (app *Registrar) SetBlockHead(hash []byte) {
     app.state.Put([]byte("__head__"), hash)
+
+    height := app.Height() + 1
+    heightBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(heightBytes, height)
+    app.state.Put([]byte("__height__"), heightBytes)
+
+    for _, name := range app.scheduled("expiry__", height) {
+        // The name may have been renewed since it was scheduled for release at this height.
+        record := app.storedRecord(name)
+        if record != nil && *record.Expiry == height {
+            app.state.Del(append([]byte("name__"), name...))
+        }
+    }
+    for _, commitment := range app.scheduled("commitmentexpiry__", height) {
+        // The commitment may have been revealed already.
+        app.state.Del(append([]byte("commitment__"), commitment...))
+    }
+}
+
+// schedule adds an item to a list of items to clean up at a height.
+func (app *Registrar) schedule(prefix string, height uint64, item []byte) error {
+    heightBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(heightBytes, height)
+    key := append([]byte(prefix), heightBytes...)
+    items, _ := app.state.Get(key)
+    for _, listed := range decodeNameList(items) {
+        if bytes.Compare(listed, item) == 0 {
+            return nil
+        }
+    }
+    return app.state.Put(key, appendNameList(items, item))
+}
+
+// scheduled removes and returns the list of items to clean up at a height.
+func (app *Registrar) scheduled(prefix string, height uint64) [][]byte {
+    heightBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(heightBytes, height)
+    key := append([]byte(prefix), heightBytes...)
+    items, err := app.state.Get(key)
+    if err != nil {
+        return nil
+    }
+    app.state.Del(key)
+    return decodeNameList(items)
 }
 
 func (app *Registrar) BlockHead() []byte {
@@ -79,49 +472,327 @@ func (app *Registrar) BlockHead() []byte {
     return head
 }
 
+// Height returns the number of blocks that the registrar has processed as the head of the chain.
+func (app *Registrar) Height() uint64 {
+    height, err := app.state.Get([]byte("__height__"))
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(height)
+}
+
+func (app *Registrar) State() MapStore {
+    return app.state
+}
+
+func (app *Registrar) SetState(state MapStore) {
+    app.state = state
+}
+
+// Name returns the address that owns a name, or an empty slice if it is unregistered or has expired.
 func (app *Registrar) Name(name []byte) []byte {
+    record := app.record(name)
+    if record == nil {
+        return []byte{}
+    }
+    return record.Owner
+}
+
+// Expiry returns the height at which a name expires, or 0 if it is unregistered or has expired.
+func (app *Registrar) Expiry(name []byte) uint64 {
+    record := app.record(name)
+    if record == nil {
+        return 0
+    }
+    return *record.Expiry
+}
+
+// Resolve returns the value of a name's record of a type, or nil if the name is unregistered or has no such record.
+func (app *Registrar) Resolve(name []byte, recordType RecordType) []byte {
+    record := app.record(name)
+    if record == nil {
+        return nil
+    }
+    for _, typedRecord := range record.Records {
+        if *typedRecord.Type == recordType {
+            return typedRecord.Value
+        }
+    }
+    return nil
+}
+
+// ResolveNamespace returns the application namespace ID that a name resolves to, if it has one.
+func (app *Registrar) ResolveNamespace(name []byte) ([namespaceSize]byte, bool) {
+    var namespace [namespaceSize]byte
+    value := app.Resolve(name, RecordType_NAMESPACE)
+    if value == nil {
+        return namespace, false
+    }
+    copy(namespace[:], value)
+    return namespace, true
+}
+
+// record returns the record of a name, or nil if it is unregistered or has expired.
+func (app *Registrar) record(name []byte) *NameRecord {
+    record := app.storedRecord(name)
+    if record == nil || *record.Expiry <= app.Height() {
+        return nil
+    }
+    return record
+}
+
+// storedRecord returns the stored record of a name, even if it has expired but hasn't been released yet.
+func (app *Registrar) storedRecord(name []byte) *NameRecord {
     value, err := app.state.Get(append([]byte("name__"), name...))
     if err != nil {
-        return []byte{}
+        return nil
+    }
+    record := &NameRecord{}
+    err = proto.Unmarshal(value, record)
+    if err != nil {
+        return nil
     }
-    return value
+    return record
 }
 
-func (app *Registrar) Balance(key []byte) uint64 {
-    balance, err := app.state.Get(append([]byte("balance__"), key...))
+// putRecord stores the record of a name and adds it to the list of names to release at its expiry.
+func (app *Registrar) putRecord(name []byte, record *NameRecord) *Receipt {
+    value, err := proto.Marshal(record)
     if err != nil {
-        return 0
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    err = app.state.Put(append([]byte("name__"), name...), value)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
     }
-    return binary.BigEndian.Uint64(balance)
+
+    err = app.schedule("expiry__", *record.Expiry, name)
+    if err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return nil
+}
+
+// Balance returns the prepaid balance of an address that hasn't been spent on names or withdrawn.
+func (app *Registrar) Balance(address []byte) uint64 {
+    return app.getUint64(append([]byte("balance__"), address...))
+}
+
+// Refund returns the amount withdrawn by an address that the owner hasn't paid back yet.
+func (app *Registrar) Refund(address []byte) uint64 {
+    return app.getUint64(append([]byte("refund__"), address...))
+}
+
+// Revenue returns the total that the owner has earned from registrations and renewals.
+func (app *Registrar) Revenue() uint64 {
+    return app.getUint64([]byte("__revenue__"))
+}
+
+// Nonce returns the nonce that the next withdrawal by an address must have.
+func (app *Registrar) Nonce(address []byte) uint64 {
+    return app.nonce(address)
 }
 
-func (app *Registrar) transferCallback(from []byte, to []byte, value int) {
+func (app *Registrar) nonce(address []byte) uint64 {
+    return app.getUint64(append([]byte("nonce__"), address...))
+}
+
+// Subscriptions subscribes the registrar to the currency's transfers, through which names are prepaid.
+func (app *Registrar) Subscriptions() []Subscription {
+    return []Subscription{{Namespace: app.currency.Namespace(), Type: "transfer"}}
+}
+
+// ConsumeEvent credits a transfer to the registrar's owner to the balance of the sender,
+// and counts a transfer from the owner towards the refund owed to the recipient.
+func (app *Registrar) ConsumeEvent(event EmittedEvent) error {
+    from := event.Attribute("from")
+    to := event.Attribute("to")
+    if bytes.Compare(app.owner, to) != 0 && bytes.Compare(app.owner, from) != 0 {
+        return nil
+    }
+    amount, err := event.Uint64Attribute("amount")
+    if err != nil {
+        return err
+    }
     if bytes.Compare(app.owner, to) == 0 {
-        balanceBytes, err := app.state.Get(append([]byte("balance__"), from...))
-        var balance uint64
-        if err != nil {
-            balance = 0
-        } else {
-            balance = binary.BigEndian.Uint64(balanceBytes)
-        }
-        newBalanceBytes := make([]byte, binary.MaxVarintLen64)
-        binary.BigEndian.PutUint64(newBalanceBytes, balance + uint64(value))
-        app.state.Put(append([]byte("balance__"), from...), newBalanceBytes)
+        return app.putUint64(append([]byte("balance__"), from...), app.Balance(from) + amount)
     }
+    refund := app.Refund(to)
+    if refund == 0 {
+        return nil
+    }
+    if amount > refund {
+        amount = refund
+    }
+    return app.putUint64(append([]byte("refund__"), to...), refund - amount)
 }
 
-func (app *Registrar) GenerateTransaction(owner crypto.PrivKey, name []byte) Message {
-    ownerPubKeyBytes, _ := owner.GetPublic().Bytes()
+// GenerateCommitTransaction generates a transaction that commits to registering a name with a salt.
+// The salt should be random and kept secret until the name is registered with GenerateTransaction, in a later block.
+func (app *Registrar) GenerateCommitTransaction(owner crypto.PubKey, name []byte, salt []byte) Message {
+    transaction := &CommitNameTransaction{
+        Commitment: app.Commitment(name, Address(owner), salt),
+    }
+    return app.generateMessage(&RegistrarTransaction{
+        Message: &RegistrarTransaction_Commit{Commit: transaction},
+    })
+}
+
+// GenerateTransaction generates a transaction that registers a name by revealing the salt of an earlier commitment to it.
+// The owner's public key is only included if the currency doesn't know it yet.
+func (app *Registrar) GenerateTransaction(owner crypto.PrivKey, name []byte, salt []byte) Message {
+    ownerAddress := Address(owner.GetPublic())
     transactionMessage := &RegisterTransactionMessage{
         Name: name,
+        Salt: salt,
     }
-    signedData, _ := proto.Marshal(transactionMessage)
+    signedData, _ := app.signedData(transactionMessage)
     signature, _ := owner.Sign(signedData)
     transaction := &RegisterTransaction{
-        Owner: ownerPubKeyBytes,
+        Owner: ownerAddress,
+        Name: name,
+        Signature: signature,
+        PublicKey: app.revealedKey(owner),
+        Salt: salt,
+    }
+    return app.generateMessage(&RegistrarTransaction{
+        Message: &RegistrarTransaction_Register{Register: transaction},
+    })
+}
+
+// GenerateTransferTransaction generates a transaction that transfers a name to a new owner.
+// It is only valid until the name is next transferred, renewed or updated.
+func (app *Registrar) GenerateTransferTransaction(owner crypto.PrivKey, name []byte, newOwner []byte) Message {
+    record := app.record(name)
+    if record == nil {
+        record = &NameRecord{Expiry: proto.Uint64(0), Sequence: proto.Uint64(0)}
+    }
+    transactionMessage := &TransferNameTransactionMessage{
+        Name: name,
+        NewOwner: newOwner,
+        Expiry: record.Expiry,
+        Sequence: record.Sequence,
+    }
+    signedData, _ := app.signedData(transactionMessage)
+    signature, _ := owner.Sign(signedData)
+    transaction := &TransferNameTransaction{
+        Name: name,
+        NewOwner: newOwner,
+        Signature: signature,
+        PublicKey: app.revealedKey(owner),
+    }
+    return app.generateMessage(&RegistrarTransaction{
+        Message: &RegistrarTransaction_Transfer{Transfer: transaction},
+    })
+}
+
+// GenerateRenewTransaction generates a transaction that extends a name's registration, paid for from the owner's prepaid balance.
+// It is only valid until the name is next transferred, renewed or updated.
+func (app *Registrar) GenerateRenewTransaction(owner crypto.PrivKey, name []byte) Message {
+    record := app.record(name)
+    if record == nil {
+        record = &NameRecord{Expiry: proto.Uint64(0), Sequence: proto.Uint64(0)}
+    }
+    transactionMessage := &RenewNameTransactionMessage{
+        Name: name,
+        Expiry: record.Expiry,
+        Sequence: record.Sequence,
+    }
+    signedData, _ := app.signedData(transactionMessage)
+    signature, _ := owner.Sign(signedData)
+    transaction := &RenewNameTransaction{
         Name: name,
         Signature: signature,
+        PublicKey: app.revealedKey(owner),
+    }
+    return app.generateMessage(&RegistrarTransaction{
+        Message: &RegistrarTransaction_Renew{Renew: transaction},
+    })
+}
+
+// GenerateUpdateRecordTransaction generates a transaction that sets a name's record of a type, or deletes it if value is empty.
+// It is only valid until the name is next transferred, renewed or updated.
+func (app *Registrar) GenerateUpdateRecordTransaction(owner crypto.PrivKey, name []byte, recordType RecordType, value []byte) Message {
+    record := app.record(name)
+    if record == nil {
+        record = &NameRecord{Expiry: proto.Uint64(0), Sequence: proto.Uint64(0)}
+    }
+    transactionMessage := &UpdateRecordTransactionMessage{
+        Name: name,
+        Type: recordType.Enum(),
+        Value: value,
+        Expiry: record.Expiry,
+        Sequence: record.Sequence,
     }
+    signedData, _ := app.signedData(transactionMessage)
+    signature, _ := owner.Sign(signedData)
+    transaction := &UpdateRecordTransaction{
+        Name: name,
+        Type: recordType.Enum(),
+        Value: value,
+        Signature: signature,
+        PublicKey: app.revealedKey(owner),
+    }
+    return app.generateMessage(&RegistrarTransaction{
+        Message: &RegistrarTransaction_UpdateRecord{UpdateRecord: transaction},
+    })
+}
+
+// GenerateWithdrawTransaction generates a transaction that withdraws an amount of a prepaid balance, to be refunded by the owner.
+// The nonce must be the address's next withdrawal nonce at the time the transaction is processed; see Nonce.
+func (app *Registrar) GenerateWithdrawTransaction(key crypto.PrivKey, amount uint64, nonce uint64) Message {
+    transactionMessage := &WithdrawTransactionMessage{
+        Amount: &amount,
+        Nonce: &nonce,
+    }
+    signedData, _ := app.signedData(transactionMessage)
+    signature, _ := key.Sign(signedData)
+    transaction := &WithdrawTransaction{
+        Address: Address(key.GetPublic()),
+        Amount: &amount,
+        Nonce: &nonce,
+        Signature: signature,
+        PublicKey: app.revealedKey(key),
+    }
+    return app.generateMessage(&RegistrarTransaction{
+        Message: &RegistrarTransaction_Withdraw{Withdraw: transaction},
+    })
+}
+
+// GenerateRefundTransaction generates a currency transfer from the owner that pays the refund owed to an address; see Refund.
+// The nonce is the owner's currency nonce.
+func (app *Registrar) GenerateRefundTransaction(owner crypto.PrivKey, address []byte, nonce uint64) Message {
+    return app.currency.GenerateTransactionToAddress(owner, address, app.Refund(address), 0, nonce, nil)
+}
+
+// revealedKey returns the marshalled public key of a private key if the currency doesn't know it yet, or else nil.
+func (app *Registrar) revealedKey(key crypto.PrivKey) []byte {
+    if app.currency.publicKey(Address(key.GetPublic())) != nil {
+        return nil
+    }
+    keyBytes, _ := key.GetPublic().Bytes()
+    return keyBytes
+}
+
+func (app *Registrar) generateMessage(transaction *RegistrarTransaction) Message {
     messageData, _ := proto.Marshal(transaction)
     return *NewMessage(app.Namespace(), messageData)
 }
+
+func appendNameList(list []byte, name []byte) []byte {
+    list = appendUvarint(list, uint64(len(name)))
+    return append(list, name...)
+}
+
+func decodeNameList(list []byte) [][]byte {
+    var names [][]byte
+    for len(list) > 0 {
+        length, n := binary.Uvarint(list)
+        if n <= 0 || uint64(len(list) - n) < length {
+            break
+        }
+        names = append(names, list[n:n + int(length)])
+        list = list[n + int(length):]
+    }
+    return names
+}
@@ -20,10 +20,234 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type RecordType int32
+
+const (
+	RecordType_ADDRESS   RecordType = 0
+	RecordType_NAMESPACE RecordType = 1
+	RecordType_TEXT      RecordType = 2
+)
+
+var RecordType_name = map[int32]string{
+	0: "ADDRESS",
+	1: "NAMESPACE",
+	2: "TEXT",
+}
+
+var RecordType_value = map[string]int32{
+	"ADDRESS":   0,
+	"NAMESPACE": 1,
+	"TEXT":      2,
+}
+
+func (x RecordType) Enum() *RecordType {
+	p := new(RecordType)
+	*p = x
+	return p
+}
+
+func (x RecordType) String() string {
+	return proto.EnumName(RecordType_name, int32(x))
+}
+
+func (x *RecordType) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(RecordType_value, data, "RecordType")
+	if err != nil {
+		return err
+	}
+	*x = RecordType(value)
+	return nil
+}
+
+func (RecordType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{0}
+}
+
+type RegistrarTransaction struct {
+	// Types that are valid to be assigned to Message:
+	//	*RegistrarTransaction_Register
+	//	*RegistrarTransaction_Transfer
+	//	*RegistrarTransaction_Renew
+	//	*RegistrarTransaction_UpdateRecord
+	//	*RegistrarTransaction_Withdraw
+	//	*RegistrarTransaction_Commit
+	Message              isRegistrarTransaction_Message `protobuf_oneof:"message"`
+	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
+	XXX_unrecognized     []byte                         `json:"-"`
+	XXX_sizecache        int32                          `json:"-"`
+}
+
+func (m *RegistrarTransaction) Reset()         { *m = RegistrarTransaction{} }
+func (m *RegistrarTransaction) String() string { return proto.CompactTextString(m) }
+func (*RegistrarTransaction) ProtoMessage()    {}
+func (*RegistrarTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{0}
+}
+
+func (m *RegistrarTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegistrarTransaction.Unmarshal(m, b)
+}
+func (m *RegistrarTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegistrarTransaction.Marshal(b, m, deterministic)
+}
+func (m *RegistrarTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegistrarTransaction.Merge(m, src)
+}
+func (m *RegistrarTransaction) XXX_Size() int {
+	return xxx_messageInfo_RegistrarTransaction.Size(m)
+}
+func (m *RegistrarTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegistrarTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegistrarTransaction proto.InternalMessageInfo
+
+type isRegistrarTransaction_Message interface {
+	isRegistrarTransaction_Message()
+}
+
+type RegistrarTransaction_Register struct {
+	Register *RegisterTransaction `protobuf:"bytes,1,opt,name=register,oneof"`
+}
+
+type RegistrarTransaction_Transfer struct {
+	Transfer *TransferNameTransaction `protobuf:"bytes,2,opt,name=transfer,oneof"`
+}
+
+type RegistrarTransaction_Renew struct {
+	Renew *RenewNameTransaction `protobuf:"bytes,3,opt,name=renew,oneof"`
+}
+
+type RegistrarTransaction_UpdateRecord struct {
+	UpdateRecord *UpdateRecordTransaction `protobuf:"bytes,4,opt,name=update_record,json=updateRecord,oneof"`
+}
+
+type RegistrarTransaction_Withdraw struct {
+	Withdraw *WithdrawTransaction `protobuf:"bytes,5,opt,name=withdraw,oneof"`
+}
+
+type RegistrarTransaction_Commit struct {
+	Commit *CommitNameTransaction `protobuf:"bytes,6,opt,name=commit,oneof"`
+}
+
+func (*RegistrarTransaction_Register) isRegistrarTransaction_Message() {}
+
+func (*RegistrarTransaction_Transfer) isRegistrarTransaction_Message() {}
+
+func (*RegistrarTransaction_Renew) isRegistrarTransaction_Message() {}
+
+func (*RegistrarTransaction_UpdateRecord) isRegistrarTransaction_Message() {}
+
+func (*RegistrarTransaction_Withdraw) isRegistrarTransaction_Message() {}
+
+func (*RegistrarTransaction_Commit) isRegistrarTransaction_Message() {}
+
+func (m *RegistrarTransaction) GetMessage() isRegistrarTransaction_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *RegistrarTransaction) GetRegister() *RegisterTransaction {
+	if x, ok := m.GetMessage().(*RegistrarTransaction_Register); ok {
+		return x.Register
+	}
+	return nil
+}
+
+func (m *RegistrarTransaction) GetTransfer() *TransferNameTransaction {
+	if x, ok := m.GetMessage().(*RegistrarTransaction_Transfer); ok {
+		return x.Transfer
+	}
+	return nil
+}
+
+func (m *RegistrarTransaction) GetRenew() *RenewNameTransaction {
+	if x, ok := m.GetMessage().(*RegistrarTransaction_Renew); ok {
+		return x.Renew
+	}
+	return nil
+}
+
+func (m *RegistrarTransaction) GetUpdateRecord() *UpdateRecordTransaction {
+	if x, ok := m.GetMessage().(*RegistrarTransaction_UpdateRecord); ok {
+		return x.UpdateRecord
+	}
+	return nil
+}
+
+func (m *RegistrarTransaction) GetWithdraw() *WithdrawTransaction {
+	if x, ok := m.GetMessage().(*RegistrarTransaction_Withdraw); ok {
+		return x.Withdraw
+	}
+	return nil
+}
+
+func (m *RegistrarTransaction) GetCommit() *CommitNameTransaction {
+	if x, ok := m.GetMessage().(*RegistrarTransaction_Commit); ok {
+		return x.Commit
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*RegistrarTransaction) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*RegistrarTransaction_Register)(nil),
+		(*RegistrarTransaction_Transfer)(nil),
+		(*RegistrarTransaction_Renew)(nil),
+		(*RegistrarTransaction_UpdateRecord)(nil),
+		(*RegistrarTransaction_Withdraw)(nil),
+		(*RegistrarTransaction_Commit)(nil),
+	}
+}
+
+type CommitNameTransaction struct {
+	Commitment           []byte   `protobuf:"bytes,1,req,name=commitment" json:"commitment,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommitNameTransaction) Reset()         { *m = CommitNameTransaction{} }
+func (m *CommitNameTransaction) String() string { return proto.CompactTextString(m) }
+func (*CommitNameTransaction) ProtoMessage()    {}
+func (*CommitNameTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{1}
+}
+
+func (m *CommitNameTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommitNameTransaction.Unmarshal(m, b)
+}
+func (m *CommitNameTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommitNameTransaction.Marshal(b, m, deterministic)
+}
+func (m *CommitNameTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitNameTransaction.Merge(m, src)
+}
+func (m *CommitNameTransaction) XXX_Size() int {
+	return xxx_messageInfo_CommitNameTransaction.Size(m)
+}
+func (m *CommitNameTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitNameTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitNameTransaction proto.InternalMessageInfo
+
+func (m *CommitNameTransaction) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
 type RegisterTransaction struct {
 	Owner                []byte   `protobuf:"bytes,1,req,name=owner" json:"owner,omitempty"`
 	Name                 []byte   `protobuf:"bytes,2,req,name=name" json:"name,omitempty"`
 	Signature            []byte   `protobuf:"bytes,3,req,name=signature" json:"signature,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,4,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	Salt                 []byte   `protobuf:"bytes,5,req,name=salt" json:"salt,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -33,7 +257,7 @@ func (m *RegisterTransaction) Reset()         { *m = RegisterTransaction{} }
 func (m *RegisterTransaction) String() string { return proto.CompactTextString(m) }
 func (*RegisterTransaction) ProtoMessage()    {}
 func (*RegisterTransaction) Descriptor() ([]byte, []int) {
-	return fileDescriptor_84f106271c15fd48, []int{0}
+	return fileDescriptor_84f106271c15fd48, []int{2}
 }
 
 func (m *RegisterTransaction) XXX_Unmarshal(b []byte) error {
@@ -75,8 +299,23 @@ func (m *RegisterTransaction) GetSignature() []byte {
 	return nil
 }
 
+func (m *RegisterTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *RegisterTransaction) GetSalt() []byte {
+	if m != nil {
+		return m.Salt
+	}
+	return nil
+}
+
 type RegisterTransactionMessage struct {
 	Name                 []byte   `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Salt                 []byte   `protobuf:"bytes,2,req,name=salt" json:"salt,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -86,7 +325,7 @@ func (m *RegisterTransactionMessage) Reset()         { *m = RegisterTransactionM
 func (m *RegisterTransactionMessage) String() string { return proto.CompactTextString(m) }
 func (*RegisterTransactionMessage) ProtoMessage()    {}
 func (*RegisterTransactionMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_84f106271c15fd48, []int{1}
+	return fileDescriptor_84f106271c15fd48, []int{3}
 }
 
 func (m *RegisterTransactionMessage) XXX_Unmarshal(b []byte) error {
@@ -114,23 +353,682 @@ func (m *RegisterTransactionMessage) GetName() []byte {
 	return nil
 }
 
+func (m *RegisterTransactionMessage) GetSalt() []byte {
+	if m != nil {
+		return m.Salt
+	}
+	return nil
+}
+
+type TransferNameTransaction struct {
+	Name                 []byte   `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	NewOwner             []byte   `protobuf:"bytes,2,req,name=new_owner,json=newOwner" json:"new_owner,omitempty"`
+	Signature            []byte   `protobuf:"bytes,3,req,name=signature" json:"signature,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,4,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferNameTransaction) Reset()         { *m = TransferNameTransaction{} }
+func (m *TransferNameTransaction) String() string { return proto.CompactTextString(m) }
+func (*TransferNameTransaction) ProtoMessage()    {}
+func (*TransferNameTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{4}
+}
+
+func (m *TransferNameTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferNameTransaction.Unmarshal(m, b)
+}
+func (m *TransferNameTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferNameTransaction.Marshal(b, m, deterministic)
+}
+func (m *TransferNameTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferNameTransaction.Merge(m, src)
+}
+func (m *TransferNameTransaction) XXX_Size() int {
+	return xxx_messageInfo_TransferNameTransaction.Size(m)
+}
+func (m *TransferNameTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferNameTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferNameTransaction proto.InternalMessageInfo
+
+func (m *TransferNameTransaction) GetName() []byte {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *TransferNameTransaction) GetNewOwner() []byte {
+	if m != nil {
+		return m.NewOwner
+	}
+	return nil
+}
+
+func (m *TransferNameTransaction) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *TransferNameTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+type TransferNameTransactionMessage struct {
+	Name                 []byte   `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	NewOwner             []byte   `protobuf:"bytes,2,req,name=new_owner,json=newOwner" json:"new_owner,omitempty"`
+	Expiry               *uint64  `protobuf:"varint,3,req,name=expiry" json:"expiry,omitempty"`
+	Sequence             *uint64  `protobuf:"varint,4,req,name=sequence" json:"sequence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferNameTransactionMessage) Reset()         { *m = TransferNameTransactionMessage{} }
+func (m *TransferNameTransactionMessage) String() string { return proto.CompactTextString(m) }
+func (*TransferNameTransactionMessage) ProtoMessage()    {}
+func (*TransferNameTransactionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{5}
+}
+
+func (m *TransferNameTransactionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferNameTransactionMessage.Unmarshal(m, b)
+}
+func (m *TransferNameTransactionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferNameTransactionMessage.Marshal(b, m, deterministic)
+}
+func (m *TransferNameTransactionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferNameTransactionMessage.Merge(m, src)
+}
+func (m *TransferNameTransactionMessage) XXX_Size() int {
+	return xxx_messageInfo_TransferNameTransactionMessage.Size(m)
+}
+func (m *TransferNameTransactionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferNameTransactionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferNameTransactionMessage proto.InternalMessageInfo
+
+func (m *TransferNameTransactionMessage) GetName() []byte {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *TransferNameTransactionMessage) GetNewOwner() []byte {
+	if m != nil {
+		return m.NewOwner
+	}
+	return nil
+}
+
+func (m *TransferNameTransactionMessage) GetExpiry() uint64 {
+	if m != nil && m.Expiry != nil {
+		return *m.Expiry
+	}
+	return 0
+}
+
+func (m *TransferNameTransactionMessage) GetSequence() uint64 {
+	if m != nil && m.Sequence != nil {
+		return *m.Sequence
+	}
+	return 0
+}
+
+type RenewNameTransaction struct {
+	Name                 []byte   `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Signature            []byte   `protobuf:"bytes,2,req,name=signature" json:"signature,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,3,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RenewNameTransaction) Reset()         { *m = RenewNameTransaction{} }
+func (m *RenewNameTransaction) String() string { return proto.CompactTextString(m) }
+func (*RenewNameTransaction) ProtoMessage()    {}
+func (*RenewNameTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{6}
+}
+
+func (m *RenewNameTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RenewNameTransaction.Unmarshal(m, b)
+}
+func (m *RenewNameTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RenewNameTransaction.Marshal(b, m, deterministic)
+}
+func (m *RenewNameTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RenewNameTransaction.Merge(m, src)
+}
+func (m *RenewNameTransaction) XXX_Size() int {
+	return xxx_messageInfo_RenewNameTransaction.Size(m)
+}
+func (m *RenewNameTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_RenewNameTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RenewNameTransaction proto.InternalMessageInfo
+
+func (m *RenewNameTransaction) GetName() []byte {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *RenewNameTransaction) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *RenewNameTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+type RenewNameTransactionMessage struct {
+	Name                 []byte   `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Expiry               *uint64  `protobuf:"varint,2,req,name=expiry" json:"expiry,omitempty"`
+	Sequence             *uint64  `protobuf:"varint,3,req,name=sequence" json:"sequence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RenewNameTransactionMessage) Reset()         { *m = RenewNameTransactionMessage{} }
+func (m *RenewNameTransactionMessage) String() string { return proto.CompactTextString(m) }
+func (*RenewNameTransactionMessage) ProtoMessage()    {}
+func (*RenewNameTransactionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{7}
+}
+
+func (m *RenewNameTransactionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RenewNameTransactionMessage.Unmarshal(m, b)
+}
+func (m *RenewNameTransactionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RenewNameTransactionMessage.Marshal(b, m, deterministic)
+}
+func (m *RenewNameTransactionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RenewNameTransactionMessage.Merge(m, src)
+}
+func (m *RenewNameTransactionMessage) XXX_Size() int {
+	return xxx_messageInfo_RenewNameTransactionMessage.Size(m)
+}
+func (m *RenewNameTransactionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_RenewNameTransactionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RenewNameTransactionMessage proto.InternalMessageInfo
+
+func (m *RenewNameTransactionMessage) GetName() []byte {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *RenewNameTransactionMessage) GetExpiry() uint64 {
+	if m != nil && m.Expiry != nil {
+		return *m.Expiry
+	}
+	return 0
+}
+
+func (m *RenewNameTransactionMessage) GetSequence() uint64 {
+	if m != nil && m.Sequence != nil {
+		return *m.Sequence
+	}
+	return 0
+}
+
+type UpdateRecordTransaction struct {
+	Name                 []byte      `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Type                 *RecordType `protobuf:"varint,2,req,name=type,enum=lazyledger.RecordType" json:"type,omitempty"`
+	Value                []byte      `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	Signature            []byte      `protobuf:"bytes,4,req,name=signature" json:"signature,omitempty"`
+	PublicKey            []byte      `protobuf:"bytes,5,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *UpdateRecordTransaction) Reset()         { *m = UpdateRecordTransaction{} }
+func (m *UpdateRecordTransaction) String() string { return proto.CompactTextString(m) }
+func (*UpdateRecordTransaction) ProtoMessage()    {}
+func (*UpdateRecordTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{8}
+}
+
+func (m *UpdateRecordTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRecordTransaction.Unmarshal(m, b)
+}
+func (m *UpdateRecordTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRecordTransaction.Marshal(b, m, deterministic)
+}
+func (m *UpdateRecordTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRecordTransaction.Merge(m, src)
+}
+func (m *UpdateRecordTransaction) XXX_Size() int {
+	return xxx_messageInfo_UpdateRecordTransaction.Size(m)
+}
+func (m *UpdateRecordTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRecordTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRecordTransaction proto.InternalMessageInfo
+
+func (m *UpdateRecordTransaction) GetName() []byte {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *UpdateRecordTransaction) GetType() RecordType {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return RecordType_ADDRESS
+}
+
+func (m *UpdateRecordTransaction) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *UpdateRecordTransaction) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *UpdateRecordTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+type UpdateRecordTransactionMessage struct {
+	Name                 []byte      `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Type                 *RecordType `protobuf:"varint,2,req,name=type,enum=lazyledger.RecordType" json:"type,omitempty"`
+	Value                []byte      `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	Expiry               *uint64     `protobuf:"varint,4,req,name=expiry" json:"expiry,omitempty"`
+	Sequence             *uint64     `protobuf:"varint,5,req,name=sequence" json:"sequence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *UpdateRecordTransactionMessage) Reset()         { *m = UpdateRecordTransactionMessage{} }
+func (m *UpdateRecordTransactionMessage) String() string { return proto.CompactTextString(m) }
+func (*UpdateRecordTransactionMessage) ProtoMessage()    {}
+func (*UpdateRecordTransactionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{9}
+}
+
+func (m *UpdateRecordTransactionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRecordTransactionMessage.Unmarshal(m, b)
+}
+func (m *UpdateRecordTransactionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRecordTransactionMessage.Marshal(b, m, deterministic)
+}
+func (m *UpdateRecordTransactionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRecordTransactionMessage.Merge(m, src)
+}
+func (m *UpdateRecordTransactionMessage) XXX_Size() int {
+	return xxx_messageInfo_UpdateRecordTransactionMessage.Size(m)
+}
+func (m *UpdateRecordTransactionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRecordTransactionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRecordTransactionMessage proto.InternalMessageInfo
+
+func (m *UpdateRecordTransactionMessage) GetName() []byte {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *UpdateRecordTransactionMessage) GetType() RecordType {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return RecordType_ADDRESS
+}
+
+func (m *UpdateRecordTransactionMessage) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *UpdateRecordTransactionMessage) GetExpiry() uint64 {
+	if m != nil && m.Expiry != nil {
+		return *m.Expiry
+	}
+	return 0
+}
+
+func (m *UpdateRecordTransactionMessage) GetSequence() uint64 {
+	if m != nil && m.Sequence != nil {
+		return *m.Sequence
+	}
+	return 0
+}
+
+type WithdrawTransaction struct {
+	Address              []byte   `protobuf:"bytes,1,req,name=address" json:"address,omitempty"`
+	Amount               *uint64  `protobuf:"varint,2,req,name=amount" json:"amount,omitempty"`
+	Nonce                *uint64  `protobuf:"varint,3,req,name=nonce" json:"nonce,omitempty"`
+	Signature            []byte   `protobuf:"bytes,4,req,name=signature" json:"signature,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,5,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WithdrawTransaction) Reset()         { *m = WithdrawTransaction{} }
+func (m *WithdrawTransaction) String() string { return proto.CompactTextString(m) }
+func (*WithdrawTransaction) ProtoMessage()    {}
+func (*WithdrawTransaction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{10}
+}
+
+func (m *WithdrawTransaction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WithdrawTransaction.Unmarshal(m, b)
+}
+func (m *WithdrawTransaction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WithdrawTransaction.Marshal(b, m, deterministic)
+}
+func (m *WithdrawTransaction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WithdrawTransaction.Merge(m, src)
+}
+func (m *WithdrawTransaction) XXX_Size() int {
+	return xxx_messageInfo_WithdrawTransaction.Size(m)
+}
+func (m *WithdrawTransaction) XXX_DiscardUnknown() {
+	xxx_messageInfo_WithdrawTransaction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WithdrawTransaction proto.InternalMessageInfo
+
+func (m *WithdrawTransaction) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *WithdrawTransaction) GetAmount() uint64 {
+	if m != nil && m.Amount != nil {
+		return *m.Amount
+	}
+	return 0
+}
+
+func (m *WithdrawTransaction) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+func (m *WithdrawTransaction) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *WithdrawTransaction) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+type WithdrawTransactionMessage struct {
+	Amount               *uint64  `protobuf:"varint,1,req,name=amount" json:"amount,omitempty"`
+	Nonce                *uint64  `protobuf:"varint,2,req,name=nonce" json:"nonce,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WithdrawTransactionMessage) Reset()         { *m = WithdrawTransactionMessage{} }
+func (m *WithdrawTransactionMessage) String() string { return proto.CompactTextString(m) }
+func (*WithdrawTransactionMessage) ProtoMessage()    {}
+func (*WithdrawTransactionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{11}
+}
+
+func (m *WithdrawTransactionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WithdrawTransactionMessage.Unmarshal(m, b)
+}
+func (m *WithdrawTransactionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WithdrawTransactionMessage.Marshal(b, m, deterministic)
+}
+func (m *WithdrawTransactionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WithdrawTransactionMessage.Merge(m, src)
+}
+func (m *WithdrawTransactionMessage) XXX_Size() int {
+	return xxx_messageInfo_WithdrawTransactionMessage.Size(m)
+}
+func (m *WithdrawTransactionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_WithdrawTransactionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WithdrawTransactionMessage proto.InternalMessageInfo
+
+func (m *WithdrawTransactionMessage) GetAmount() uint64 {
+	if m != nil && m.Amount != nil {
+		return *m.Amount
+	}
+	return 0
+}
+
+func (m *WithdrawTransactionMessage) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+type TypedRecord struct {
+	Type                 *RecordType `protobuf:"varint,1,req,name=type,enum=lazyledger.RecordType" json:"type,omitempty"`
+	Value                []byte      `protobuf:"bytes,2,req,name=value" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *TypedRecord) Reset()         { *m = TypedRecord{} }
+func (m *TypedRecord) String() string { return proto.CompactTextString(m) }
+func (*TypedRecord) ProtoMessage()    {}
+func (*TypedRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{12}
+}
+
+func (m *TypedRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TypedRecord.Unmarshal(m, b)
+}
+func (m *TypedRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TypedRecord.Marshal(b, m, deterministic)
+}
+func (m *TypedRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TypedRecord.Merge(m, src)
+}
+func (m *TypedRecord) XXX_Size() int {
+	return xxx_messageInfo_TypedRecord.Size(m)
+}
+func (m *TypedRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_TypedRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TypedRecord proto.InternalMessageInfo
+
+func (m *TypedRecord) GetType() RecordType {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return RecordType_ADDRESS
+}
+
+func (m *TypedRecord) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type NameRecord struct {
+	Owner                []byte         `protobuf:"bytes,1,req,name=owner" json:"owner,omitempty"`
+	Expiry               *uint64        `protobuf:"varint,2,req,name=expiry" json:"expiry,omitempty"`
+	Sequence             *uint64        `protobuf:"varint,3,req,name=sequence" json:"sequence,omitempty"`
+	Records              []*TypedRecord `protobuf:"bytes,4,rep,name=records" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *NameRecord) Reset()         { *m = NameRecord{} }
+func (m *NameRecord) String() string { return proto.CompactTextString(m) }
+func (*NameRecord) ProtoMessage()    {}
+func (*NameRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84f106271c15fd48, []int{13}
+}
+
+func (m *NameRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NameRecord.Unmarshal(m, b)
+}
+func (m *NameRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NameRecord.Marshal(b, m, deterministic)
+}
+func (m *NameRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NameRecord.Merge(m, src)
+}
+func (m *NameRecord) XXX_Size() int {
+	return xxx_messageInfo_NameRecord.Size(m)
+}
+func (m *NameRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_NameRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NameRecord proto.InternalMessageInfo
+
+func (m *NameRecord) GetOwner() []byte {
+	if m != nil {
+		return m.Owner
+	}
+	return nil
+}
+
+func (m *NameRecord) GetExpiry() uint64 {
+	if m != nil && m.Expiry != nil {
+		return *m.Expiry
+	}
+	return 0
+}
+
+func (m *NameRecord) GetSequence() uint64 {
+	if m != nil && m.Sequence != nil {
+		return *m.Sequence
+	}
+	return 0
+}
+
+func (m *NameRecord) GetRecords() []*TypedRecord {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterEnum("lazyledger.RecordType", RecordType_name, RecordType_value)
+	proto.RegisterType((*RegistrarTransaction)(nil), "lazyledger.RegistrarTransaction")
+	proto.RegisterType((*CommitNameTransaction)(nil), "lazyledger.CommitNameTransaction")
 	proto.RegisterType((*RegisterTransaction)(nil), "lazyledger.RegisterTransaction")
 	proto.RegisterType((*RegisterTransactionMessage)(nil), "lazyledger.RegisterTransactionMessage")
+	proto.RegisterType((*TransferNameTransaction)(nil), "lazyledger.TransferNameTransaction")
+	proto.RegisterType((*TransferNameTransactionMessage)(nil), "lazyledger.TransferNameTransactionMessage")
+	proto.RegisterType((*RenewNameTransaction)(nil), "lazyledger.RenewNameTransaction")
+	proto.RegisterType((*RenewNameTransactionMessage)(nil), "lazyledger.RenewNameTransactionMessage")
+	proto.RegisterType((*UpdateRecordTransaction)(nil), "lazyledger.UpdateRecordTransaction")
+	proto.RegisterType((*UpdateRecordTransactionMessage)(nil), "lazyledger.UpdateRecordTransactionMessage")
+	proto.RegisterType((*WithdrawTransaction)(nil), "lazyledger.WithdrawTransaction")
+	proto.RegisterType((*WithdrawTransactionMessage)(nil), "lazyledger.WithdrawTransactionMessage")
+	proto.RegisterType((*TypedRecord)(nil), "lazyledger.TypedRecord")
+	proto.RegisterType((*NameRecord)(nil), "lazyledger.NameRecord")
 }
 
 func init() { proto.RegisterFile("app_registrar.proto", fileDescriptor_84f106271c15fd48) }
 
 var fileDescriptor_84f106271c15fd48 = []byte{
-	// 146 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x4e, 0x2c, 0x28, 0x88,
-	0x2f, 0x4a, 0x4d, 0xcf, 0x2c, 0x2e, 0x29, 0x4a, 0x2c, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17,
-	0xe2, 0xca, 0x49, 0xac, 0xaa, 0xcc, 0x49, 0x4d, 0x49, 0x4f, 0x2d, 0x52, 0x8a, 0xe5, 0x12, 0x0e,
-	0x02, 0x4b, 0xa7, 0x16, 0x85, 0x14, 0x25, 0xe6, 0x15, 0x27, 0x26, 0x97, 0x64, 0xe6, 0xe7, 0x09,
-	0x89, 0x70, 0xb1, 0xe6, 0x97, 0xe7, 0xa5, 0x16, 0x49, 0x30, 0x2a, 0x30, 0x69, 0xf0, 0x04, 0x41,
-	0x38, 0x42, 0x42, 0x5c, 0x2c, 0x79, 0x89, 0xb9, 0xa9, 0x12, 0x4c, 0x60, 0x41, 0x30, 0x5b, 0x48,
-	0x86, 0x8b, 0xb3, 0x38, 0x33, 0x3d, 0x2f, 0xb1, 0xa4, 0xb4, 0x28, 0x55, 0x82, 0x19, 0x2c, 0x81,
-	0x10, 0x50, 0x32, 0xe0, 0x92, 0xc2, 0x62, 0xbc, 0x6f, 0x6a, 0x71, 0x71, 0x62, 0x7a, 0x2a, 0xdc,
-	0x3c, 0x46, 0x84, 0x79, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x33, 0xb6, 0x18, 0xac, 0xb2, 0x00,
-	0x00, 0x00,
+	// 676 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x4d, 0x6f, 0xd3, 0x4a,
+	0x14, 0xed, 0x38, 0x4e, 0x93, 0xdc, 0xb4, 0x4f, 0xd5, 0xb4, 0xaf, 0x1d, 0xb5, 0xef, 0x95, 0x60,
+	0x36, 0x55, 0x17, 0x95, 0xc8, 0x06, 0x24, 0xc4, 0x22, 0xb4, 0x91, 0x50, 0x51, 0x5b, 0xe4, 0x06,
+	0xc1, 0x2e, 0x1a, 0xe2, 0x4b, 0xb0, 0x88, 0xc7, 0x66, 0x6c, 0x63, 0xc2, 0x1e, 0x21, 0x76, 0xac,
+	0xd9, 0xb3, 0x41, 0xfc, 0x48, 0xe4, 0x19, 0xbb, 0x76, 0x12, 0x27, 0x12, 0x74, 0xe7, 0x3b, 0x77,
+	0xce, 0x99, 0x73, 0xce, 0x7c, 0x18, 0xb6, 0x79, 0x10, 0x0c, 0x25, 0x8e, 0xdd, 0x30, 0x92, 0x5c,
+	0x9e, 0x04, 0xd2, 0x8f, 0x7c, 0x0a, 0x13, 0xfe, 0x69, 0x3a, 0x41, 0x67, 0x8c, 0xd2, 0xfa, 0x51,
+	0x83, 0x1d, 0x3b, 0xef, 0x0f, 0x24, 0x17, 0x21, 0x1f, 0x45, 0xae, 0x2f, 0xe8, 0x63, 0x68, 0x6a,
+	0x1c, 0x4a, 0x46, 0x3a, 0xe4, 0xa8, 0xdd, 0xbd, 0x73, 0x52, 0xe0, 0x4e, 0xec, 0xac, 0x57, 0x82,
+	0x3c, 0x5d, 0xb3, 0x6f, 0x20, 0xb4, 0x07, 0xcd, 0x28, 0x6d, 0xbd, 0x41, 0xc9, 0x0c, 0x05, 0xbf,
+	0x57, 0x86, 0x0f, 0xb2, 0xde, 0x25, 0xf7, 0x70, 0x8e, 0x22, 0x87, 0xd1, 0x87, 0x50, 0x97, 0x28,
+	0x30, 0x61, 0x35, 0x85, 0xef, 0xcc, 0x2e, 0x2f, 0x30, 0x59, 0x04, 0x6b, 0x00, 0x3d, 0x87, 0xcd,
+	0x38, 0x70, 0x78, 0x84, 0x43, 0x89, 0x23, 0x5f, 0x3a, 0xcc, 0x5c, 0x54, 0xf0, 0x42, 0x4d, 0xb0,
+	0x55, 0x7f, 0x96, 0x64, 0x23, 0x2e, 0xb5, 0xd2, 0x1c, 0x12, 0x37, 0x7a, 0xeb, 0x48, 0x9e, 0xb0,
+	0xfa, 0x62, 0x0e, 0x2f, 0xb3, 0xde, 0x9c, 0x89, 0x1c, 0x42, 0x1f, 0xc1, 0xfa, 0xc8, 0xf7, 0x3c,
+	0x37, 0x62, 0xeb, 0x0a, 0x7c, 0xb7, 0x0c, 0x3e, 0x55, 0x9d, 0x45, 0x1b, 0x19, 0xe4, 0x49, 0x0b,
+	0x1a, 0x1e, 0x86, 0x21, 0x1f, 0xa3, 0xf5, 0x00, 0xfe, 0xad, 0x9c, 0x4d, 0x0f, 0x01, 0xf4, 0x6c,
+	0x0f, 0x45, 0xc4, 0x48, 0xc7, 0x38, 0xda, 0xb0, 0x4b, 0x23, 0xd6, 0x37, 0x02, 0xdb, 0x15, 0x9b,
+	0x45, 0x77, 0xa0, 0xee, 0x27, 0x02, 0x65, 0x06, 0xd1, 0x05, 0xa5, 0x60, 0x0a, 0xee, 0x21, 0x33,
+	0xd4, 0xa0, 0xfa, 0xa6, 0xff, 0x41, 0x2b, 0x74, 0xc7, 0x82, 0x47, 0xb1, 0x44, 0x56, 0x53, 0x8d,
+	0x62, 0x80, 0xfe, 0x0f, 0x10, 0xc4, 0xaf, 0x27, 0xee, 0x68, 0xf8, 0x0e, 0xa7, 0x2a, 0xe8, 0x0d,
+	0xbb, 0xa5, 0x47, 0x9e, 0xe1, 0x34, 0x25, 0x0c, 0xf9, 0x24, 0x62, 0x75, 0x4d, 0x98, 0x7e, 0x5b,
+	0x67, 0xb0, 0x5f, 0xa1, 0xe8, 0x42, 0x3b, 0xbd, 0x91, 0x40, 0x4a, 0x12, 0x72, 0x16, 0xa3, 0xc4,
+	0xf2, 0x85, 0xc0, 0xde, 0x92, 0x63, 0x54, 0xc9, 0x71, 0x00, 0x2d, 0x81, 0xc9, 0x50, 0x9b, 0xd6,
+	0x44, 0x4d, 0x81, 0xc9, 0x95, 0xf2, 0x7d, 0x1b, 0x8f, 0xd6, 0x67, 0x02, 0x87, 0x4b, 0x94, 0xac,
+	0x32, 0xb5, 0x52, 0xd0, 0x2e, 0xac, 0xe3, 0xc7, 0xc0, 0x95, 0x53, 0xa5, 0xc6, 0xb4, 0xb3, 0x8a,
+	0xee, 0x43, 0x33, 0xc4, 0xf7, 0x31, 0x8a, 0x11, 0x32, 0x53, 0x75, 0x6e, 0x6a, 0x6b, 0x0c, 0x3b,
+	0x55, 0xf7, 0xa2, 0x72, 0xf1, 0x19, 0xc3, 0xc6, 0x6a, 0xc3, 0xb5, 0x79, 0xc3, 0x08, 0x07, 0x55,
+	0x0b, 0xad, 0x32, 0x5b, 0xf8, 0x31, 0x96, 0xfa, 0xa9, 0xcd, 0xf9, 0xf9, 0x45, 0x60, 0x6f, 0xc9,
+	0x35, 0xad, 0x5c, 0xe3, 0x18, 0xcc, 0x68, 0x1a, 0x68, 0x3b, 0xff, 0x74, 0x77, 0x67, 0xdf, 0x0b,
+	0x45, 0x30, 0x0d, 0xd0, 0x56, 0x73, 0xd2, 0xe3, 0xff, 0x81, 0x4f, 0x62, 0xcc, 0xcc, 0xe9, 0x62,
+	0x36, 0x15, 0x73, 0x75, 0x2a, 0xf5, 0xf9, 0x54, 0x7e, 0x12, 0x38, 0x5c, 0x22, 0x77, 0x55, 0x32,
+	0xb7, 0x57, 0x5d, 0x64, 0x6b, 0x2e, 0xcd, 0xb6, 0x3e, 0x97, 0xed, 0x77, 0x02, 0xdb, 0x15, 0x6f,
+	0x17, 0x65, 0xd0, 0xe0, 0x8e, 0x23, 0x31, 0x0c, 0x33, 0x91, 0x79, 0x99, 0xae, 0xc2, 0x3d, 0x3f,
+	0x16, 0x51, 0xbe, 0x83, 0xba, 0x4a, 0x35, 0x09, 0xbf, 0xd8, 0x3e, 0x5d, 0xdc, 0x2e, 0xc9, 0x73,
+	0xd8, 0xaf, 0xd0, 0x96, 0x87, 0x58, 0x08, 0x21, 0xd5, 0x42, 0x8c, 0x92, 0x10, 0xeb, 0x0a, 0xda,
+	0x69, 0x80, 0x4e, 0xf6, 0x9c, 0xe7, 0x69, 0x93, 0x3f, 0x49, 0x5b, 0xdf, 0x0f, 0x5d, 0x58, 0x5f,
+	0x09, 0x40, 0x7a, 0xf0, 0x33, 0xc2, 0xea, 0x77, 0xf4, 0x2f, 0x8e, 0x3b, 0xbd, 0x0f, 0x0d, 0xfd,
+	0xbb, 0x0a, 0x99, 0xd9, 0xa9, 0x1d, 0xb5, 0xbb, 0x7b, 0x33, 0x7f, 0xcc, 0xc2, 0x84, 0x9d, 0xcf,
+	0x3b, 0xee, 0x02, 0x14, 0xaa, 0x69, 0x1b, 0x1a, 0xbd, 0xb3, 0x33, 0xbb, 0x7f, 0x7d, 0xbd, 0xb5,
+	0x46, 0x37, 0xa1, 0x75, 0xd9, 0xbb, 0xe8, 0x5f, 0x3f, 0xef, 0x9d, 0xf6, 0xb7, 0x08, 0x6d, 0x82,
+	0x39, 0xe8, 0xbf, 0x1a, 0x6c, 0x19, 0xbf, 0x03, 0x00, 0x00, 0xff, 0xff, 0xd6, 0x86, 0x28, 0xe9,
+	0x13, 0x08, 0x00, 0x00,
 }
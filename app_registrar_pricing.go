@@ -0,0 +1,29 @@
+package lazyledger
+
+// RegistrationPriceFunc returns the price of registering or renewing a name.
+type RegistrationPriceFunc = func(name []byte) uint64
+
+// FlatPrice returns a pricing function that charges the same price for every name.
+func FlatPrice(price uint64) RegistrationPriceFunc {
+    return func(name []byte) uint64 {
+        return price
+    }
+}
+
+// LengthPrice returns a pricing function that charges by name length:
+// names of length i + 1 cost prices[i], and names longer than len(prices) cost the last price.
+func LengthPrice(prices []uint64) RegistrationPriceFunc {
+    prices = append([]uint64(nil), prices...)
+    return func(name []byte) uint64 {
+        if len(prices) == 0 {
+            return 0
+        }
+        if len(name) == 0 {
+            return prices[0]
+        }
+        if len(name) > len(prices) {
+            return prices[len(prices) - 1]
+        }
+        return prices[len(name) - 1]
+    }
+}
@@ -2,7 +2,6 @@ package lazyledger
 
 import (
     "crypto/rand"
-    "encoding/binary"
     "testing"
     "bytes"
 
@@ -21,27 +20,306 @@ func TestAppRegistrarSimpleBlock(t *testing.T) {
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubBBytes, _ := pubB.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000)
-    ms1.Put(pubABytes, pubABalanceBytes)
+    addrA := Address(pubA)
+    addrB := Address(pubB)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currencyApp.(*Currency).LoadGenesis(genesis)
 
     ms2 := NewSimpleMap()
-    registrarApp := NewRegistrar(ms2, currencyApp.(*Currency), pubBBytes)
+    registrarApp := NewRegistrar(ms2, currencyApp.(*Currency), addrB)
     b.RegisterApplication(&registrarApp)
 
-    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privA, pubB, 100, nil))
-    sb.AddMessage(registrarApp.(*Registrar).GenerateTransaction(privA, []byte("foo")))
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privA, pubB, 100, 0, nil))
+    sb.AddMessage(registrarApp.(*Registrar).GenerateCommitTransaction(pubA, []byte("foo"), []byte("salt")))
+    b.ProcessBlock(sb)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrarApp.(*Registrar).GenerateTransaction(privA, []byte("foo"), []byte("salt")))
     b.ProcessBlock(sb)
 
     if currencyApp.(*Currency).Balance(pubA) != 900 || currencyApp.(*Currency).Balance(pubB) != 100 {
         t.Error("test tranasaction failed: invalid post-balances")
     }
-    if registrarApp.(*Registrar).Balance(pubABytes) != 100 {
+    if registrarApp.(*Registrar).Balance(addrA) != 95 {
         t.Error("test tranasaction failed: invalid post-balances in registrar")
     }
-    if bytes.Compare(registrarApp.(*Registrar).Name([]byte("foo")), pubABytes) != 0 {
+    if bytes.Compare(registrarApp.(*Registrar).Name([]byte("foo")), addrA) != 0 {
+        t.Error("failed to register name")
+    }
+}
+
+func TestAppRegistrarTransferRenewExpiry(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms1 := NewSimpleMap()
+    currencyApp := NewCurrency(ms1, b)
+    b.RegisterApplication(&currencyApp)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubOwner, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    addrA := Address(pubA)
+    addrB := Address(pubB)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    genesis.Add(pubB, 1000)
+    currencyApp.(*Currency).LoadGenesis(genesis)
+
+    ms2 := NewSimpleMap()
+    registrarApp := NewRegistrar(ms2, currencyApp.(*Currency), Address(pubOwner))
+    registrarApp.(*Registrar).SetRegistrationPeriod(3)
+    b.RegisterApplication(&registrarApp)
+    registrar := registrarApp.(*Registrar)
+
+    // Height 1: A prepays and commits to foo, and at height 2 registers it until height 5.
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privA, pubOwner, 10, 0, nil))
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privB, pubOwner, 10, 0, nil))
+    sb.AddMessage(registrar.GenerateCommitTransaction(pubA, []byte("foo"), []byte("salt")))
+    b.ProcessBlock(sb)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrar.GenerateTransaction(privA, []byte("foo"), []byte("salt")))
+    b.ProcessBlock(sb)
+    if bytes.Compare(registrar.Name([]byte("foo")), addrA) != 0 || registrar.Expiry([]byte("foo")) != 5 {
+        t.Fatal("failed to register name")
+    }
+
+    // Height 3: A transfers foo to B.
+    transferToB := registrar.GenerateTransferTransaction(privA, []byte("foo"), addrB)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(transferToB)
+    b.ProcessBlock(sb)
+    if bytes.Compare(registrar.Name([]byte("foo")), addrB) != 0 {
+        t.Error("failed to transfer name")
+    }
+
+    // Height 4: B renews foo until height 8.
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrar.GenerateRenewTransaction(privB, []byte("foo")))
+    b.ProcessBlock(sb)
+    if registrar.Expiry([]byte("foo")) != 8 || registrar.Balance(addrB) != 5 {
+        t.Error("failed to renew name")
+    }
+
+    // Height 5: B transfers foo back to A, after which the first transfer can't be replayed.
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrar.GenerateTransferTransaction(privB, []byte("foo"), addrA))
+    sb.AddMessage(transferToB)
+    b.ProcessBlock(sb)
+    if bytes.Compare(registrar.Name([]byte("foo")), addrA) != 0 {
+        t.Error("failed to transfer name back, or transfer was replayed")
+    }
+
+    // Heights 6 to 8: foo expires and is released.
+    for i := 0; i < 3; i++ {
+        sb = NewSimpleBlock(sb.Digest())
+        b.ProcessBlock(sb)
+    }
+    if len(registrar.Name([]byte("foo"))) != 0 {
+        t.Error("expired name was not released")
+    }
+    if _, err := ms2.Get([]byte("name__foo")); err == nil {
+        t.Error("expired name record was not deleted")
+    }
+}
+
+func TestAppRegistrarRecords(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms1 := NewSimpleMap()
+    currencyApp := NewCurrency(ms1, b)
+    b.RegisterApplication(&currencyApp)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubOwner, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currencyApp.(*Currency).LoadGenesis(genesis)
+
+    ms2 := NewSimpleMap()
+    registrarApp := NewRegistrar(ms2, currencyApp.(*Currency), Address(pubOwner))
+    b.RegisterApplication(&registrarApp)
+    registrar := registrarApp.(*Registrar)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privA, pubOwner, 10, 0, nil))
+    sb.AddMessage(registrar.GenerateCommitTransaction(pubA, []byte("foo"), []byte("salt")))
+    b.ProcessBlock(sb)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrar.GenerateTransaction(privA, []byte("foo"), []byte("salt")))
+    b.ProcessBlock(sb)
+
+    // Each update is signed against the record's sequence, so they are applied one block at a time.
+    var namespace [namespaceSize]byte
+    copy(namespace[:], []byte("foons"))
+    processUpdate := func(recordType RecordType, value []byte) {
+        sb = NewSimpleBlock(sb.Digest())
+        sb.AddMessage(registrar.GenerateUpdateRecordTransaction(privA, []byte("foo"), recordType, value))
+        b.ProcessBlock(sb)
+    }
+    processUpdate(RecordType_NAMESPACE, namespace[:])
+    processUpdate(RecordType_TEXT, []byte("hello"))
+    processUpdate(RecordType_ADDRESS, Address(pubB))
+
+    if bytes.Compare(registrar.Resolve([]byte("foo"), RecordType_ADDRESS), Address(pubB)) != 0 {
+        t.Error("failed to resolve address record")
+    }
+    if string(registrar.Resolve([]byte("foo"), RecordType_TEXT)) != "hello" {
+        t.Error("failed to resolve text record")
+    }
+    if resolved, ok := registrar.ResolveNamespace([]byte("foo")); !ok || resolved != namespace {
+        t.Error("failed to resolve namespace record")
+    }
+
+    // Only the owner can update records, and records must match their type.
+    unauthorized := registrar.GenerateUpdateRecordTransaction(privB, []byte("foo"), RecordType_TEXT, []byte("bye"))
+    invalid := registrar.GenerateUpdateRecordTransaction(privA, []byte("foo"), RecordType_ADDRESS, []byte("short"))
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(unauthorized)
+    sb.AddMessage(invalid)
+    b.ProcessBlock(sb)
+    if string(registrar.Resolve([]byte("foo"), RecordType_TEXT)) != "hello" {
+        t.Error("record was updated without the owner's signature")
+    }
+    if receipt, _ := b.Receipt(invalid.Hash()); receipt == nil || receipt.Code != ReceiptRejected {
+        t.Error("expected invalid address record to be rejected")
+    }
+
+    processUpdate(RecordType_TEXT, nil)
+    if registrar.Resolve([]byte("foo"), RecordType_TEXT) != nil {
+        t.Error("failed to delete text record")
+    }
+}
+
+func TestAppRegistrarPricingAndWithdraw(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms1 := NewSimpleMap()
+    currencyApp := NewCurrency(ms1, b)
+    b.RegisterApplication(&currencyApp)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privOwner, pubOwner, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    addrA := Address(pubA)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currencyApp.(*Currency).LoadGenesis(genesis)
+
+    ms2 := NewSimpleMap()
+    registrarApp := NewRegistrar(ms2, currencyApp.(*Currency), Address(pubOwner))
+    b.RegisterApplication(&registrarApp)
+    registrar := registrarApp.(*Registrar)
+    registrar.SetPrice(LengthPrice([]uint64{50, 20, 10}))
+    if registrar.Price([]byte("a")) != 50 || registrar.Price([]byte("abc")) != 10 || registrar.Price([]byte("abcdef")) != 10 {
+        t.Error("invalid length-based price")
+    }
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privA, pubOwner, 100, 0, nil))
+    sb.AddMessage(registrar.GenerateCommitTransaction(pubA, []byte("ab"), []byte("salt")))
+    sb.AddMessage(registrar.GenerateCommitTransaction(pubA, []byte("abcd"), []byte("salt")))
+    b.ProcessBlock(sb)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrar.GenerateTransaction(privA, []byte("ab"), []byte("salt")))
+    sb.AddMessage(registrar.GenerateTransaction(privA, []byte("abcd"), []byte("salt")))
+    b.ProcessBlock(sb)
+
+    if registrar.Balance(addrA) != 70 || registrar.Revenue() != 30 {
+        t.Error("registrations were not charged at their price")
+    }
+
+    withdraw := registrar.GenerateWithdrawTransaction(privA, 60, 0)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(withdraw)
+    sb.AddMessage(withdraw)
+    sb.AddMessage(registrar.GenerateWithdrawTransaction(privA, 20, 1))
+    b.ProcessBlock(sb)
+
+    // Withdrawing doesn't move any coins, which only the owner can do with a signed transfer.
+    if registrar.Balance(addrA) != 10 || registrar.Refund(addrA) != 60 || currencyApp.(*Currency).Balance(pubA) != 900 || currencyApp.(*Currency).Balance(pubOwner) != 100 {
+        t.Error("withdrawal failed: invalid post-balances")
+    }
+    receipts := b.BlockReceipts(sb.Digest())
+    if len(receipts) != 3 || !receipts[0].Success() || receipts[1].Code != ReceiptInvalidNonce || receipts[2].Code != ReceiptInsufficientBalance {
+        t.Error("expected replayed withdrawal and overdraft to fail")
+    }
+
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(registrar.GenerateRefundTransaction(privOwner, addrA, 0))
+    b.ProcessBlock(sb)
+
+    if registrar.Refund(addrA) != 0 || currencyApp.(*Currency).Balance(pubA) != 960 || currencyApp.(*Currency).Balance(pubOwner) != 40 {
+        t.Error("refund failed: invalid post-balances")
+    }
+}
+
+func TestAppRegistrarCommitReveal(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms1 := NewSimpleMap()
+    currencyApp := NewCurrency(ms1, b)
+    b.RegisterApplication(&currencyApp)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubOwner, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    genesis.Add(pubB, 1000)
+    currencyApp.(*Currency).LoadGenesis(genesis)
+
+    ms2 := NewSimpleMap()
+    registrarApp := NewRegistrar(ms2, currencyApp.(*Currency), Address(pubOwner))
+    b.RegisterApplication(&registrarApp)
+    registrar := registrarApp.(*Registrar)
+
+    ms3 := NewSimpleMap()
+    otherRegistrarApp := NewRegistrar(ms3, currencyApp.(*Currency), Address(pubOwner))
+    var otherNamespace [namespaceSize]byte
+    copy(otherNamespace[:], []byte("reggie2"))
+    otherRegistrarApp.(*Registrar).SetNamespace(otherNamespace)
+    b.RegisterApplication(&otherRegistrarApp)
+    otherRegistrar := otherRegistrarApp.(*Registrar)
+
+    // A registration revealed in the same block as its commitment is too early.
+    early := registrar.GenerateTransaction(privA, []byte("foo"), []byte("salt"))
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privA, pubOwner, 20, 0, nil))
+    sb.AddMessage(currencyApp.(*Currency).GenerateTransaction(privB, pubOwner, 20, 0, nil))
+    sb.AddMessage(registrar.GenerateCommitTransaction(pubA, []byte("foo"), []byte("salt")))
+    sb.AddMessage(otherRegistrar.GenerateCommitTransaction(pubA, []byte("foo"), []byte("salt")))
+    sb.AddMessage(early)
+    b.ProcessBlock(sb)
+    if receipt, _ := b.Receipt(early.Hash()); receipt == nil || receipt.Code != ReceiptRejected {
+        t.Error("expected registration in the block of its commitment to fail")
+    }
+
+    // B saw the name but can't register it without a commitment of their own.
+    frontRun := registrar.GenerateTransaction(privB, []byte("foo"), []byte("salt"))
+    // A's registration can't be replayed on another registrar, since signatures are bound to the namespace.
+    replayed := registrar.GenerateTransaction(privA, []byte("foo"), []byte("salt"))
+    replayed.namespace = otherRegistrar.Namespace()
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(frontRun)
+    sb.AddMessage(registrar.GenerateTransaction(privA, []byte("foo"), []byte("salt")))
+    sb.AddMessage(replayed)
+    b.ProcessBlock(sb)
+
+    if bytes.Compare(registrar.Name([]byte("foo")), Address(pubA)) != 0 {
         t.Error("failed to register name")
     }
+    if len(otherRegistrar.Name([]byte("foo"))) != 0 {
+        t.Error("registration was replayed on another registrar")
+    }
+    if receipt, _ := b.Receipt(replayed.Hash()); receipt == nil || receipt.Code != ReceiptInvalidSignature {
+        t.Error("expected replayed registration to fail signature verification")
+    }
+    if _, err := ms2.Get(append([]byte("commitment__"), registrar.Commitment([]byte("foo"), Address(pubA), []byte("salt"))...)); err == nil {
+        t.Error("revealed commitment was not deleted")
+    }
 }
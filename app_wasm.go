@@ -0,0 +1,241 @@
+package lazyledger
+
+import (
+    "errors"
+    "fmt"
+
+    "github.com/lazyledger/lazyledger-prototype/wasm"
+)
+
+// ErrInvalidWASMModule is returned when a WebAssembly module doesn't implement the application interface.
+var ErrInvalidWASMModule = errors.New("module does not implement a WASM application")
+
+// wasmKeyPrefix prefixes the keys written by a module, so it can't overwrite the application's internal keys.
+const wasmKeyPrefix = "wasm__"
+
+// Gas costs of host calls: every call costs wasmHostCallGas, plus wasmByteGas per byte moved in or out of the sandbox.
+const (
+    wasmHostCallGas = 100
+    wasmByteGas = 1
+)
+
+// WASMApplication is an application whose state machine is a WebAssembly module, run in a deterministic sandbox.
+// Each message is processed by a fresh instance of the module with its own memory and gas limit.
+//
+// The module exports process() i32, which returns a ReceiptCode, and can import these functions from "env":
+//
+//     message_size() i32
+//     message_read(ptr i32)
+//     state_get(key_ptr i32, key_len i32, value_ptr i32, value_cap i32) i32
+//     state_put(key_ptr i32, key_len i32, value_ptr i32, value_len i32)
+//     state_delete(key_ptr i32, key_len i32)
+//     emit_event(type_ptr i32, type_len i32)
+//     event_attribute(key_ptr i32, key_len i32, value_ptr i32, value_len i32)
+//     reject(reason_ptr i32, reason_len i32)
+//
+// state_get copies at most value_cap bytes of a value, and returns the value's size, or -1 if the key isn't set.
+// event_attribute adds an attribute to the last event emitted, and reject sets the reason of a failure receipt.
+type WASMApplication struct {
+    *BaseApplication
+    module *wasm.Module
+    gasLimit uint64
+}
+
+// NewWASMApplication creates an application for a namespace that runs a WebAssembly module,
+// with a gas limit for processing each message.
+func NewWASMApplication(namespace [namespaceSize]byte, code []byte, state MapStore, gasLimit uint64) (Application, error) {
+    module, err := wasm.Parse(code)
+    if err != nil {
+        return nil, err
+    }
+    if export, ok := module.Exports["process"]; !ok || export.Kind != wasm.ExternalFunction {
+        return nil, ErrInvalidWASMModule
+    }
+    hostFunctions := (&wasmExecution{}).imports()
+    for _, imp := range module.Imports {
+        if _, ok := hostFunctions[imp.Module][imp.Name]; !ok {
+            return nil, fmt.Errorf("%v: unknown import %s.%s", ErrInvalidWASMModule, imp.Module, imp.Name)
+        }
+    }
+    return &WASMApplication{
+        BaseApplication: NewBaseApplication(namespace, state),
+        module: module,
+        gasLimit: gasLimit,
+    }, nil
+}
+
+// ProcessMessage runs the module's process function on a message.
+// The module's writes are only applied if it returns ReceiptOK.
+func (app *WASMApplication) ProcessMessage(message Message) *Receipt {
+    execution := &wasmExecution{
+        message: message.Data(),
+        state: NewBatch(app.State()),
+    }
+    instance, err := wasm.Instantiate(app.module, execution.imports(), wasm.Config{GasLimit: app.gasLimit})
+    if err != nil {
+        return wasmFailureReceipt(err)
+    }
+    results, err := instance.Call("process")
+    if err != nil {
+        return wasmFailureReceipt(err)
+    }
+    if len(results) != 1 {
+        return NewFailureReceipt(ReceiptRejected, "process must return a receipt code")
+    }
+    code := ReceiptCode(int32(results[0]))
+    if code != ReceiptOK {
+        if _, ok := receiptCodeNames[code]; !ok {
+            code = ReceiptRejected
+        }
+        reason := execution.reason
+        if reason == "" {
+            reason = fmt.Sprintf("module returned code %d", int32(results[0]))
+        }
+        return NewFailureReceipt(code, reason)
+    }
+    if err := execution.state.Commit(); err != nil {
+        return NewFailureReceipt(ReceiptStorageError, err.Error())
+    }
+    return NewReceipt(execution.events...)
+}
+
+func wasmFailureReceipt(err error) *Receipt {
+    if err == wasm.ErrOutOfGas {
+        return NewFailureReceipt(ReceiptOutOfGas, err.Error())
+    }
+    return NewFailureReceipt(ReceiptRejected, err.Error())
+}
+
+// Get gets a value written by the module, or nil if the key isn't set.
+func (app *WASMApplication) Get(key []byte) []byte {
+    return app.GetBytes(append([]byte(wasmKeyPrefix), key...))
+}
+
+// wasmExecution holds the host side of processing one message.
+type wasmExecution struct {
+    message []byte
+    state *Batch
+    events []Event
+    reason string
+}
+
+func (e *wasmExecution) imports() wasm.Imports {
+    i32 := wasm.I32
+    return wasm.Imports{
+        "env": {
+            "message_size": e.host(nil, []wasm.ValueType{i32}, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                return uint32(len(e.message)), nil
+            }),
+            "message_read": e.host([]wasm.ValueType{i32}, nil, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                if err := instance.UseGas(uint64(len(e.message)) * wasmByteGas); err != nil {
+                    return 0, err
+                }
+                return 0, instance.WriteMemory(args[0], e.message)
+            }),
+            "state_get": e.host([]wasm.ValueType{i32, i32, i32, i32}, []wasm.ValueType{i32}, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                key, err := readMemory(instance, args[0], args[1])
+                if err != nil {
+                    return 0, err
+                }
+                value, err := e.state.Get(append([]byte(wasmKeyPrefix), key...))
+                if err != nil {
+                    return 0xffffffff, nil
+                }
+                n := len(value)
+                if uint32(n) > args[3] {
+                    n = int(args[3])
+                }
+                if err := instance.UseGas(uint64(n) * wasmByteGas); err != nil {
+                    return 0, err
+                }
+                return uint32(len(value)), instance.WriteMemory(args[2], value[:n])
+            }),
+            "state_put": e.host([]wasm.ValueType{i32, i32, i32, i32}, nil, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                key, err := readMemory(instance, args[0], args[1])
+                if err != nil {
+                    return 0, err
+                }
+                value, err := readMemory(instance, args[2], args[3])
+                if err != nil {
+                    return 0, err
+                }
+                return 0, e.state.Put(append([]byte(wasmKeyPrefix), key...), value)
+            }),
+            "state_delete": e.host([]wasm.ValueType{i32, i32}, nil, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                key, err := readMemory(instance, args[0], args[1])
+                if err != nil {
+                    return 0, err
+                }
+                key = append([]byte(wasmKeyPrefix), key...)
+                if _, err := e.state.Get(key); err != nil {
+                    return 0, nil
+                }
+                return 0, e.state.Del(key)
+            }),
+            "emit_event": e.host([]wasm.ValueType{i32, i32}, nil, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                eventType, err := readMemory(instance, args[0], args[1])
+                if err != nil {
+                    return 0, err
+                }
+                e.events = append(e.events, NewEvent(string(eventType)))
+                return 0, nil
+            }),
+            "event_attribute": e.host([]wasm.ValueType{i32, i32, i32, i32}, nil, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                if len(e.events) == 0 {
+                    return 0, &wasm.Trap{Reason: "event attribute without an event"}
+                }
+                key, err := readMemory(instance, args[0], args[1])
+                if err != nil {
+                    return 0, err
+                }
+                value, err := readMemory(instance, args[2], args[3])
+                if err != nil {
+                    return 0, err
+                }
+                event := &e.events[len(e.events) - 1]
+                event.Attributes = append(event.Attributes, EventAttribute{Key: string(key), Value: value})
+                return 0, nil
+            }),
+            "reject": e.host([]wasm.ValueType{i32, i32}, nil, func(instance *wasm.Instance, args []uint32) (uint32, error) {
+                reason, err := readMemory(instance, args[0], args[1])
+                if err != nil {
+                    return 0, err
+                }
+                e.reason = string(reason)
+                return 0, nil
+            }),
+        },
+    }
+}
+
+// host wraps a host function over i32 arguments, charging the base gas cost of a host call.
+func (e *wasmExecution) host(params []wasm.ValueType, results []wasm.ValueType, fn func(instance *wasm.Instance, args []uint32) (uint32, error)) wasm.HostFunction {
+    return wasm.HostFunction{
+        Type: wasm.FunctionType{Params: params, Results: results},
+        Call: func(instance *wasm.Instance, args []uint64) ([]uint64, error) {
+            if err := instance.UseGas(wasmHostCallGas); err != nil {
+                return nil, err
+            }
+            args32 := make([]uint32, len(args))
+            for i, arg := range args {
+                args32[i] = uint32(arg)
+            }
+            result, err := fn(instance, args32)
+            if err != nil {
+                return nil, err
+            }
+            if len(results) == 0 {
+                return nil, nil
+            }
+            return []uint64{uint64(result)}, nil
+        },
+    }
+}
+
+// readMemory copies a range of a module's memory, charging gas for its size.
+func readMemory(instance *wasm.Instance, ptr uint32, length uint32) ([]byte, error) {
+    if err := instance.UseGas(uint64(length) * wasmByteGas); err != nil {
+        return nil, err
+    }
+    return instance.ReadMemory(ptr, length)
+}
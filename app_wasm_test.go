@@ -0,0 +1,155 @@
+package lazyledger
+
+import (
+    "encoding/binary"
+    "testing"
+)
+
+func wasmLEB(v int) []byte {
+    var out []byte
+    for v >= 0x80 {
+        out = append(out, byte(v & 0x7f) | 0x80)
+        v >>= 7
+    }
+    return append(out, byte(v))
+}
+
+func wasmVec(items ...[]byte) []byte {
+    out := wasmLEB(len(items))
+    for _, item := range items {
+        out = append(out, item...)
+    }
+    return out
+}
+
+func wasmName(s string) []byte {
+    return append(wasmLEB(len(s)), s...)
+}
+
+func wasmSection(id byte, contents []byte) []byte {
+    return append(append([]byte{id}, wasmLEB(len(contents))...), contents...)
+}
+
+func wasmImport(name string, typeIndex byte) []byte {
+    return append(append(wasmName("env"), wasmName(name)...), 0x00, typeIndex)
+}
+
+// wasmCounterModule builds a module whose messages increment a counter keyed by the message's data.
+// If spin is set, process loops forever instead.
+func wasmCounterModule(spin bool) []byte {
+    types := wasmVec(
+        []byte{0x60, 0x00, 0x01, 0x7f},
+        []byte{0x60, 0x01, 0x7f, 0x00},
+        []byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f},
+        []byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x00},
+        []byte{0x60, 0x02, 0x7f, 0x7f, 0x00},
+    )
+    imports := wasmVec(
+        wasmImport("message_size", 0),
+        wasmImport("message_read", 1),
+        wasmImport("state_get", 2),
+        wasmImport("state_put", 3),
+        wasmImport("emit_event", 4),
+        wasmImport("reject", 4),
+    )
+    code := []byte{
+        0x01, 0x01, 0x7f,
+        // len = message_size(); if len == 0 { reject("empty key"); return ReceiptRejected }
+        0x10, 0x00, 0x21, 0x00,
+        0x20, 0x00, 0x45, 0x04, 0x40,
+        0x41, 0x80, 0x02, 0x41, 0x09, 0x10, 0x05,
+        0x41, byte(ReceiptRejected), 0x0f,
+        0x0b,
+        // message_read(0); if state_get(0, len, 128, 8) == -1 { memory[128] = 0 }
+        0x41, 0x00, 0x10, 0x01,
+        0x41, 0x00, 0x20, 0x00, 0x41, 0x80, 0x01, 0x41, 0x08, 0x10, 0x02,
+        0x41, 0x7f, 0x46, 0x04, 0x40,
+        0x41, 0x80, 0x01, 0x42, 0x00, 0x37, 0x03, 0x00,
+        0x0b,
+        // memory[128] += 1; state_put(0, len, 128, 8); emit_event("increment")
+        0x41, 0x80, 0x01, 0x41, 0x80, 0x01, 0x29, 0x03, 0x00, 0x42, 0x01, 0x7c, 0x37, 0x03, 0x00,
+        0x41, 0x00, 0x20, 0x00, 0x41, 0x80, 0x01, 0x41, 0x08, 0x10, 0x03,
+        0x41, 0xac, 0x02, 0x41, 0x09, 0x10, 0x04,
+        0x41, 0x00, 0x0b,
+    }
+    if spin {
+        code = []byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b}
+    }
+    data := wasmVec(
+        append([]byte{0x00, 0x41, 0x80, 0x02, 0x0b}, wasmName("empty key")...),
+        append([]byte{0x00, 0x41, 0xac, 0x02, 0x0b}, wasmName("increment")...),
+    )
+
+    module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+    module = append(module, wasmSection(1, types)...)
+    module = append(module, wasmSection(2, imports)...)
+    module = append(module, wasmSection(3, wasmVec([]byte{0x00}))...)
+    module = append(module, wasmSection(5, wasmVec([]byte{0x00, 0x01}))...)
+    module = append(module, wasmSection(7, wasmVec(append(wasmName("process"), 0x00, 0x06)))...)
+    module = append(module, wasmSection(10, wasmVec(append(wasmLEB(len(code)), code...)))...)
+    return append(module, wasmSection(11, data)...)
+}
+
+func TestAppWASM(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    var namespace [namespaceSize]byte
+    copy(namespace[:], []byte("wasm"))
+    app, err := NewWASMApplication(namespace, wasmCounterModule(false), NewSimpleMap(), 100000)
+    if err != nil {
+        t.Fatal(err)
+    }
+    b.RegisterApplication(&app)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(*NewMessage(namespace, []byte("foo")))
+    sb.AddMessage(*NewMessage(namespace, []byte("foo")))
+    sb.AddMessage(*NewMessage(namespace, []byte("bar")))
+    sb.AddMessage(*NewMessage(namespace, []byte{}))
+    b.ProcessBlock(sb)
+
+    wasmApp := app.(*WASMApplication)
+    if binary.LittleEndian.Uint64(wasmApp.Get([]byte("foo"))) != 2 || binary.LittleEndian.Uint64(wasmApp.Get([]byte("bar"))) != 1 {
+        t.Error("wrong counter values")
+    }
+    if string(wasmApp.BlockHead()) != string(sb.Digest()) {
+        t.Error("block head not tracked")
+    }
+    receipts := b.BlockReceipts(sb.Digest())
+    if len(receipts) != 4 {
+        t.Fatalf("expected 4 receipts, got %d", len(receipts))
+    }
+    if !receipts[0].Success() || len(receipts[0].Events) != 1 || receipts[0].Events[0].Type != "increment" {
+        t.Error("wrong receipt for increment")
+    }
+    if receipts[3].Code != ReceiptRejected || receipts[3].Reason != "empty key" {
+        t.Errorf("wrong receipt for empty key: %s", receipts[3])
+    }
+}
+
+func TestAppWASMOutOfGas(t *testing.T) {
+    var namespace [namespaceSize]byte
+    copy(namespace[:], []byte("wasm"))
+
+    app, err := NewWASMApplication(namespace, wasmCounterModule(false), NewSimpleMap(), 150)
+    if err != nil {
+        t.Fatal(err)
+    }
+    receipt := app.ProcessMessage(*NewMessage(namespace, []byte("foo")))
+    if receipt.Code != ReceiptOutOfGas || app.(*WASMApplication).Get([]byte("foo")) != nil {
+        t.Error("out of gas message should fail without writing state")
+    }
+
+    spinner, err := NewWASMApplication(namespace, wasmCounterModule(true), NewSimpleMap(), 100000)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if spinner.ProcessMessage(*NewMessage(namespace, []byte("foo"))).Code != ReceiptOutOfGas {
+        t.Error("infinite loop should run out of gas")
+    }
+
+    if _, err := NewWASMApplication(namespace, []byte("not wasm"), NewSimpleMap(), 100000); err == nil {
+        t.Error("created an application from an invalid module")
+    }
+}
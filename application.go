@@ -2,8 +2,8 @@ package lazyledger
 
 // Application is an interface for a lazyledger application.
 type Application interface {
-    // ProcessMessage processes a message according to the application's state machine.
-    ProcessMessage(message Message)
+    // ProcessMessage processes a message according to the application's state machine, and returns a receipt of the outcome.
+    ProcessMessage(message Message) *Receipt
 
     // Namespace returns the namespace ID of the application.
     Namespace() [namespaceSize]byte
@@ -14,3 +14,34 @@ type Application interface {
     // SetBlockHead sets the hash of the latest block that has been processed.
     SetBlockHead(hash []byte)
 }
+
+// StatefulApplication is an Application that keeps its state in a MapStore.
+// The Blockchain swaps the state for a Batch while processing a block, so that the block's effects are applied atomically.
+type StatefulApplication interface {
+    Application
+
+    // State returns the store that holds the application's state.
+    State() MapStore
+
+    // SetState replaces the store that holds the application's state.
+    SetState(state MapStore)
+}
+
+// DependentApplication is an Application whose messages can depend on messages in other namespaces.
+// The Blockchain proves a message's dependencies before feeding it to the application,
+// and defers the message to later blocks while they can't be proven.
+type DependentApplication interface {
+    Application
+
+    // Dependencies returns references to the messages that a message depends on.
+    Dependencies(message Message) []DependencyReference
+}
+
+// FinalityApplication is an Application that is notified when the blocks it has processed are finalized.
+// Blocks are finalized in order, and a block is only finalized after it has been processed.
+type FinalityApplication interface {
+    Application
+
+    // BlockFinalized is called with the digest of each block that is finalized.
+    BlockFinalized(digest []byte)
+}
@@ -28,4 +28,16 @@ type Block interface {
     VerifyDependency(int, []byte, [][]byte) bool
 
     DependencyProven([]byte) bool
+
+    // ProveDependencies creates a Merkle multiproof for the messages at several indexes, and returns their hashes.
+    ProveDependencies([]int) ([][]byte, *MultiDependencyProof, error)
+
+    // ProducerSignature returns the signature of the block's producer, or nil if the block is unsigned.
+    ProducerSignature() *ProducerSignature
+
+    // SetProducerSignature sets the signature of the block's producer.
+    SetProducerSignature(*ProducerSignature)
+
+    // VerifyDependencies verifies a Merkle multiproof for several messages, and records them as proven if it is valid.
+    VerifyDependencies([][]byte, *MultiDependencyProof) bool
 }
@@ -9,26 +9,136 @@ import (
 type Blockchain struct {
     blockStore BlockStore
     headBlock Block
-    applications []*Application
+    applications *applicationRegistry
+    receipts map[string]*Receipt
+    blockReceipts map[string][]*Receipt
+    feeCollector []byte
+    dependencyProvider DependencyProofProvider
+    provenDependencies map[string][]provenDependency
+    processingBlock []byte
+    maxDependencyDelay int
+    producers map[string]bool
+    consensus Consensus
+    heights map[string]int
+    finalized map[string]bool
+    finalizedHead []byte
 }
 
 // NewBlockchain returns a new blockchain.
 func NewBlockchain(blockStore BlockStore) *Blockchain {
     return &Blockchain{
         blockStore: blockStore,
+        applications: newApplicationRegistry(),
+        receipts: make(map[string]*Receipt),
+        blockReceipts: make(map[string][]*Receipt),
+        provenDependencies: make(map[string][]provenDependency),
+        maxDependencyDelay: defaultMaxDependencyDelay,
+        producers: make(map[string]bool),
+        heights: make(map[string]int),
+        finalized: make(map[string]bool),
     }
 }
 
 // ProcessBlock processes a new block.
-func (b *Blockchain) ProcessBlock(block Block) {
-    b.blockStore.Put(block.Digest(), block)
+// The effects of the block on the state of each application are applied atomically.
+func (b *Blockchain) ProcessBlock(block Block) error {
+    if err := b.verifyProducer(block); err != nil {
+        return err
+    }
+    if err := b.verifyProposal(block); err != nil {
+        return err
+    }
+    // Messages are grouped by namespace once, so that each application only visits the messages addressed to it.
+    messages := make(map[[namespaceSize]byte][]*pendingMessage)
+    for index, message := range block.Messages() {
+        if len(b.applications.lookup(message.Namespace())) > 0 {
+            messages[message.Namespace()] = append(messages[message.Namespace()], &pendingMessage{message: message, block: block.Digest(), index: index})
+        }
+    }
+    return b.processBlockMessages(block, messages)
+}
+
+// SyncBlockHeader processes a new block of which only the header is known.
+// For each namespace with a registered application, the namespace's messages are fetched from a provider
+// and verified against the header, so that applications are fed the block without downloading the rest of it.
+// If any proof fails to verify, the block is not processed.
+func (b *Blockchain) SyncBlockHeader(header Block, provider ApplicationProofProvider) error {
+    prover, ok := header.(ApplicationProver)
+    if !ok {
+        return ErrLazySyncUnsupported
+    }
+    if err := b.verifyProducer(header); err != nil {
+        return err
+    }
+    if err := b.verifyProposal(header); err != nil {
+        return err
+    }
+    messages := make(map[[namespaceSize]byte][]*pendingMessage)
+    for _, registered := range b.applications.applications {
+        if _, ok := messages[registered.namespace]; ok {
+            continue
+        }
+        proof, err := provider.ApplicationProof(header.Digest(), registered.namespace)
+        if err != nil {
+            return err
+        }
+        if proof.Namespace != registered.namespace || !prover.VerifyApplication(proof) {
+            return ErrInvalidApplicationProof
+        }
+        messages[registered.namespace] = []*pendingMessage{}
+        if proof.Messages != nil {
+            for i, message := range *proof.Messages {
+                if message.Namespace() == registered.namespace {
+                    messages[registered.namespace] = append(messages[registered.namespace], &pendingMessage{message: message, block: header.Digest(), index: proof.ProofStart + i})
+                }
+            }
+        }
+    }
+    return b.processBlockMessages(header, messages)
+}
+
+// processBlockMessages stores a block and processes its messages, grouped by namespace.
+func (b *Blockchain) processBlockMessages(block Block, messages map[[namespaceSize]byte][]*pendingMessage) error {
+    height, err := b.NextHeight(block.PrevHash())
+    if err != nil {
+        return err
+    }
+    // The block is stored while its messages are processed so that dependencies can be proven against it,
+    // and removed again along with the new head if the applications' writes fail to commit.
+    _, getErr := b.blockStore.Get(block.Digest())
+    stored := getErr == nil
+    err = b.blockStore.Put(block.Digest(), block)
+    if err != nil {
+        return err
+    }
 
-    if b.headBlock == nil || bytes.Compare(block.PrevHash(), b.headBlock.Digest()) == 0 {
+    previousHead := b.headBlock
+    isHead := b.headBlock == nil || bytes.Compare(block.PrevHash(), b.headBlock.Digest()) == 0
+    if isHead {
         b.headBlock = block
-        b.processCallbacks(block, true)
-    } else {
-        b.processCallbacks(block, false)
     }
+    receipts, err := b.processCallbacks(block.Digest(), messages, isHead)
+    if err != nil {
+        b.headBlock = previousHead
+        if !stored {
+            b.blockStore.Del(block.Digest())
+        }
+        return err
+    }
+
+    b.heights[string(block.Digest())] = height
+    b.blockReceipts[string(block.Digest())] = receipts
+    for _, receipt := range receipts {
+        // A replayed message must not hide the receipt of the time it was applied.
+        if previous, ok := b.receipts[string(receipt.MessageHash)]; ok && previous.Success() {
+            continue
+        }
+        b.receipts[string(receipt.MessageHash)] = receipt
+    }
+    if b.consensus == nil && isHead {
+        return b.finalize(block.Digest())
+    }
+    return nil
 }
 
 func (b *Blockchain) Block(digest []byte) (Block, error) {
@@ -39,20 +149,181 @@ func (b *Blockchain) Block(digest []byte) (Block, error) {
     return block, nil
 }
 
-// RegisterApplication registers an application instance to call when new relevant messages arrive.
-func (b *Blockchain) RegisterApplication(application *Application) {
-    b.applications = append(b.applications, application)
+// Receipt returns the receipt of a processed message by its hash.
+// If the message was processed more than once, the receipt of the time it was applied is returned, or else the latest one.
+func (b *Blockchain) Receipt(messageHash []byte) (*Receipt, error) {
+    receipt, ok := b.receipts[string(messageHash)]
+    if !ok {
+        return nil, &InvalidKeyError{Key: messageHash}
+    }
+    return receipt, nil
+}
+
+// BlockReceipts returns the receipts of the messages of a processed block, in the order they were processed.
+func (b *Blockchain) BlockReceipts(digest []byte) []*Receipt {
+    return b.blockReceipts[string(digest)]
+}
+
+// SetFeeCollector sets the address that is credited with the fees paid by transactions in blocks without a producer signature.
+func (b *Blockchain) SetFeeCollector(address []byte) {
+    b.feeCollector = address
 }
 
-func (b *Blockchain) processCallbacks(block Block, isHead bool) {
-    for _, application := range b.applications {
+// FeeCollector returns the address that is credited with the fees paid by transactions in the block being processed.
+// That is the address of the block's producer if the block is signed, or else the address set by SetFeeCollector,
+// or nil if fees are burned.
+func (b *Blockchain) FeeCollector() []byte {
+    if b.processingBlock != nil {
+        block, err := b.blockStore.Get(b.processingBlock)
+        if err == nil && block.ProducerSignature() != nil {
+            return addressOf(block.ProducerSignature().Producer)
+        }
+    }
+    return b.feeCollector
+}
+
+// RegisterApplication registers an application instance to call when new messages in its namespace arrive.
+// The application is indexed by the namespace it has when it is registered, and several applications can share a namespace.
+// Registering an application twice, or two applications that keep their state in the same store, is an error.
+func (b *Blockchain) RegisterApplication(application *Application) error {
+    return b.applications.register(application)
+}
+
+// UnregisterApplication stops calling an application for new blocks.
+func (b *Blockchain) UnregisterApplication(application *Application) error {
+    return b.applications.unregister(application)
+}
+
+// Applications returns the applications registered under a namespace, in the order they were registered.
+func (b *Blockchain) Applications(namespace [namespaceSize]byte) []Application {
+    var applications []Application
+    for _, application := range b.applications.lookup(namespace) {
+        applications = append(applications, *application)
+    }
+    return applications
+}
+
+// processCallbacks feeds the messages of a block, grouped by namespace, to the registered applications.
+// While the block is processed, the state of every application that keeps its state in a MapStore is swapped for a batch,
+// including writes made through callbacks between applications. The batches are only committed once the whole block has been processed.
+// Each message is further processed in its own nested batch, which is discarded if its receipt reports a failure.
+// A message whose dependencies can't be proven yet is deferred to the next block, and has no receipt until it is processed.
+func (b *Blockchain) processCallbacks(digest []byte, messages map[[namespaceSize]byte][]*pendingMessage, isHead bool) (receipts []*Receipt, err error) {
+    var batches []*Batch
+    var states []MapStore
+    var statefulApplications []StatefulApplication
+    for _, registered := range b.applications.applications {
+        if sa, ok := (*registered.application).(StatefulApplication); ok {
+            batch := NewBatch(sa.State())
+            states = append(states, sa.State())
+            batches = append(batches, batch)
+            statefulApplications = append(statefulApplications, sa)
+            sa.SetState(batch)
+        }
+    }
+    // The deferred messages are restored along with the application states if the block isn't committed.
+    deferred := make([][]*pendingMessage, len(b.applications.applications))
+    for i, registered := range b.applications.applications {
+        deferred[i] = registered.deferred
+    }
+    restoreDeferred := func() {
+        for i, registered := range b.applications.applications {
+            registered.deferred = deferred[i]
+        }
+    }
+    b.processingBlock = digest
+    defer func() {
+        b.processingBlock = nil
+        for i, sa := range statefulApplications {
+            sa.SetState(states[i])
+        }
+        if r := recover(); r != nil {
+            for _, batch := range batches {
+                batch.Discard()
+            }
+            restoreDeferred()
+            panic(r)
+        }
+        // Each store commits its batch atomically, but they can't commit together, so if one fails the others are rolled back.
+        var undos []*Batch
+        for i, batch := range batches {
+            undo := batch.undo()
+            if commitErr := batch.Commit(); commitErr != nil {
+                for j := len(undos) - 1; j >= 0; j-- {
+                    undos[j].Commit()
+                }
+                for _, remaining := range batches[i + 1:] {
+                    remaining.Discard()
+                }
+                restoreDeferred()
+                if err == nil {
+                    err = commitErr
+                }
+                return
+            }
+            undos = append(undos, undo)
+        }
+    }()
+
+    for _, registered := range b.applications.applications {
         if isHead {
-            (*application).SetBlockHead(block.Digest())
+            (*registered.application).SetBlockHead(digest)
+        }
+        // Messages deferred from earlier blocks are retried before the block's own messages.
+        pending := append(registered.deferred, messages[registered.namespace]...)
+        registered.deferred = nil
+        for _, pm := range pending {
+            if !b.dependenciesProven(*registered.application, pm.message, digest) {
+                if pm.delay < b.maxDependencyDelay {
+                    delayed := *pm
+                    delayed.delay++
+                    registered.deferred = append(registered.deferred, &delayed)
+                    continue
+                }
+                receipt := NewFailureReceipt(ReceiptUnprovenDependency, "dependency not proven in time")
+                receipt.MessageHash = pm.message.Hash()
+                receipts = append(receipts, receipt)
+                continue
+            }
+            receipts = append(receipts, b.processMessage(registered, pm))
+        }
+    }
+    return receipts, nil
+}
+
+// processMessage feeds a message to an application and delivers the events it emits to their subscribers,
+// and returns its receipt. The writes of the message and of the event consumers are rolled back if either failed.
+func (b *Blockchain) processMessage(registered *registration, pm *pendingMessage) *Receipt {
+    application := *registered.application
+    var receipt *Receipt
+    if sa, ok := application.(StatefulApplication); ok {
+        blockState := sa.State()
+        batch := NewBatch(blockState)
+        sa.SetState(batch)
+        receipt = application.ProcessMessage(pm.message)
+        sa.SetState(blockState)
+        if receipt == nil || receipt.Success() {
+            if failure := b.deliverEvents(registered, pm, receipt); failure != nil {
+                receipt = failure
+            } else if err := batch.Commit(); err != nil {
+                receipt = NewFailureReceipt(ReceiptStorageError, err.Error())
+            }
         }
-        for _, message := range block.Messages() {
-            if message.Namespace() == (*application).Namespace() {
-                (*application).ProcessMessage(message)
+        if receipt != nil && !receipt.Success() {
+            batch.Discard()
+        }
+    } else {
+        receipt = application.ProcessMessage(pm.message)
+        if receipt == nil || receipt.Success() {
+            if failure := b.deliverEvents(registered, pm, receipt); failure != nil {
+                receipt = failure
             }
         }
     }
+
+    if receipt == nil {
+        receipt = NewReceipt()
+    }
+    receipt.MessageHash = pm.message.Hash()
+    return receipt
 }
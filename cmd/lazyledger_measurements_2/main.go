@@ -2,7 +2,6 @@ package main
 
 import (
     "crypto/rand"
-    "encoding/binary"
     "fmt"
 
     "github.com/lazyledger/lazyledger-prototype"
@@ -65,13 +64,12 @@ func generateSimpleBlock(currencyTxes int, otherTxes, txSize int) (*lazyledger.S
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    app.(*lazyledger.Currency).LoadGenesis(genesis)
 
     for i := 0; i < currencyTxes; i++ {
-        sb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        sb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(i), nil))
     }
 
     for i := 0; i < otherTxes; i++ {
@@ -95,13 +93,12 @@ func generateProbabilisticBlock(currencyTxes int, otherTxes, txSize int) (*lazyl
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    app.(*lazyledger.Currency).LoadGenesis(genesis)
 
     for i := 0; i < currencyTxes; i++ {
-        pb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        pb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(i), nil))
     }
 
     for i := 0; i < otherTxes; i++ {
@@ -2,7 +2,6 @@ package main
 
 import (
     "crypto/rand"
-    "encoding/binary"
     "fmt"
 
     "github.com/lazyledger/lazyledger-prototype"
@@ -41,14 +40,13 @@ func generateSimpleBlock(currencyTxes int, otherTxes, txSize int) (*lazyledger.S
     b.RegisterApplication(&app2)
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    app.(*lazyledger.Currency).LoadGenesis(genesis)
 
     for i := 0; i < currencyTxes; i++ {
         _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-        sb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        sb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(i), nil))
     }
 
     for i := 0; i < otherTxes; i++ {
@@ -77,10 +75,9 @@ func generateProbabilisticBlock(currencyTxes int, otherTxes, txSize int) (*lazyl
     b.RegisterApplication(&app)
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms.Put(pubABytes, pubABalanceBytes)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    app.(*lazyledger.Currency).LoadGenesis(genesis)
 
     ms2 := lazyledger.NewSimpleMap()
     app2 := lazyledger.NewDummyApp(ms2)
@@ -88,7 +85,7 @@ func generateProbabilisticBlock(currencyTxes int, otherTxes, txSize int) (*lazyl
 
     for i := 0; i < currencyTxes; i++ {
         _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-        pb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        pb.AddMessage(app.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(i), nil))
     }
 
     for i := 0; i < otherTxes; i++ {
@@ -2,7 +2,6 @@ package main
 
 import (
     "crypto/rand"
-    "encoding/binary"
     "fmt"
 
     "github.com/lazyledger/lazyledger-prototype"
@@ -86,26 +85,25 @@ func generateSimpleBlock(registrarTxes int, otherTxes, txSize int) (*lazyledger.
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubBBytes, _ := pubB.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms1.Put(pubABytes, pubABalanceBytes)
+    addrB := lazyledger.Address(pubB)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    currencyApp.(*lazyledger.Currency).LoadGenesis(genesis)
 
     ms2 := lazyledger.NewSimpleMap()
-    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), pubBBytes)
+    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), addrB)
     b.RegisterApplication(&registrarApp)
 
     for i := 0; i < registrarTxes; i++ {
-        sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(i), nil))
     }
 
     ms3 := lazyledger.NewSimpleMap()
-    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), pubBBytes)
+    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), addrB)
     b.RegisterApplication(&registrarApp2)
 
     for i := 0; i < otherTxes; i++ {
-        sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(registrarTxes + i), nil))
     }
 
     return sb.(*lazyledger.SimpleBlock), currencyApp.Namespace(), registrarApp.Namespace()
@@ -124,26 +122,25 @@ func generateProbabilisticBlock(registrarTxes int, otherTxes, txSize int) (*lazy
 
     privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
     _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
-    pubABytes, _ := pubA.Bytes()
-    pubBBytes, _ := pubB.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms1.Put(pubABytes, pubABalanceBytes)
+    addrB := lazyledger.Address(pubB)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    currencyApp.(*lazyledger.Currency).LoadGenesis(genesis)
 
     ms2 := lazyledger.NewSimpleMap()
-    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), pubBBytes)
+    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), addrB)
     b.RegisterApplication(&registrarApp)
 
     for i := 0; i < registrarTxes; i++ {
-        pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(i), nil))
     }
 
     ms3 := lazyledger.NewSimpleMap()
-    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), pubBBytes)
+    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), addrB)
     b.RegisterApplication(&registrarApp2)
 
     for i := 0; i < otherTxes; i++ {
-        pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, nil))
+        pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 1, uint64(registrarTxes + i), nil))
     }
 
     return pb.(*lazyledger.ProbabilisticBlock), currencyApp.Namespace(), registrarApp.Namespace()
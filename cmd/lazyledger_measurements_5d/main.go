@@ -2,7 +2,6 @@ package main
 
 import (
     "crypto/rand"
-    "encoding/binary"
     "fmt"
 
     "github.com/lazyledger/lazyledger-prototype"
@@ -87,52 +86,52 @@ func generateSimpleBlock(registrarTxes int, otherTxes, txSize int) (*lazyledger.
     bs := lazyledger.NewSimpleBlockStore()
     b := lazyledger.NewBlockchain(bs)
 
-    sb := lazyledger.NewSimpleBlock([]byte{0})
-
     ms1 := lazyledger.NewSimpleMap()
     currencyApp := lazyledger.NewCurrency(ms1, b)
     b.RegisterApplication(&currencyApp)
 
-    pubABytes, _ := pubA.Bytes()
-    pubBBytes, _ := pubB.Bytes()
-    pubCBytes, _ := pubC.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms1.Put(pubABytes, pubABalanceBytes)
+    addrB := lazyledger.Address(pubB)
+    addrC := lazyledger.Address(pubC)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    currencyApp.(*lazyledger.Currency).LoadGenesis(genesis)
+    sb := lazyledger.NewSimpleBlock(revealPublicKey(b, currencyApp.(*lazyledger.Currency), privA))
 
     ms2 := lazyledger.NewSimpleMap()
-    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), pubBBytes)
+    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), addrB)
     var rns1 [namespaceSize]byte
     copy(rns1[:], []byte("reg1"))
     registrarApp.(*lazyledger.Registrar).SetNamespace(rns1)
     b.RegisterApplication(&registrarApp)
-    sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 100000, nil))
+    sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 100000, 1, nil))
 
     for i := 0; i < registrarTxes; i++ {
         name := make([]byte, 8)
         //rand.Read(name)
-        sb.AddMessage(registrarApp.(*lazyledger.Registrar).GenerateTransaction(privA, name))
+        salt := make([]byte, 16)
+        sb.AddMessage(registrarApp.(*lazyledger.Registrar).GenerateTransaction(privA, name, salt))
     }
 
     ms3 := lazyledger.NewSimpleMap()
-    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), pubCBytes)
+    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), addrC)
     var rns2 [namespaceSize]byte
     copy(rns2[:], []byte("reg2"))
     registrarApp2.(*lazyledger.Registrar).SetNamespace(rns2)
     b.RegisterApplication(&registrarApp2)
-    sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubC, 100000, nil))
+    sb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubC, 100000, 2, nil))
 
     for i := 0; i < otherTxes; i++ {
         name := make([]byte, 8)
         //rand.Read(name)
-        sb.AddMessage(registrarApp2.(*lazyledger.Registrar).GenerateTransaction(privA, name))
+        salt := make([]byte, 16)
+        sb.AddMessage(registrarApp2.(*lazyledger.Registrar).GenerateTransaction(privA, name, salt))
     }
 
     return sb.(*lazyledger.SimpleBlock), currencyApp.Namespace(), registrarApp.Namespace()
 }
 
 func generateProbabilisticBlock(registrarTxes int, otherTxes, txSize int) (*lazyledger.ProbabilisticBlock, [namespaceSize]byte, [namespaceSize]byte) {
-    pb := lazyledger.NewProbabilisticBlock([]byte{0}, txSize)
+    shareSize := txSize
     txSize -= namespaceSize + 2
 
     bs := lazyledger.NewSimpleBlockStore()
@@ -142,40 +141,52 @@ func generateProbabilisticBlock(registrarTxes int, otherTxes, txSize int) (*lazy
     currencyApp := lazyledger.NewCurrency(ms1, b)
     b.RegisterApplication(&currencyApp)
 
-    pubABytes, _ := pubA.Bytes()
-    pubBBytes, _ := pubB.Bytes()
-    pubCBytes, _ := pubC.Bytes()
-    pubABalanceBytes := make([]byte, binary.MaxVarintLen64)
-    binary.BigEndian.PutUint64(pubABalanceBytes, 1000000)
-    ms1.Put(pubABytes, pubABalanceBytes)
+    addrB := lazyledger.Address(pubB)
+    addrC := lazyledger.Address(pubC)
+    genesis := lazyledger.NewCurrencyGenesis()
+    genesis.Add(pubA, 1000000)
+    currencyApp.(*lazyledger.Currency).LoadGenesis(genesis)
+    pb := lazyledger.NewProbabilisticBlock(revealPublicKey(b, currencyApp.(*lazyledger.Currency), privA), shareSize)
 
     ms2 := lazyledger.NewSimpleMap()
-    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), pubBBytes)
+    registrarApp := lazyledger.NewRegistrar(ms2, currencyApp.(*lazyledger.Currency), addrB)
     var rns1 [namespaceSize]byte
     copy(rns1[:], []byte("reg1"))
     registrarApp.(*lazyledger.Registrar).SetNamespace(rns1)
     b.RegisterApplication(&registrarApp)
-    pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 100000, nil))
+    pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubB, 100000, 1, nil))
 
     for i := 0; i < registrarTxes; i++ {
         name := make([]byte, 8)
         //rand.Read(name)
-        pb.AddMessage(registrarApp.(*lazyledger.Registrar).GenerateTransaction(privA, name))
+        salt := make([]byte, 16)
+        pb.AddMessage(registrarApp.(*lazyledger.Registrar).GenerateTransaction(privA, name, salt))
     }
 
     ms3 := lazyledger.NewSimpleMap()
-    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), pubCBytes)
+    registrarApp2 := lazyledger.NewRegistrar(ms3, currencyApp.(*lazyledger.Currency), addrC)
     var rns2 [namespaceSize]byte
     copy(rns2[:], []byte("reg2"))
     registrarApp2.(*lazyledger.Registrar).SetNamespace(rns2)
     b.RegisterApplication(&registrarApp2)
-    pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubC, 100000, nil))
+    pb.AddMessage(currencyApp.(*lazyledger.Currency).GenerateTransaction(privA, pubC, 100000, 2, nil))
 
     for i := 0; i < otherTxes; i++ {
         name := make([]byte, 8)
         //rand.Read(name)
-        pb.AddMessage(registrarApp2.(*lazyledger.Registrar).GenerateTransaction(privA, name))
+        salt := make([]byte, 16)
+        pb.AddMessage(registrarApp2.(*lazyledger.Registrar).GenerateTransaction(privA, name, salt))
     }
 
     return pb.(*lazyledger.ProbabilisticBlock), currencyApp.Namespace(), registrarApp.Namespace()
 }
+
+// revealPublicKey processes a block in which the sender reveals its public key, and returns the block's digest.
+// Messages only carry a public key until the chain knows it, so the measured block is built on this one to measure
+// the steady state in which senders are referred to by their addresses alone.
+func revealPublicKey(b *lazyledger.Blockchain, currency *lazyledger.Currency, privKey crypto.PrivKey) []byte {
+    sb := lazyledger.NewSimpleBlock([]byte{0})
+    sb.AddMessage(currency.GenerateTransaction(privKey, privKey.GetPublic(), 0, 0, nil))
+    b.ProcessBlock(sb)
+    return sb.Digest()
+}
@@ -0,0 +1,140 @@
+package lazyledger
+
+import (
+    "bytes"
+    "errors"
+)
+
+// ErrBlockNotProcessed is returned when committing a block that the blockchain hasn't processed.
+var ErrBlockNotProcessed = errors.New("block not processed")
+
+// ErrUnknownParent is returned when processing a block, other than the genesis block, whose parent hasn't been processed.
+var ErrUnknownParent = errors.New("parent block not processed")
+
+// ErrConflictingFinality is returned when committing a block that doesn't descend from the latest finalized block.
+var ErrConflictingFinality = errors.New("block conflicts with finalized chain")
+
+// Consensus decides which blocks may be processed and which are finalized.
+type Consensus interface {
+    // VerifyProposal checks that a block at a height was proposed by a producer allowed to propose it.
+    VerifyProposal(block Block, height int) error
+
+    // VerifyCommit checks that a commit finalizes a block at a height.
+    VerifyCommit(block Block, height int, commit *Commit) error
+}
+
+// CommitSignature is a validator's signature of a commit vote for a block.
+type CommitSignature struct {
+    Validator []byte
+    Signature []byte
+}
+
+// Commit is the set of validator signatures that finalizes a block.
+type Commit struct {
+    Signatures []CommitSignature
+}
+
+// SetConsensus sets the consensus that decides which blocks are processed and finalized.
+// Without a consensus, a block is finalized as soon as it is processed as the head, since the blockchain doesn't support re-orgs.
+func (b *Blockchain) SetConsensus(consensus Consensus) {
+    b.consensus = consensus
+}
+
+// NextHeight returns the height of a block built on prevHash.
+// The genesis block, which has no parent or is the first block the blockchain processes, is at height 0.
+// Any other block must be built on a processed block, or ErrUnknownParent is returned.
+func (b *Blockchain) NextHeight(prevHash []byte) (int, error) {
+    height, ok := b.heights[string(prevHash)]
+    if ok {
+        return height + 1, nil
+    }
+    if len(prevHash) == 0 || len(b.heights) == 0 {
+        return 0, nil
+    }
+    return 0, ErrUnknownParent
+}
+
+// Height returns the height of a processed block.
+func (b *Blockchain) Height(digest []byte) (int, error) {
+    height, ok := b.heights[string(digest)]
+    if !ok {
+        return 0, ErrBlockNotProcessed
+    }
+    return height, nil
+}
+
+// CommitBlock finalizes a processed block, along with its ancestors, if the consensus accepts the commit.
+func (b *Blockchain) CommitBlock(digest []byte, commit *Commit) error {
+    block, err := b.blockStore.Get(digest)
+    if err != nil {
+        return ErrBlockNotProcessed
+    }
+    height, err := b.Height(digest)
+    if err != nil {
+        return err
+    }
+    if b.consensus != nil {
+        if err := b.consensus.VerifyCommit(block, height, commit); err != nil {
+            return err
+        }
+    }
+    return b.finalize(digest)
+}
+
+// Finalized returns true if a block has been finalized.
+func (b *Blockchain) Finalized(digest []byte) bool {
+    return b.finalized[string(digest)]
+}
+
+// FinalizedHead returns the digest of the latest finalized block, or nil if no block has been finalized.
+func (b *Blockchain) FinalizedHead() []byte {
+    return b.finalizedHead
+}
+
+// verifyProposal checks a block with the consensus, if there is one.
+func (b *Blockchain) verifyProposal(block Block) error {
+    if b.consensus == nil {
+        return nil
+    }
+    height, err := b.NextHeight(block.PrevHash())
+    if err != nil {
+        return err
+    }
+    return b.consensus.VerifyProposal(block, height)
+}
+
+// finalize marks a block and its unfinalized ancestors as finalized, oldest first,
+// and notifies the applications that track finality.
+func (b *Blockchain) finalize(digest []byte) error {
+    if b.finalized[string(digest)] {
+        return nil
+    }
+    var chain [][]byte
+    current := digest
+    for !b.finalized[string(current)] {
+        block, err := b.blockStore.Get(current)
+        if err != nil {
+            // The start of the chain was reached without meeting a finalized block.
+            if b.finalizedHead != nil {
+                return ErrConflictingFinality
+            }
+            break
+        }
+        chain = append(chain, current)
+        current = block.PrevHash()
+    }
+    if b.finalized[string(current)] && bytes.Compare(current, b.finalizedHead) != 0 {
+        return ErrConflictingFinality
+    }
+
+    for i := len(chain) - 1; i >= 0; i-- {
+        b.finalized[string(chain[i])] = true
+        b.finalizedHead = chain[i]
+        for _, registered := range b.applications.applications {
+            if fa, ok := (*registered.application).(FinalityApplication); ok {
+                fa.BlockFinalized(chain[i])
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,176 @@
+package lazyledger
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ErrNoValidators is returned when creating a consensus without validators.
+var ErrNoValidators = errors.New("no validators")
+
+// ErrWrongProposer is returned when a block is not signed by the proposer of its height.
+var ErrWrongProposer = errors.New("block not signed by the proposer of its height")
+
+// ErrProposerOffline is returned when the proposer of a height is offline in a LocalBFT.
+var ErrProposerOffline = errors.New("proposer offline")
+
+// ErrInsufficientCommit is returned when a commit isn't signed by more than two thirds of the validators.
+var ErrInsufficientCommit = errors.New("commit not signed by more than two thirds of validators")
+
+// RoundRobinBFT is a minimal BFT consensus: the validators take turns proposing blocks by height,
+// and a block is finalized by the commit votes of more than two thirds of them.
+// There are no rounds, so a height whose proposer is offline can't make progress.
+type RoundRobinBFT struct {
+    validators [][]byte
+    validatorIndexes map[string]int
+}
+
+// NewRoundRobinBFT returns a consensus for a set of validators, which propose blocks in the order given.
+func NewRoundRobinBFT(validators []crypto.PubKey) (*RoundRobinBFT, error) {
+    if len(validators) == 0 {
+        return nil, ErrNoValidators
+    }
+    c := &RoundRobinBFT{
+        validatorIndexes: make(map[string]int),
+    }
+    for i, validator := range validators {
+        validatorBytes, err := validator.Bytes()
+        if err != nil {
+            return nil, err
+        }
+        c.validators = append(c.validators, validatorBytes)
+        c.validatorIndexes[string(validatorBytes)] = i
+    }
+    return c, nil
+}
+
+// Proposer returns the marshalled public key of the validator that proposes the block at a height.
+func (c *RoundRobinBFT) Proposer(height int) []byte {
+    return c.validators[height % len(c.validators)]
+}
+
+// VerifyProposal checks that a block is signed by the proposer of its height.
+func (c *RoundRobinBFT) VerifyProposal(block Block, height int) error {
+    if err := VerifyBlockSignature(block); err != nil {
+        return err
+    }
+    if bytes.Compare(block.ProducerSignature().Producer, c.Proposer(height)) != 0 {
+        return ErrWrongProposer
+    }
+    return nil
+}
+
+// VerifyCommit checks that more than two thirds of the validators signed commit votes for a block at a height.
+func (c *RoundRobinBFT) VerifyCommit(block Block, height int, commit *Commit) error {
+    if commit == nil {
+        return ErrInsufficientCommit
+    }
+    vote := commitVote(block.Digest(), height)
+    signed := make(map[int]bool)
+    for _, signature := range commit.Signatures {
+        index, ok := c.validatorIndexes[string(signature.Validator)]
+        if !ok || signed[index] {
+            continue
+        }
+        validator, err := crypto.UnmarshalPublicKey(signature.Validator)
+        if err != nil {
+            continue
+        }
+        if ok, err := validator.Verify(vote, signature.Signature); ok && err == nil {
+            signed[index] = true
+        }
+    }
+    if 3 * len(signed) <= 2 * len(c.validators) {
+        return ErrInsufficientCommit
+    }
+    return nil
+}
+
+// SignCommitVote signs a validator's commit vote for a block at a height.
+func SignCommitVote(privKey crypto.PrivKey, digest []byte, height int) (CommitSignature, error) {
+    validator, err := privKey.GetPublic().Bytes()
+    if err != nil {
+        return CommitSignature{}, err
+    }
+    signature, err := privKey.Sign(commitVote(digest, height))
+    if err != nil {
+        return CommitSignature{}, err
+    }
+    return CommitSignature{Validator: validator, Signature: signature}, nil
+}
+
+// commitVote returns the data signed by a commit vote, which binds the vote to a height so it can't be replayed.
+func commitVote(digest []byte, height int) []byte {
+    heightBytes := make([]byte, 8)
+    binary.BigEndian.PutUint64(heightBytes, uint64(height))
+    vote := append([]byte("commit"), heightBytes...)
+    return append(vote, digest...)
+}
+
+// LocalBFT runs RoundRobinBFT in process for a Blockchain, holding the keys of every validator,
+// so that a single node can simulate a network in which some validators are offline.
+type LocalBFT struct {
+    *RoundRobinBFT
+    b *Blockchain
+    keys []crypto.PrivKey
+    offline map[int]bool
+}
+
+// NewLocalBFT creates a consensus from the keys of its validators, and sets it as the blockchain's consensus.
+func NewLocalBFT(b *Blockchain, keys []crypto.PrivKey) (*LocalBFT, error) {
+    var validators []crypto.PubKey
+    for _, key := range keys {
+        validators = append(validators, key.GetPublic())
+    }
+    consensus, err := NewRoundRobinBFT(validators)
+    if err != nil {
+        return nil, err
+    }
+    l := &LocalBFT{
+        RoundRobinBFT: consensus,
+        b: b,
+        keys: keys,
+        offline: make(map[int]bool),
+    }
+    b.SetConsensus(l)
+    return l, nil
+}
+
+// SetOffline sets whether a validator, by its index, is offline. Offline validators don't vote.
+func (l *LocalBFT) SetOffline(validator int, offline bool) {
+    l.offline[validator] = offline
+}
+
+// ProduceBlock has the proposer of the block's height sign it, processes it, and commits it with the votes of the online validators.
+// The block is processed even if too few validators are online to finalize it, in which case ErrInsufficientCommit is returned.
+func (l *LocalBFT) ProduceBlock(block Block, timestamp int64) error {
+    height, err := l.b.NextHeight(block.PrevHash())
+    if err != nil {
+        return err
+    }
+    proposer := height % len(l.keys)
+    if l.offline[proposer] {
+        return ErrProposerOffline
+    }
+    if err := SignBlock(block, l.keys[proposer], timestamp); err != nil {
+        return err
+    }
+    if err := l.b.ProcessBlock(block); err != nil {
+        return err
+    }
+    commit := &Commit{}
+    for i, key := range l.keys {
+        if l.offline[i] {
+            continue
+        }
+        signature, err := SignCommitVote(key, block.Digest(), height)
+        if err != nil {
+            return err
+        }
+        commit.Signatures = append(commit.Signatures, signature)
+    }
+    return l.b.CommitBlock(block.Digest(), commit)
+}
@@ -0,0 +1,198 @@
+package lazyledger
+
+import (
+    "bytes"
+    "crypto/rand"
+    "testing"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// finalityRecorder is a DummyApp that records the blocks finalized.
+type finalityRecorder struct {
+    *DummyApp
+    finalized [][]byte
+}
+
+func (r *finalityRecorder) BlockFinalized(digest []byte) {
+    r.finalized = append(r.finalized, digest)
+}
+
+func newFinalityRecorder() Application {
+    return &finalityRecorder{DummyApp: NewDummyApp(NewSimpleMap()).(*DummyApp)}
+}
+
+func TestBlockchainFinalityWithoutConsensus(t *testing.T) {
+    b := NewBlockchain(NewSimpleBlockStore())
+    app := newFinalityRecorder()
+    b.RegisterApplication(&app)
+
+    sb := NewSimpleBlock([]byte{0})
+    b.ProcessBlock(sb)
+    if !b.Finalized(sb.Digest()) || bytes.Compare(b.FinalizedHead(), sb.Digest()) != 0 {
+        t.Error("processed head block not finalized")
+    }
+    if len(app.(*finalityRecorder).finalized) != 1 {
+        t.Error("application not notified of finality")
+    }
+
+    // Once the chain has started, a block must be built on a processed block to have a height.
+    if height, err := b.NextHeight(sb.Digest()); err != nil || height != 1 {
+        t.Errorf("expected height 1, got %d, %v", height, err)
+    }
+    if _, err := b.NextHeight([]byte{1}); err != ErrUnknownParent {
+        t.Errorf("expected ErrUnknownParent, got %v", err)
+    }
+    orphan := NewSimpleBlock([]byte{1})
+    if err := b.ProcessBlock(orphan); err != ErrUnknownParent {
+        t.Errorf("expected ErrUnknownParent, got %v", err)
+    }
+    if _, err := b.Block(orphan.Digest()); err == nil {
+        t.Error("block with an unknown parent was stored")
+    }
+}
+
+func TestBlockchainRoundRobinBFT(t *testing.T) {
+    b := NewBlockchain(NewSimpleBlockStore())
+    app := newFinalityRecorder()
+    b.RegisterApplication(&app)
+    recorder := app.(*finalityRecorder)
+
+    var keys []crypto.PrivKey
+    for i := 0; i < 4; i++ {
+        key, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+        keys = append(keys, key)
+    }
+    bft, err := NewLocalBFT(b, keys)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    sb0 := NewSimpleBlock([]byte{0})
+    if err := bft.ProduceBlock(sb0, 1); err != nil {
+        t.Fatal(err)
+    }
+    if !b.Finalized(sb0.Digest()) {
+        t.Error("block committed by every validator not finalized")
+    }
+
+    // With two of four validators offline, blocks are processed but not finalized.
+    bft.SetOffline(2, true)
+    bft.SetOffline(3, true)
+    sb1 := NewSimpleBlock(sb0.Digest())
+    sb1.AddMessage(recorder.GenerateTransaction(map[string]string{"foo": "bar"}))
+    if err := bft.ProduceBlock(sb1, 2); err != ErrInsufficientCommit {
+        t.Errorf("expected ErrInsufficientCommit, got %v", err)
+    }
+    if recorder.Get("foo") != "bar" || b.Finalized(sb1.Digest()) {
+        t.Error("block should be processed but not finalized")
+    }
+    if bft.ProduceBlock(NewSimpleBlock(sb1.Digest()), 3) != ErrProposerOffline {
+        t.Error("offline proposer produced a block")
+    }
+
+    // Once three validators are back, committing a block also finalizes its unfinalized ancestors.
+    bft.SetOffline(2, false)
+    sb2 := NewSimpleBlock(sb1.Digest())
+    if err := bft.ProduceBlock(sb2, 3); err != nil {
+        t.Fatal(err)
+    }
+    if height, _ := b.Height(sb2.Digest()); height != 2 {
+        t.Errorf("expected height 2, got %d", height)
+    }
+    expected := [][]byte{sb0.Digest(), sb1.Digest(), sb2.Digest()}
+    if len(recorder.finalized) != len(expected) {
+        t.Fatalf("expected %d finalized blocks, got %d", len(expected), len(recorder.finalized))
+    }
+    for i := range expected {
+        if bytes.Compare(recorder.finalized[i], expected[i]) != 0 {
+            t.Errorf("block %d finalized out of order", i)
+        }
+    }
+
+    // A block at height 3 must be proposed by the fourth validator.
+    sb3 := NewSimpleBlock(sb2.Digest())
+    SignBlock(sb3, keys[0], 4)
+    if err := b.ProcessBlock(sb3); err != ErrWrongProposer {
+        t.Errorf("expected ErrWrongProposer, got %v", err)
+    }
+    SignBlock(sb3, keys[3], 4)
+    if err := b.ProcessBlock(sb3); err != nil {
+        t.Fatal(err)
+    }
+    signature, _ := SignCommitVote(keys[0], sb3.Digest(), 3)
+    duplicated := &Commit{Signatures: []CommitSignature{signature, signature, signature}}
+    if b.CommitBlock(sb3.Digest(), duplicated) != ErrInsufficientCommit {
+        t.Error("commit with a duplicated vote accepted")
+    }
+
+    // A fork of a finalized block can't be finalized.
+    fork := NewSimpleBlock(sb0.Digest())
+    fork.AddMessage(recorder.GenerateTransaction(map[string]string{"fork": "yes"}))
+    SignBlock(fork, keys[1], 5)
+    if err := b.ProcessBlock(fork); err != nil {
+        t.Fatal(err)
+    }
+    commit := &Commit{}
+    for _, key := range keys {
+        signature, _ := SignCommitVote(key, fork.Digest(), 1)
+        commit.Signatures = append(commit.Signatures, signature)
+    }
+    if b.CommitBlock(fork.Digest(), commit) != ErrConflictingFinality {
+        t.Error("finalized a fork of the finalized chain")
+    }
+}
+
+func TestSyncBlockHeaderFinality(t *testing.T) {
+    var keys []crypto.PrivKey
+    var validators []crypto.PubKey
+    for i := 0; i < 3; i++ {
+        key, pub, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+        keys = append(keys, key)
+        validators = append(validators, pub)
+    }
+
+    fullBlockStore := NewSimpleBlockStore()
+    full := NewBlockchain(fullBlockStore)
+    if _, err := NewLocalBFT(full, keys); err != nil {
+        t.Fatal(err)
+    }
+    dummyApp := NewDummyApp(NewSimpleMap())
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(dummyApp.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    SignBlock(sb, keys[0], 1)
+
+    // A light node verifies the proposal and commit of a header, and only then is its application told the block is final.
+    light := NewBlockchain(NewSimpleBlockStore())
+    consensus, _ := NewRoundRobinBFT(validators)
+    light.SetConsensus(consensus)
+    app := newFinalityRecorder()
+    light.RegisterApplication(&app)
+
+    full.ProcessBlock(sb)
+    header := ImportSimpleBlockHeader(sb.PrevHash(), sb.(*SimpleBlock).MessagesRoot(), sb.(*SimpleBlock).NumMessages())
+    header.SetProducerSignature(sb.ProducerSignature())
+    if err := light.SyncBlockHeader(header, NewBlockStoreProofProvider(fullBlockStore)); err != nil {
+        t.Fatal(err)
+    }
+    if app.(*finalityRecorder).Get("foo") != "bar" || len(app.(*finalityRecorder).finalized) != 0 {
+        t.Error("synced block should be processed but not finalized")
+    }
+
+    commit := &Commit{}
+    for _, key := range keys[:2] {
+        signature, _ := SignCommitVote(key, header.Digest(), 0)
+        commit.Signatures = append(commit.Signatures, signature)
+    }
+    if err := light.CommitBlock(header.Digest(), commit); err != ErrInsufficientCommit {
+        t.Errorf("expected ErrInsufficientCommit with two of three votes, got %v", err)
+    }
+    signature, _ := SignCommitVote(keys[2], header.Digest(), 0)
+    commit.Signatures = append(commit.Signatures, signature)
+    if err := light.CommitBlock(header.Digest(), commit); err != nil {
+        t.Fatal(err)
+    }
+    if len(app.(*finalityRecorder).finalized) != 1 {
+        t.Error("synced block not finalized")
+    }
+}
@@ -2,6 +2,7 @@ package lazyledger
 
 const namespaceSize = 8
 const flagSize = 16
+const addressSize = 20
 
 var codedNamespace [namespaceSize]byte
 var codedFlag [flagSize]byte
@@ -0,0 +1,330 @@
+package lazyledger
+
+import (
+    "bytes"
+    "errors"
+    "sort"
+)
+
+// ErrDependencyNotFound is returned by a provider that does not have a message with a given hash in a block.
+var ErrDependencyNotFound = errors.New("dependency not found in block")
+
+// defaultMaxDependencyDelay is the default number of blocks a message can wait for its dependencies to be proven.
+const defaultMaxDependencyDelay = 10
+
+// dependencySearchDepth is the number of blocks, from the block being processed backwards, searched for a dependency.
+const dependencySearchDepth = 16
+
+// ErrHeaderNotFound is returned by a provider that does not have the header of a block.
+var ErrHeaderNotFound = errors.New("block header not found")
+
+// DependencyReference refers to a message that another message depends on, by its leaf hash.
+// If the reference includes the digest of the block that contains the message and the message's index in it,
+// the message can be in any ancestor of the block being processed. Otherwise only recent blocks are searched for it.
+type DependencyReference struct {
+    Block []byte
+    Index int
+    Hash []byte
+}
+
+// DependencyProofProvider proves that a block contains a message, such as a full node that stores whole blocks.
+type DependencyProofProvider interface {
+    // DependencyProof returns the index of a referenced message in its block, along with its Merkle proof.
+    // If the reference's index is negative, the block is searched for the message's hash.
+    DependencyProof(ref DependencyReference) (int, [][]byte, error)
+}
+
+// MultiDependencyProofProvider is a DependencyProofProvider that can prove several messages of a block with a single multiproof.
+type MultiDependencyProofProvider interface {
+    DependencyProofProvider
+
+    // DependencyProofs returns a multiproof for the messages at sorted indexes of the block with a digest.
+    DependencyProofs(digest []byte, indexes []int) (*MultiDependencyProof, error)
+}
+
+// HeaderProvider provides the headers of blocks the Blockchain hasn't stored, so that it can follow the chain back
+// to a dependency's block. Since a block's digest commits to the previous block's, fetched headers are checked by their digest.
+type HeaderProvider interface {
+    Header(digest []byte) (Block, error)
+}
+
+// pendingMessage is a message of a block waiting to be processed, along with its position in the block
+// and the number of blocks it has been waiting for its dependencies to be proven.
+type pendingMessage struct {
+    message Message
+    block []byte
+    index int
+    delay int
+}
+
+// DependencyProof returns the proof of a message in one of the blocks of the store.
+func (p *BlockStoreProofProvider) DependencyProof(ref DependencyReference) (int, [][]byte, error) {
+    block, err := p.blockStore.Get(ref.Block)
+    if err != nil {
+        return 0, nil, err
+    }
+    if ref.Index >= 0 {
+        if ref.Index >= len(block.Messages()) {
+            return 0, nil, ErrDependencyNotFound
+        }
+        leaf, proof, err := block.ProveDependency(ref.Index)
+        if err != nil {
+            return 0, nil, err
+        }
+        if bytes.Compare(leaf, ref.Hash) != 0 {
+            return 0, nil, ErrDependencyNotFound
+        }
+        return ref.Index, proof, nil
+    }
+    for index := range block.Messages() {
+        leaf, proof, err := block.ProveDependency(index)
+        if err != nil {
+            return 0, nil, err
+        }
+        if bytes.Compare(leaf, ref.Hash) == 0 {
+            return index, proof, nil
+        }
+    }
+    return 0, nil, ErrDependencyNotFound
+}
+
+// DependencyProofs returns a multiproof for several messages of one of the blocks of the store.
+func (p *BlockStoreProofProvider) DependencyProofs(digest []byte, indexes []int) (*MultiDependencyProof, error) {
+    block, err := p.blockStore.Get(digest)
+    if err != nil {
+        return nil, err
+    }
+    _, proof, err := block.ProveDependencies(indexes)
+    return proof, err
+}
+
+// Header returns a block of the store.
+func (p *BlockStoreProofProvider) Header(digest []byte) (Block, error) {
+    block, err := p.blockStore.Get(digest)
+    if err != nil {
+        return nil, ErrHeaderNotFound
+    }
+    return block, nil
+}
+
+// SetDependencyProvider sets where the proofs of dependencies are fetched from.
+// By default they are proven from the blocks the Blockchain stores itself, which only works if it stores whole blocks.
+// If the provider is also a HeaderProvider, it is asked for the headers of blocks the Blockchain hasn't stored.
+func (b *Blockchain) SetDependencyProvider(provider DependencyProofProvider) {
+    b.dependencyProvider = provider
+}
+
+// SetMaxDependencyDelay sets the number of blocks a message can wait for its dependencies to be proven before it fails.
+func (b *Blockchain) SetMaxDependencyDelay(blocks int) {
+    b.maxDependencyDelay = blocks
+}
+
+// provenDependency is the position of a message that has been proven to be in a block.
+type provenDependency struct {
+    block []byte
+    index int
+}
+
+// DependencyProven returns true if a referenced message has been proven to be in the block being processed or one of its ancestors.
+// Outside of processing a block, the message must be in the head block or one of its ancestors.
+func (b *Blockchain) DependencyProven(ref DependencyReference) bool {
+    digest := b.processingBlock
+    if digest == nil {
+        if b.headBlock == nil {
+            return false
+        }
+        digest = b.headBlock.Digest()
+    }
+    return b.dependencyProven(ref, digest)
+}
+
+// dependencyProven returns true if a referenced message has been proven to be in the block with a digest or one of its ancestors.
+// A proof is only reused for a reference that matches the block and index it was proven at.
+func (b *Blockchain) dependencyProven(ref DependencyReference, digest []byte) bool {
+    for _, proven := range b.provenDependencies[string(ref.Hash)] {
+        if ref.Block != nil && (bytes.Compare(ref.Block, proven.block) != 0 || (ref.Index >= 0 && ref.Index != proven.index)) {
+            continue
+        }
+        if b.isAncestor(proven.block, digest) {
+            return true
+        }
+    }
+    // Dependencies can also be verified by hand against the head block, which only records their hashes.
+    // Since they aren't proven at an index, they only satisfy references that don't name a block.
+    if ref.Block != nil || b.headBlock == nil || !b.headBlock.DependencyProven(ref.Hash) {
+        return false
+    }
+    return b.isAncestor(b.headBlock.Digest(), digest)
+}
+
+// recordDependency records that a message with a hash is at an index of the block with a digest.
+func (b *Blockchain) recordDependency(digest []byte, index int, hash []byte) {
+    for _, proven := range b.provenDependencies[string(hash)] {
+        if proven.index == index && bytes.Compare(proven.block, digest) == 0 {
+            return
+        }
+    }
+    b.provenDependencies[string(hash)] = append(b.provenDependencies[string(hash)], provenDependency{block: digest, index: index})
+}
+
+// DeferredMessages returns the messages waiting for their dependencies to be proven.
+func (b *Blockchain) DeferredMessages() []Message {
+    var messages []Message
+    for _, registered := range b.applications.applications {
+        for _, deferred := range registered.deferred {
+            messages = append(messages, deferred.message)
+        }
+    }
+    return messages
+}
+
+// dependenciesProven proves the dependencies of a message to be processed in the block with a digest.
+func (b *Blockchain) dependenciesProven(application Application, message Message, digest []byte) bool {
+    da, ok := application.(DependentApplication)
+    if !ok {
+        return true
+    }
+    refs := da.Dependencies(message)
+    b.proveDependencyBatches(refs, digest)
+    for _, ref := range refs {
+        if !b.proveDependency(ref, digest) {
+            return false
+        }
+    }
+    return true
+}
+
+// proveDependencyBatches proves the references to several messages of the same block with one multiproof per block,
+// if the provider supports it. References it can't prove are left to be proven one by one.
+func (b *Blockchain) proveDependencyBatches(refs []DependencyReference, digest []byte) {
+    mp, ok := b.dependencyProofProvider().(MultiDependencyProofProvider)
+    if !ok {
+        return
+    }
+    batches := make(map[string][]DependencyReference)
+    for _, ref := range refs {
+        if ref.Block != nil && ref.Index >= 0 && !b.dependencyProven(ref, digest) {
+            batches[string(ref.Block)] = append(batches[string(ref.Block)], ref)
+        }
+    }
+    for blockDigest, batch := range batches {
+        if len(batch) < 2 || !b.isAncestor([]byte(blockDigest), digest) {
+            continue
+        }
+        sort.Slice(batch, func(i, j int) bool {
+            return batch[i].Index < batch[j].Index
+        })
+        indexes := make([]int, len(batch))
+        hashes := make([][]byte, len(batch))
+        for i, ref := range batch {
+            indexes[i] = ref.Index
+            hashes[i] = ref.Hash
+        }
+        block, err := b.blockStore.Get([]byte(blockDigest))
+        if err != nil {
+            continue
+        }
+        proof, err := mp.DependencyProofs([]byte(blockDigest), indexes)
+        if err != nil || !equalIndexes(proof.Indexes, indexes) || !block.VerifyDependencies(hashes, proof) {
+            continue
+        }
+        for i, hash := range hashes {
+            b.recordDependency([]byte(blockDigest), indexes[i], hash)
+        }
+    }
+}
+
+func equalIndexes(a []int, b []int) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+// proveDependency verifies the provider's proof of a referenced message against its block, which must be the block with a digest
+// or one of its ancestors. If the reference doesn't name a block, the block and its recent ancestors are searched for the message.
+func (b *Blockchain) proveDependency(ref DependencyReference, digest []byte) bool {
+    if b.dependencyProven(ref, digest) {
+        return true
+    }
+    if ref.Block != nil {
+        if !b.isAncestor(ref.Block, digest) {
+            return false
+        }
+        return b.verifyDependency(ref)
+    }
+    for i := 0; i < dependencySearchDepth; i++ {
+        block, err := b.blockStore.Get(digest)
+        if err != nil {
+            return false
+        }
+        if b.verifyDependency(DependencyReference{Block: digest, Index: -1, Hash: ref.Hash}) {
+            return true
+        }
+        digest = block.PrevHash()
+    }
+    return false
+}
+
+// verifyDependency fetches the proof of a referenced message and verifies it against the stored header of its block.
+func (b *Blockchain) verifyDependency(ref DependencyReference) bool {
+    block, err := b.blockStore.Get(ref.Block)
+    if err != nil {
+        return false
+    }
+    index, proof, err := b.dependencyProofProvider().DependencyProof(ref)
+    if err != nil || index < 0 || (ref.Index >= 0 && index != ref.Index) {
+        return false
+    }
+    if !block.VerifyDependency(index, ref.Hash, proof) {
+        return false
+    }
+    b.recordDependency(ref.Block, index, ref.Hash)
+    return true
+}
+
+// isAncestor returns true if the block with digest ancestor is the block with a digest or one of its ancestors.
+// Headers missing from the block store are fetched from the dependency provider, and stored once their digest is checked.
+func (b *Blockchain) isAncestor(ancestor []byte, digest []byte) bool {
+    for {
+        if bytes.Compare(ancestor, digest) == 0 {
+            return true
+        }
+        block, err := b.blockStore.Get(digest)
+        if err != nil {
+            block = b.fetchHeader(digest)
+            if block == nil {
+                return false
+            }
+        }
+        digest = block.PrevHash()
+    }
+}
+
+// fetchHeader fetches the header of a block from the dependency provider, or returns nil if it can't.
+func (b *Blockchain) fetchHeader(digest []byte) Block {
+    hp, ok := b.dependencyProofProvider().(HeaderProvider)
+    if !ok {
+        return nil
+    }
+    header, err := hp.Header(digest)
+    if err != nil || bytes.Compare(header.Digest(), digest) != 0 {
+        return nil
+    }
+    if err := b.blockStore.Put(digest, header); err != nil {
+        return nil
+    }
+    return header
+}
+
+func (b *Blockchain) dependencyProofProvider() DependencyProofProvider {
+    if b.dependencyProvider == nil {
+        return NewBlockStoreProofProvider(b.blockStore)
+    }
+    return b.dependencyProvider
+}
@@ -0,0 +1,290 @@
+package lazyledger
+
+import (
+    "crypto/rand"
+    "testing"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// unavailableProofProvider is a provider that can be switched off, like a full node that is unreachable.
+type unavailableProofProvider struct {
+    provider DependencyProofProvider
+    available bool
+}
+
+func (p *unavailableProofProvider) DependencyProof(ref DependencyReference) (int, [][]byte, error) {
+    if !p.available {
+        return 0, nil, ErrDependencyNotFound
+    }
+    return p.provider.DependencyProof(ref)
+}
+
+func TestBlockchainDependencies(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    // A dependency in the same block is proven without calling VerifyDependency.
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("foo")))
+    hash, _, _ := sb.ProveDependency(0)
+    sb.AddMessage(currency.GenerateTransaction(privA, pubB, 100, 0, hash))
+    b.ProcessBlock(sb)
+
+    if currency.Balance(pubB) != 100 {
+        t.Error("transaction with a dependency in the same block failed")
+    }
+
+    // A dependency in an earlier block is found by searching back from the block being processed.
+    previous := sb
+    sb = NewSimpleBlock(previous.Digest())
+    sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("bar")))
+    b.ProcessBlock(sb)
+    hash, _, _ = sb.ProveDependency(0)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(currency.GenerateTransaction(privA, pubB, 100, 1, hash))
+    b.ProcessBlock(sb)
+
+    if currency.Balance(pubB) != 200 {
+        t.Error("transaction with a dependency in an earlier block failed")
+    }
+}
+
+func TestBlockchainDeferredDependencies(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+    provider := &unavailableProofProvider{provider: NewBlockStoreProofProvider(bs)}
+    b.SetDependencyProvider(provider)
+    b.SetMaxDependencyDelay(1)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("foo")))
+    hash, _, _ := sb.ProveDependency(0)
+    deferred := currency.GenerateTransaction(privA, pubB, 100, 0, hash)
+    sb.AddMessage(deferred)
+    b.ProcessBlock(sb)
+
+    if currency.Balance(pubB) != 0 || len(b.DeferredMessages()) != 1 {
+        t.Error("transaction with an unproven dependency was not deferred")
+    }
+    if _, err := b.Receipt(deferred.Hash()); err == nil {
+        t.Error("deferred transaction has a receipt")
+    }
+
+    // Once the dependency can be proven, the deferred transaction is processed in the next block.
+    provider.available = true
+    sb = NewSimpleBlock(sb.Digest())
+    b.ProcessBlock(sb)
+
+    if currency.Balance(pubB) != 100 || len(b.DeferredMessages()) != 0 {
+        t.Error("deferred transaction was not processed once its dependency was proven")
+    }
+
+    // A transaction whose dependency never shows up fails after the maximum delay.
+    failing := currency.GenerateTransaction(privA, pubB, 100, 1, []byte("missing"))
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(failing)
+    b.ProcessBlock(sb)
+    sb = NewSimpleBlock(sb.Digest())
+    b.ProcessBlock(sb)
+
+    receipt, err := b.Receipt(failing.Hash())
+    if err != nil || receipt.Code != ReceiptUnprovenDependency {
+        t.Error("transaction with a missing dependency did not fail after the maximum delay")
+    }
+    if len(b.DeferredMessages()) != 0 {
+        t.Error("failed transaction is still deferred")
+    }
+}
+
+func TestBlockchainDependencyReferences(t *testing.T) {
+    // A full node stores the blocks that a light node that joined later doesn't have.
+    fullBlockStore := NewSimpleBlockStore()
+    full := NewBlockchain(fullBlockStore)
+    var blocks []Block
+    prevHash := []byte{0}
+    for i := 0; i < 5; i++ {
+        sb := NewSimpleBlock(prevHash)
+        sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte{byte(i)}))
+        sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("payment")))
+        full.ProcessBlock(sb)
+        blocks = append(blocks, sb)
+        prevHash = sb.Digest()
+    }
+    hash, _, _ := blocks[0].ProveDependency(1)
+
+    b := NewBlockchain(NewSimpleBlockStore())
+    b.SetDependencyProvider(NewBlockStoreProofProvider(fullBlockStore))
+    b.SetMaxDependencyDelay(0)
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    // A reference to the wrong index fails, even though the block contains the message.
+    sb := NewSimpleBlock(prevHash)
+    wrongIndex := currency.GenerateTransactionWithReference(privA, pubB, 100, 0, DependencyReference{Block: blocks[0].Digest(), Index: 0, Hash: hash})
+    sb.AddMessage(wrongIndex)
+    b.ProcessBlock(sb)
+
+    receipt, err := b.Receipt(wrongIndex.Hash())
+    if err != nil || receipt.Code != ReceiptUnprovenDependency {
+        t.Error("transaction referencing the wrong index did not fail")
+    }
+
+    // A block that isn't an ancestor of the block being processed can't be referenced.
+    fork := NewSimpleBlock([]byte{1})
+    fork.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("fork payment")))
+    fullBlockStore.Put(fork.Digest(), fork)
+    forkHash, _, _ := fork.ProveDependency(0)
+    sb = NewSimpleBlock(sb.Digest())
+    forked := currency.GenerateTransactionWithReference(privA, pubB, 100, 0, DependencyReference{Block: fork.Digest(), Index: 0, Hash: forkHash})
+    sb.AddMessage(forked)
+    b.ProcessBlock(sb)
+
+    receipt, err = b.Receipt(forked.Hash())
+    if err != nil || receipt.Code != ReceiptUnprovenDependency {
+        t.Error("transaction referencing a block outside the chain did not fail")
+    }
+
+    // A payment several blocks back is proven through the headers fetched from the full node.
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(currency.GenerateTransactionWithReference(privA, pubB, 100, 0, DependencyReference{Block: blocks[0].Digest(), Index: 1, Hash: hash}))
+    b.ProcessBlock(sb)
+
+    if currency.Balance(pubB) != 100 {
+        t.Error("transaction with a dependency several blocks back failed")
+    }
+    if !b.DependencyProven(DependencyReference{Block: blocks[0].Digest(), Index: 1, Hash: hash}) {
+        t.Error("dependency not recorded as proven")
+    }
+}
+
+func TestBlockchainProvenDependencyBinding(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+    b.SetMaxDependencyDelay(0)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    // The same message is in a block of the chain and in a fork of it.
+    payment := *NewMessage([namespaceSize]byte{0}, []byte("payment"))
+    x := NewSimpleBlock([]byte{0})
+    x.AddMessage(payment)
+    b.ProcessBlock(x)
+    hash, _, _ := x.ProveDependency(0)
+    y := NewSimpleBlock(x.Digest())
+    y.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("other")))
+    b.ProcessBlock(y)
+    // The fork isn't built on a processed block, so it can only be stored.
+    fork := NewSimpleBlock([]byte{1})
+    fork.AddMessage(payment)
+    bs.Put(fork.Digest(), fork)
+
+    sb := NewSimpleBlock(y.Digest())
+    sb.AddMessage(currency.GenerateTransactionWithReference(privA, pubB, 100, 0, DependencyReference{Block: x.Digest(), Index: 0, Hash: hash}))
+    b.ProcessBlock(sb)
+    if currency.Balance(pubB) != 100 {
+        t.Fatal("transaction with a proven dependency failed")
+    }
+
+    // The proof for block x doesn't prove the hash is in block y, or at another index of x.
+    for _, ref := range []DependencyReference{{Block: y.Digest(), Index: 0, Hash: hash}, {Block: x.Digest(), Index: 1, Hash: hash}} {
+        sb = NewSimpleBlock(sb.Digest())
+        wrong := currency.GenerateTransactionWithReference(privA, pubB, 100, 1, ref)
+        sb.AddMessage(wrong)
+        b.ProcessBlock(sb)
+        receipt, err := b.Receipt(wrong.Hash())
+        if err != nil || receipt.Code != ReceiptUnprovenDependency {
+            t.Error("cached proof accepted for a different block or index")
+        }
+    }
+
+    // Nor does it prove the hash for a block whose ancestors don't include x.
+    if b.dependencyProven(DependencyReference{Block: x.Digest(), Index: 0, Hash: hash}, fork.Digest()) {
+        t.Error("cached proof accepted for a block that doesn't descend from its block")
+    }
+    if b.dependencyProven(DependencyReference{Index: -1, Hash: hash}, fork.Digest()) {
+        t.Error("cached proof accepted for an unnamed block that doesn't descend from its block")
+    }
+}
+
+// outOfRangeProofProvider is a provider that answers every reference with an index past the end of its block.
+type outOfRangeProofProvider struct{}
+
+func (p outOfRangeProofProvider) DependencyProof(ref DependencyReference) (int, [][]byte, error) {
+    return 1 << 20, nil, nil
+}
+
+func TestBlockchainOutOfRangeDependency(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+    b.SetDependencyProvider(outOfRangeProofProvider{})
+    b.SetMaxDependencyDelay(0)
+
+    ms := NewSimpleMap()
+    app := NewCurrency(ms, b)
+    b.RegisterApplication(&app)
+    currency := app.(*Currency)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubB, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    genesis := NewCurrencyGenesis()
+    genesis.Add(pubA, 1000)
+    currency.LoadGenesis(genesis)
+
+    pb := NewProbabilisticBlock([]byte{0}, 512)
+    pb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte("payment")))
+    b.ProcessBlock(pb)
+    if pb.VerifyDependency(1 << 20, []byte("missing"), nil) {
+        t.Error("dependency at an index outside the square verified")
+    }
+
+    // The provider picks the index of a reference without one, so it can't be trusted to be in the block.
+    sb := NewSimpleBlock(pb.Digest())
+    transaction := currency.GenerateTransactionWithReference(privA, pubB, 100, 0, DependencyReference{Block: pb.Digest(), Index: -1, Hash: []byte("missing")})
+    sb.AddMessage(transaction)
+    b.ProcessBlock(sb)
+
+    receipt, err := b.Receipt(transaction.Hash())
+    if err != nil || receipt.Code != ReceiptUnprovenDependency {
+        t.Error("transaction with an out of range dependency proof did not fail")
+    }
+}
@@ -0,0 +1,123 @@
+package lazyledger
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "fmt"
+)
+
+// Subscription selects the events of a type emitted by the applications of a namespace.
+type Subscription struct {
+    Namespace [namespaceSize]byte
+    Type string
+}
+
+// EmittedEvent is an event emitted by an application while processing a message, as delivered to the applications subscribed to it.
+// Source refers to the message that emitted the event, so that a consumer can prove the message is in its block; see VerifyEvent.
+type EmittedEvent struct {
+    Event
+    Namespace [namespaceSize]byte
+    Source DependencyReference
+}
+
+// EventConsumer is an Application that consumes events emitted by applications in other namespaces.
+// Events are delivered right after the message that emitted them is processed, in the order the message's receipt lists them,
+// and to subscribers in the order they were registered. If a consumer returns an error, the emitting message fails,
+// and neither its writes nor those of any consumer of its events are applied.
+type EventConsumer interface {
+    Application
+
+    // Subscriptions returns the events the application consumes. It is called once, when the application is registered.
+    Subscriptions() []Subscription
+
+    // ConsumeEvent processes an event.
+    ConsumeEvent(event EmittedEvent) error
+}
+
+// deliverEvents delivers the events of a message's receipt to their subscribers, other than the emitting application.
+// Each consumer's writes are batched, and only committed if every consumer succeeds. It returns a failure receipt if one fails.
+func (b *Blockchain) deliverEvents(emitter *registration, pm *pendingMessage, receipt *Receipt) *Receipt {
+    if receipt == nil {
+        return nil
+    }
+    var source *DependencyReference
+    var batches []*Batch
+    var states []MapStore
+    var consumers []StatefulApplication
+    wrapped := make(map[*registration]bool)
+    defer func() {
+        for i, sa := range consumers {
+            sa.SetState(states[i])
+        }
+    }()
+
+    for _, event := range receipt.Events {
+        for _, subscriber := range b.applications.subscribers(Subscription{Namespace: emitter.namespace, Type: event.Type}) {
+            if subscriber == emitter {
+                continue
+            }
+            if source == nil {
+                source = b.messageReference(pm)
+            }
+            if sa, ok := (*subscriber.application).(StatefulApplication); ok && !wrapped[subscriber] {
+                wrapped[subscriber] = true
+                batch := NewBatch(sa.State())
+                states = append(states, sa.State())
+                batches = append(batches, batch)
+                consumers = append(consumers, sa)
+                sa.SetState(batch)
+            }
+            err := (*subscriber.application).(EventConsumer).ConsumeEvent(EmittedEvent{
+                Event: event,
+                Namespace: emitter.namespace,
+                Source: *source,
+            })
+            if err != nil {
+                return NewFailureReceipt(ReceiptRejected, fmt.Sprintf("%s event not consumed: %s", event.Type, err.Error()))
+            }
+        }
+    }
+
+    for _, batch := range batches {
+        if err := batch.Commit(); err != nil {
+            return NewFailureReceipt(ReceiptStorageError, err.Error())
+        }
+    }
+    return nil
+}
+
+// messageReference returns a reference to a message by its position in its block.
+func (b *Blockchain) messageReference(pm *pendingMessage) *DependencyReference {
+    ref := &DependencyReference{
+        Block: pm.block,
+        Index: pm.index,
+    }
+    if block, err := b.blockStore.Get(pm.block); err == nil {
+        ref.Hash = messageLeafHash(block, pm.message)
+    }
+    return ref
+}
+
+// VerifyEvent proves that the message that emitted an event is in its block, in the namespace the event claims to be from.
+// The event's content follows from processing the message, so proving the message is enough to trust the event.
+func (b *Blockchain) VerifyEvent(event EmittedEvent) bool {
+    if len(event.Source.Hash) < flagSize {
+        return false
+    }
+    // Leaf hashes are flagged with the namespace of their message.
+    min, max := dummyNamespacesFromFlag(event.Source.Hash)
+    if bytes.Compare(min, event.Namespace[:]) != 0 || bytes.Compare(max, event.Namespace[:]) != 0 {
+        return false
+    }
+    return b.proveDependency(event.Source, event.Source.Block)
+}
+
+// messageLeafHash returns the leaf hash of a message in the Merkle tree of a block.
+func messageLeafHash(block Block, message Message) []byte {
+    ndf := NewNamespaceDummyFlagger()
+    fh := NewFlagHasher(ndf, sha256.New())
+    if pb, ok := block.(*ProbabilisticBlock); ok {
+        return leafSum(fh, message.MarshalPadded(pb.messageSize))
+    }
+    return leafSum(fh, message.Marshal())
+}
@@ -0,0 +1,91 @@
+package lazyledger
+
+import (
+    "errors"
+    "testing"
+)
+
+// putLogApp is a PetitionApp that also consumes the put events of DummyApp, and fails to if told to.
+type putLogApp struct {
+    *PetitionApp
+    events []EmittedEvent
+    fail bool
+}
+
+func (app *putLogApp) Subscriptions() []Subscription {
+    return []Subscription{{Namespace: (&DummyApp{}).Namespace(), Type: "put"}}
+}
+
+func (app *putLogApp) ConsumeEvent(event EmittedEvent) error {
+    if app.fail {
+        return errors.New("consumer failed")
+    }
+    app.events = append(app.events, event)
+    return app.state.Put(append([]byte("put__"), event.Attribute("key")...), []byte{1})
+}
+
+func TestBlockchainEvents(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    dummyApp := NewDummyApp(NewSimpleMap())
+    b.RegisterApplication(&dummyApp)
+    consumer := &putLogApp{PetitionApp: NewPetitionApp(NewSimpleMap()).(*PetitionApp)}
+    var consumerApp Application = consumer
+    b.RegisterApplication(&consumerApp)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(dummyApp.(*DummyApp).GenerateTransaction(map[string]string{"goo": "tar", "foo": "bar"}))
+    sb.AddMessage(dummyApp.(*DummyApp).GenerateOperationsTransaction(NewPutOperation("hoo", "car"), NewDeleteOperation("foo")))
+    b.ProcessBlock(sb)
+
+    if len(consumer.events) != 3 {
+        t.Fatalf("consumed %d events, want 3", len(consumer.events))
+    }
+    for i, key := range []string{"foo", "goo", "hoo"} {
+        if string(consumer.events[i].Attribute("key")) != key {
+            t.Errorf("event %d is for key %s, want %s", i, consumer.events[i].Attribute("key"), key)
+        }
+    }
+    if _, err := consumer.state.Get([]byte("put__hoo")); err != nil {
+        t.Error("consumer writes not applied")
+    }
+    if consumer.events[2].Source.Index != 1 {
+        t.Error("event refers to the wrong message")
+    }
+
+    if !b.VerifyEvent(consumer.events[2]) {
+        t.Error("event failed to verify")
+    }
+    forged := consumer.events[2]
+    forged.Namespace = consumer.Namespace()
+    if b.VerifyEvent(forged) {
+        t.Error("event verified with the wrong namespace")
+    }
+
+    // If a consumer fails, the message that emitted the event fails too.
+    consumer.fail = true
+    failing := dummyApp.(*DummyApp).GenerateOperationsTransaction(NewPutOperation("ioo", "jar"))
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(failing)
+    b.ProcessBlock(sb)
+
+    receipt, err := b.Receipt(failing.Hash())
+    if err != nil || receipt.Code != ReceiptRejected {
+        t.Error("message whose event was not consumed did not fail")
+    }
+    if dummyApp.(*DummyApp).Get("ioo") != "" {
+        t.Error("writes of a message whose event was not consumed were applied")
+    }
+
+    // Unregistered consumers no longer receive events.
+    consumer.fail = false
+    b.UnregisterApplication(&consumerApp)
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(dummyApp.(*DummyApp).GenerateOperationsTransaction(NewPutOperation("joo", "kar")))
+    b.ProcessBlock(sb)
+
+    if len(consumer.events) != 3 || dummyApp.(*DummyApp).Get("joo") != "kar" {
+        t.Error("unregistered consumer received an event")
+    }
+}
@@ -0,0 +1,316 @@
+package lazyledger
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "hash/crc32"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+)
+
+const fileMapSnapshotName = "snapshot"
+const fileMapWALName = "wal"
+
+// ErrCorruptRecord is returned when opening a FileMap whose snapshot, or a WAL record followed by others, is corrupt.
+var ErrCorruptRecord = errors.New("corrupt file map record")
+
+// ErrFileMapFailed is returned by writes to a FileMap whose WAL couldn't be restored after a failed write.
+var ErrFileMapFailed = errors.New("file map failed")
+
+// errTornRecord is returned when reading a record that is cut short by the end of its file.
+var errTornRecord = errors.New("torn record")
+
+// FileMap is a persistent key-value store kept in a directory.
+// The state is held in memory and persisted as a snapshot plus a write-ahead log (WAL).
+// Every write is appended to the WAL and synced before it is applied, and a batch is written as a single WAL record,
+// so after a crash the store recovers to the last complete write or batch.
+type FileMap struct {
+    dir string
+    m map[string][]byte
+    wal *os.File
+    failed bool
+}
+
+// NewFileMap opens the FileMap stored in dir, creating it if it does not exist, and replays its WAL.
+func NewFileMap(dir string) (*FileMap, error) {
+    err := os.MkdirAll(dir, 0755)
+    if err != nil {
+        return nil, err
+    }
+
+    fm := &FileMap{
+        dir: dir,
+        m: make(map[string][]byte),
+    }
+
+    snapshot, err := ioutil.ReadFile(filepath.Join(dir, fileMapSnapshotName))
+    if err == nil {
+        ops, _, err := readRecord(bytes.NewReader(snapshot), int64(len(snapshot)))
+        if err != nil {
+            return nil, ErrCorruptRecord
+        }
+        fm.apply(ops)
+    } else if !os.IsNotExist(err) {
+        return nil, err
+    }
+
+    fm.wal, err = os.OpenFile(filepath.Join(dir, fileMapWALName), os.O_RDWR|os.O_CREATE, 0644)
+    if err != nil {
+        return nil, err
+    }
+    err = fm.replayWAL()
+    if err != nil {
+        fm.wal.Close()
+        return nil, err
+    }
+
+    return fm, nil
+}
+
+// replayWAL replays the complete records of the WAL and truncates an incomplete trailing record left by a crash.
+// A corrupt record followed by others can't be explained by a crash, and fails the replay rather than dropping acknowledged writes.
+func (fm *FileMap) replayWAL() error {
+    info, err := fm.wal.Stat()
+    if err != nil {
+        return err
+    }
+    _, err = fm.wal.Seek(0, io.SeekStart)
+    if err != nil {
+        return err
+    }
+    reader := bufio.NewReader(fm.wal)
+    var offset int64
+    for {
+        ops, n, err := readRecord(reader, info.Size() - offset)
+        if err == io.EOF {
+            break
+        }
+        if err == errTornRecord || (err == ErrCorruptRecord && offset + int64(n) == info.Size()) {
+            // Torn write of the last record, which was never acknowledged.
+            break
+        }
+        if err != nil {
+            return err
+        }
+        fm.apply(ops)
+        offset += int64(n)
+    }
+
+    return fm.truncateWAL(offset)
+}
+
+// truncateWAL cuts the WAL off at an offset, and moves the write position there.
+func (fm *FileMap) truncateWAL(offset int64) error {
+    err := fm.wal.Truncate(offset)
+    if err != nil {
+        return err
+    }
+    _, err = fm.wal.Seek(offset, io.SeekStart)
+    return err
+}
+
+// Get gets the value for a key.
+func (fm *FileMap) Get(key []byte) ([]byte, error) {
+    if value, ok := fm.m[string(key)]; ok {
+        return value, nil
+    }
+    return nil, &InvalidKeyError{Key: key}
+}
+
+// Put updates the value for a key.
+func (fm *FileMap) Put(key []byte, value []byte) error {
+    return fm.writeBatch([]batchOp{{key: key, value: value}})
+}
+
+// Del deletes a key.
+func (fm *FileMap) Del(key []byte) error {
+    if _, ok := fm.m[string(key)]; !ok {
+        return &InvalidKeyError{Key: key}
+    }
+    return fm.writeBatch([]batchOp{{key: key, del: true}})
+}
+
+// Checkpoint writes the current state to a new snapshot and empties the WAL.
+func (fm *FileMap) Checkpoint() error {
+    ops := make([]batchOp, 0, len(fm.m))
+    for k, v := range fm.m {
+        ops = append(ops, batchOp{key: []byte(k), value: v})
+    }
+
+    tmpPath := filepath.Join(fm.dir, fileMapSnapshotName + ".tmp")
+    err := writeFileSync(tmpPath, encodeRecord(ops))
+    if err != nil {
+        return err
+    }
+    err = os.Rename(tmpPath, filepath.Join(fm.dir, fileMapSnapshotName))
+    if err != nil {
+        return err
+    }
+
+    // The WAL is only emptied once the snapshot is in place, so a crash in between just replays it on top of the snapshot.
+    return fm.truncateWAL(0)
+}
+
+// Close closes the WAL file.
+func (fm *FileMap) Close() error {
+    return fm.wal.Close()
+}
+
+func (fm *FileMap) scanPrefix(prefix []byte) ([][]byte, error) {
+    return scanMap(fm.m, prefix), nil
+}
+
+// writeBatch appends the writes to the WAL as one record and applies them.
+// A record that fails to be written is cut off, since records appended after it would make the WAL unreadable.
+// If it can't be, the FileMap refuses any further writes.
+func (fm *FileMap) writeBatch(ops []batchOp) error {
+    if fm.failed {
+        return ErrFileMapFailed
+    }
+    offset, err := fm.wal.Seek(0, io.SeekCurrent)
+    if err != nil {
+        return err
+    }
+    _, err = fm.wal.Write(encodeRecord(ops))
+    if err == nil {
+        err = fm.wal.Sync()
+    }
+    if err != nil {
+        if fm.truncateWAL(offset) != nil {
+            fm.failed = true
+        }
+        return err
+    }
+    fm.apply(ops)
+    return nil
+}
+
+func (fm *FileMap) apply(ops []batchOp) {
+    for _, op := range ops {
+        if op.del {
+            delete(fm.m, string(op.key))
+        } else {
+            fm.m[string(op.key)] = op.value
+        }
+    }
+}
+
+func (fm *FileMap) storageSize() int {
+    s := 0
+    for k, v := range fm.m {
+        s += len(k)
+        s += len(v)
+    }
+
+    return s
+}
+
+// encodeRecord encodes a set of writes as a record of the form length || crc32 || payload.
+func encodeRecord(ops []batchOp) []byte {
+    var payload []byte
+    payload = appendUvarint(payload, uint64(len(ops)))
+    for _, op := range ops {
+        if op.del {
+            payload = append(payload, 1)
+        } else {
+            payload = append(payload, 0)
+        }
+        payload = appendUvarint(payload, uint64(len(op.key)))
+        payload = append(payload, op.key...)
+        payload = appendUvarint(payload, uint64(len(op.value)))
+        payload = append(payload, op.value...)
+    }
+
+    record := make([]byte, 8, 8 + len(payload))
+    binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+    binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+    return append(record, payload...)
+}
+
+// readRecord reads a record written by encodeRecord, returning its writes and its encoded length.
+// The record's length is checked against the remaining bytes of its file before its payload is allocated.
+func readRecord(reader io.Reader, remaining int64) ([]batchOp, int, error) {
+    header := make([]byte, 8)
+    _, err := io.ReadFull(reader, header)
+    if err == io.EOF {
+        return nil, 0, io.EOF
+    }
+    if err != nil {
+        return nil, 0, errTornRecord
+    }
+    length := int64(binary.BigEndian.Uint32(header[0:4]))
+    if length > remaining - int64(len(header)) {
+        return nil, 0, errTornRecord
+    }
+    n := len(header) + int(length)
+    payload := make([]byte, length)
+    _, err = io.ReadFull(reader, payload)
+    if err != nil {
+        return nil, 0, errTornRecord
+    }
+    if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+        return nil, n, ErrCorruptRecord
+    }
+
+    buf := bytes.NewReader(payload)
+    count, err := binary.ReadUvarint(buf)
+    if err != nil {
+        return nil, n, ErrCorruptRecord
+    }
+    var ops []batchOp
+    for i := uint64(0); i < count; i++ {
+        kind, err := buf.ReadByte()
+        if err != nil {
+            return nil, n, ErrCorruptRecord
+        }
+        key, err := readBytes(buf)
+        if err != nil {
+            return nil, n, ErrCorruptRecord
+        }
+        value, err := readBytes(buf)
+        if err != nil {
+            return nil, n, ErrCorruptRecord
+        }
+        ops = append(ops, batchOp{key: key, value: value, del: kind == 1})
+    }
+
+    return ops, n, nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+    buf := make([]byte, binary.MaxVarintLen64)
+    n := binary.PutUvarint(buf, v)
+    return append(b, buf[:n]...)
+}
+
+func readBytes(buf *bytes.Reader) ([]byte, error) {
+    length, err := binary.ReadUvarint(buf)
+    if err != nil {
+        return nil, err
+    }
+    if length > uint64(buf.Len()) {
+        return nil, io.ErrUnexpectedEOF
+    }
+    b := make([]byte, length)
+    _, err = io.ReadFull(buf, b)
+    return b, err
+}
+
+func writeFileSync(path string, data []byte) error {
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    _, err = f.Write(data)
+    if err == nil {
+        err = f.Sync()
+    }
+    if closeErr := f.Close(); err == nil {
+        err = closeErr
+    }
+    return err
+}
@@ -0,0 +1,194 @@
+package lazyledger
+
+import (
+    "bytes"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestFileMap(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "filemap")
+    defer os.RemoveAll(dir)
+
+    fm, err := NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    fm.Put([]byte("foo"), []byte("bar"))
+    fm.Put([]byte("goo"), []byte("tar"))
+    fm.Del([]byte("goo"))
+    batch := NewBatch(fm)
+    batch.Put([]byte("a"), []byte("1"))
+    batch.Put([]byte("b"), []byte("2"))
+    batch.Commit()
+    fm.Close()
+
+    fm, err = NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if value, _ := fm.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("write not recovered from WAL")
+    }
+    if _, err := fm.Get([]byte("goo")); err == nil {
+        t.Error("delete not recovered from WAL")
+    }
+    if value, _ := fm.Get([]byte("b")); bytes.Compare(value, []byte("2")) != 0 {
+        t.Error("batch not recovered from WAL")
+    }
+
+    fm.Checkpoint()
+    fm.Put([]byte("c"), []byte("3"))
+    fm.Close()
+
+    fm, err = NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if value, _ := fm.Get([]byte("a")); bytes.Compare(value, []byte("1")) != 0 {
+        t.Error("write not recovered from snapshot")
+    }
+    if value, _ := fm.Get([]byte("c")); bytes.Compare(value, []byte("3")) != 0 {
+        t.Error("write after checkpoint not recovered")
+    }
+    fm.Close()
+}
+
+func TestFileMapTornWrite(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "filemap")
+    defer os.RemoveAll(dir)
+
+    fm, _ := NewFileMap(dir)
+    fm.Put([]byte("foo"), []byte("bar"))
+    batch := NewBatch(fm)
+    batch.Put([]byte("a"), []byte("1"))
+    batch.Put([]byte("b"), []byte("2"))
+    batch.Commit()
+    fm.Close()
+
+    // Simulate a crash in the middle of writing the batch.
+    walPath := filepath.Join(dir, fileMapWALName)
+    wal, _ := ioutil.ReadFile(walPath)
+    ioutil.WriteFile(walPath, wal[:len(wal) - 3], 0644)
+
+    fm, err := NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if value, _ := fm.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("complete write before the torn batch was lost")
+    }
+    if _, err := fm.Get([]byte("a")); err == nil {
+        t.Error("torn batch was partially applied")
+    }
+    fm.Put([]byte("c"), []byte("3"))
+    fm.Close()
+
+    fm, _ = NewFileMap(dir)
+    if value, _ := fm.Get([]byte("c")); bytes.Compare(value, []byte("3")) != 0 {
+        t.Error("write after recovery was lost")
+    }
+    fm.Close()
+}
+
+func TestFileMapRecordLength(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "filemap")
+    defer os.RemoveAll(dir)
+
+    fm, _ := NewFileMap(dir)
+    fm.Put([]byte("foo"), []byte("bar"))
+    fm.Close()
+
+    // A torn header claiming a huge record must not be trusted to size the payload.
+    header := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}
+    if _, _, err := readRecord(bytes.NewReader(header), int64(len(header))); err != errTornRecord {
+        t.Error("record longer than its file was read")
+    }
+    walPath := filepath.Join(dir, fileMapWALName)
+    wal, _ := ioutil.ReadFile(walPath)
+    ioutil.WriteFile(walPath, append(wal, header...), 0644)
+
+    fm, err := NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if value, _ := fm.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("write before the torn record was lost")
+    }
+    fm.Close()
+}
+
+func TestFileMapCorruptWAL(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "filemap")
+    defer os.RemoveAll(dir)
+
+    fm, _ := NewFileMap(dir)
+    fm.Put([]byte("foo"), []byte("bar"))
+    fm.Put([]byte("goo"), []byte("tar"))
+    fm.Close()
+
+    // A corrupt record followed by an acknowledged one is not a torn write, so the store refuses to open.
+    walPath := filepath.Join(dir, fileMapWALName)
+    wal, _ := ioutil.ReadFile(walPath)
+    wal[len(wal) / 2 - 1] ^= 0xFF
+    ioutil.WriteFile(walPath, wal, 0644)
+    if _, err := NewFileMap(dir); err != ErrCorruptRecord {
+        t.Errorf("expected ErrCorruptRecord, got %v", err)
+    }
+
+    // The same corruption in the last record is a torn write, which is truncated.
+    wal[len(wal) / 2 - 1] ^= 0xFF
+    wal[len(wal) - 1] ^= 0xFF
+    ioutil.WriteFile(walPath, wal, 0644)
+    fm, err := NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if value, _ := fm.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("write before the torn record was lost")
+    }
+    if _, err := fm.Get([]byte("goo")); err == nil {
+        t.Error("torn record was applied")
+    }
+    fm.Close()
+}
+
+func TestFileMapFailedWrite(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "filemap")
+    defer os.RemoveAll(dir)
+
+    fm, err := NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    fm.Put([]byte("foo"), []byte("bar"))
+
+    // A WAL that can neither be written nor truncated leaves the FileMap failed.
+    wal := fm.wal
+    fm.wal, _ = os.Open(filepath.Join(dir, fileMapWALName))
+    fm.wal.Seek(0, io.SeekEnd)
+    if fm.Put([]byte("goo"), []byte("tar")) == nil {
+        t.Fatal("write to an unwritable WAL succeeded")
+    }
+    if _, err := fm.Get([]byte("goo")); err == nil {
+        t.Error("failed write applied")
+    }
+    fm.wal.Close()
+    fm.wal = wal
+    if fm.Put([]byte("goo"), []byte("tar")) != ErrFileMapFailed {
+        t.Error("write accepted after the WAL failed")
+    }
+    fm.Close()
+
+    fm, err = NewFileMap(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if value, _ := fm.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("write before the failure not recovered")
+    }
+    fm.Close()
+}
@@ -1,7 +1,10 @@
 package lazyledger
 
 import(
+    "errors"
     "fmt"
+    "sort"
+    "strings"
 )
 
 // MapStore is a key-value store.
@@ -21,6 +24,38 @@ func (e *InvalidKeyError) Error() string {
     return fmt.Sprintf("invalid key: %s", e.Key)
 }
 
+// ErrScanUnsupported is returned when listing the keys of a store that can't list them.
+var ErrScanUnsupported = errors.New("store does not support listing keys")
+
+// prefixScanner is implemented by stores that can list their keys.
+type prefixScanner interface {
+    scanPrefix(prefix []byte) ([][]byte, error)
+}
+
+// scanPrefix returns the keys of a store that start with prefix, in sorted order.
+func scanPrefix(store MapStore, prefix []byte) ([][]byte, error) {
+    if ps, ok := store.(prefixScanner); ok {
+        return ps.scanPrefix(prefix)
+    }
+    return nil, ErrScanUnsupported
+}
+
+// scanMap returns the keys of a map that start with prefix, in sorted order.
+func scanMap(m map[string][]byte, prefix []byte) [][]byte {
+    var keys []string
+    for k := range m {
+        if strings.HasPrefix(k, string(prefix)) {
+            keys = append(keys, k)
+        }
+    }
+    sort.Strings(keys)
+    result := make([][]byte, len(keys))
+    for i, k := range keys {
+        result[i] = []byte(k)
+    }
+    return result
+}
+
 // SimpleMap is a simple in-memory map.
 type SimpleMap struct {
     m map[string][]byte
@@ -66,3 +101,193 @@ func (sm *SimpleMap) storageSize() int {
 
     return s
 }
+
+func (sm *SimpleMap) scanPrefix(prefix []byte) ([][]byte, error) {
+    return scanMap(sm.m, prefix), nil
+}
+
+func (sm *SimpleMap) writeBatch(ops []batchOp) error {
+    for _, op := range ops {
+        if op.del {
+            delete(sm.m, string(op.key))
+        } else {
+            sm.m[string(op.key)] = op.value
+        }
+    }
+    return nil
+}
+
+// batchOp is a single pending write in a Batch.
+type batchOp struct {
+    key []byte
+    value []byte
+    del bool
+}
+
+// batchWriter is implemented by stores that can apply a set of writes atomically.
+type batchWriter interface {
+    writeBatch(ops []batchOp) error
+}
+
+// Batch is a set of pending writes to a MapStore that are applied together on Commit.
+// A batch is itself a MapStore, so reads through it see its own pending writes.
+type Batch struct {
+    store MapStore
+    ops map[string]*batchOp
+}
+
+// NewBatch creates a new empty batch of writes on top of a store.
+func NewBatch(store MapStore) *Batch {
+    return &Batch{
+        store: store,
+        ops: make(map[string]*batchOp),
+    }
+}
+
+// Get gets the value for a key, taking pending writes into account.
+func (b *Batch) Get(key []byte) ([]byte, error) {
+    if op, ok := b.ops[string(key)]; ok {
+        if op.del {
+            return nil, &InvalidKeyError{Key: key}
+        }
+        return op.value, nil
+    }
+    return b.store.Get(key)
+}
+
+// Put queues an update of the value for a key.
+// The key and value are copied, so the caller may reuse them before the batch is committed.
+func (b *Batch) Put(key []byte, value []byte) error {
+    b.ops[string(key)] = &batchOp{
+        key: append([]byte(nil), key...),
+        value: append([]byte(nil), value...),
+    }
+    return nil
+}
+
+// Del queues the deletion of a key.
+func (b *Batch) Del(key []byte) error {
+    if _, err := b.Get(key); err != nil {
+        return err
+    }
+    b.ops[string(key)] = &batchOp{
+        key: append([]byte(nil), key...),
+        del: true,
+    }
+    return nil
+}
+
+// Commit applies all pending writes to the underlying store and empties the batch.
+// If the underlying store supports atomic batches, either all or none of the writes are applied.
+func (b *Batch) Commit() error {
+    ops := b.sortedOps()
+    b.Discard()
+    if len(ops) == 0 {
+        return nil
+    }
+    if bw, ok := b.store.(batchWriter); ok {
+        return bw.writeBatch(ops)
+    }
+
+    // The store can't apply the batch atomically, so the writes are applied one by one and undone if one fails.
+    var undo []batchOp
+    for _, op := range ops {
+        old, getErr := b.store.Get(op.key)
+        var err error
+        if op.del {
+            err = b.store.Del(op.key)
+        } else {
+            err = b.store.Put(op.key, op.value)
+        }
+        if err != nil {
+            for i := len(undo) - 1; i >= 0; i-- {
+                if undo[i].del {
+                    b.store.Del(undo[i].key)
+                } else {
+                    b.store.Put(undo[i].key, undo[i].value)
+                }
+            }
+            return err
+        }
+        undo = append(undo, batchOp{key: op.key, value: old, del: getErr != nil})
+    }
+    return nil
+}
+
+// undo returns a batch that restores the values in the underlying store that the pending writes overwrite,
+// so that a committed batch can be rolled back.
+func (b *Batch) undo() *Batch {
+    undo := NewBatch(b.store)
+    for k, op := range b.ops {
+        if old, err := b.store.Get([]byte(k)); err == nil {
+            undo.ops[k] = &batchOp{key: []byte(k), value: old}
+        } else if !op.del {
+            undo.ops[k] = &batchOp{key: []byte(k), del: true}
+        }
+    }
+    return undo
+}
+
+// Discard drops all pending writes.
+func (b *Batch) Discard() {
+    b.ops = make(map[string]*batchOp)
+}
+
+// Size returns the number of pending writes.
+func (b *Batch) Size() int {
+    return len(b.ops)
+}
+
+func (b *Batch) writeBatch(ops []batchOp) error {
+    for _, op := range ops {
+        op := op
+        b.ops[string(op.key)] = &op
+    }
+    return nil
+}
+
+func (b *Batch) scanPrefix(prefix []byte) ([][]byte, error) {
+    stored, err := scanPrefix(b.store, prefix)
+    if err != nil {
+        return nil, err
+    }
+    m := make(map[string][]byte)
+    for _, key := range stored {
+        m[string(key)] = nil
+    }
+    for k, op := range b.ops {
+        if op.del {
+            delete(m, k)
+        } else {
+            m[k] = nil
+        }
+    }
+    return scanMap(m, prefix), nil
+}
+
+// sortedOps returns the pending writes ordered by key, so that they are applied deterministically.
+func (b *Batch) sortedOps() []batchOp {
+    keys := make([]string, 0, len(b.ops))
+    for k := range b.ops {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    ops := make([]batchOp, len(keys))
+    for i, k := range keys {
+        ops[i] = *b.ops[k]
+    }
+    return ops
+}
+
+func (b *Batch) storageSize() int {
+    s := b.store.storageSize()
+    for k, op := range b.ops {
+        if old, err := b.store.Get([]byte(k)); err == nil {
+            s -= len(k) + len(old)
+        }
+        if !op.del {
+            s += len(k) + len(op.value)
+        }
+    }
+    return s
+}
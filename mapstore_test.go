@@ -0,0 +1,123 @@
+package lazyledger
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+)
+
+// failingMap is a store without atomic batches that fails to write one key.
+type failingMap struct {
+    sm *SimpleMap
+    failingKey []byte
+}
+
+func (fm *failingMap) Get(key []byte) ([]byte, error) {
+    return fm.sm.Get(key)
+}
+
+func (fm *failingMap) Put(key []byte, value []byte) error {
+    if bytes.Compare(key, fm.failingKey) == 0 {
+        return errors.New("put failed")
+    }
+    return fm.sm.Put(key, value)
+}
+
+func (fm *failingMap) Del(key []byte) error {
+    return fm.sm.Del(key)
+}
+
+func (fm *failingMap) storageSize() int {
+    return fm.sm.storageSize()
+}
+
+func TestBatch(t *testing.T) {
+    sm := NewSimpleMap()
+    sm.Put([]byte("foo"), []byte("bar"))
+    sm.Put([]byte("goo"), []byte("tar"))
+
+    batch := NewBatch(sm)
+    batch.Put([]byte("foo"), []byte("baz"))
+    batch.Del([]byte("goo"))
+    if value, _ := batch.Get([]byte("foo")); bytes.Compare(value, []byte("baz")) != 0 {
+        t.Error("batch did not read its own pending write")
+    }
+    if _, err := batch.Get([]byte("goo")); err == nil {
+        t.Error("batch did not read its own pending delete")
+    }
+    if value, _ := sm.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("batch wrote to the store before commit")
+    }
+
+    batch.Discard()
+    if value, _ := batch.Get([]byte("foo")); bytes.Compare(value, []byte("bar")) != 0 {
+        t.Error("discarded write still visible")
+    }
+
+    batch.Put([]byte("foo"), []byte("baz"))
+    batch.Del([]byte("goo"))
+    if err := batch.Commit(); err != nil {
+        t.Error("commit failed")
+    }
+    if value, _ := sm.Get([]byte("foo")); bytes.Compare(value, []byte("baz")) != 0 {
+        t.Error("committed write missing from store")
+    }
+    if _, err := sm.Get([]byte("goo")); err == nil {
+        t.Error("committed delete missing from store")
+    }
+}
+
+func TestBatchCopiesWrites(t *testing.T) {
+    sm := NewSimpleMap()
+    batch := NewBatch(sm)
+    key := []byte("foo")
+    value := []byte("bar")
+    batch.Put(key, value)
+    key[0] = 'g'
+    value[0] = 't'
+    batch.Commit()
+    if stored, err := sm.Get([]byte("foo")); err != nil || bytes.Compare(stored, []byte("bar")) != 0 {
+        t.Error("batch did not copy a pending write")
+    }
+}
+
+func TestBatchCommitRollback(t *testing.T) {
+    fm := &failingMap{sm: NewSimpleMap(), failingKey: []byte("b")}
+    fm.Put([]byte("a"), []byte("1"))
+
+    batch := NewBatch(fm)
+    batch.Put([]byte("a"), []byte("2"))
+    batch.Put([]byte("b"), []byte("2"))
+    if err := batch.Commit(); err == nil {
+        t.Error("commit unexpectedly succeeded")
+    }
+    if value, _ := fm.Get([]byte("a")); bytes.Compare(value, []byte("1")) != 0 {
+        t.Error("failed commit was not rolled back")
+    }
+    if _, err := fm.Get([]byte("b")); err == nil {
+        t.Error("failed commit was not rolled back")
+    }
+}
+
+func TestBatchScanPrefix(t *testing.T) {
+    sm := NewSimpleMap()
+    sm.Put([]byte("kv__b"), []byte("1"))
+    sm.Put([]byte("kv__c"), []byte("2"))
+    sm.Put([]byte("other"), []byte("3"))
+
+    batch := NewBatch(sm)
+    batch.Put([]byte("kv__a"), []byte("4"))
+    batch.Del([]byte("kv__c"))
+
+    keys, err := scanPrefix(batch, []byte("kv__"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(keys) != 2 || string(keys[0]) != "kv__a" || string(keys[1]) != "kv__b" {
+        t.Errorf("unexpected keys %q", keys)
+    }
+
+    if _, err := scanPrefix(NewBatch(&failingMap{sm: sm}), []byte("kv__")); err != ErrScanUnsupported {
+        t.Error("scanned a store that can't list its keys")
+    }
+}
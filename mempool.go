@@ -0,0 +1,265 @@
+package lazyledger
+
+import (
+    "bytes"
+    "errors"
+    "sort"
+)
+
+// ErrMessageTooLarge is returned when a message doesn't fit in a share.
+var ErrMessageTooLarge = errors.New("message too large for share size")
+
+// ErrMessageKnown is returned when a message is already in the mempool.
+var ErrMessageKnown = errors.New("message already in mempool")
+
+// FeeFunc returns the fee that a message pays, for ordering the messages of a namespace in the mempool.
+type FeeFunc = func(message Message) uint64
+
+// SenderFunc returns the sender of a message and its nonce, or false if the message has none.
+// A sender's messages are kept in nonce order, however their fees rank.
+type SenderFunc = func(message Message) ([]byte, uint64, bool)
+
+// Mempool holds messages waiting to be included in a block.
+type Mempool struct {
+    shareSize int
+    entries []*mempoolEntry
+    hashes map[string]bool
+    feeFuncs map[[namespaceSize]byte]FeeFunc
+    senderFuncs map[[namespaceSize]byte]SenderFunc
+    sequence uint64
+}
+
+type mempoolEntry struct {
+    message Message
+    hash []byte
+    fee uint64
+    sender []byte
+    nonce uint64
+    sequence uint64
+}
+
+// NewMempool returns a new mempool for messages that must fit in shares of shareSize bytes.
+func NewMempool(shareSize int) *Mempool {
+    return &Mempool{
+        shareSize: shareSize,
+        hashes: make(map[string]bool),
+        feeFuncs: make(map[[namespaceSize]byte]FeeFunc),
+        senderFuncs: make(map[[namespaceSize]byte]SenderFunc),
+    }
+}
+
+// ShareSize returns the size of the shares that messages must fit in.
+func (mp *Mempool) ShareSize() int {
+    return mp.shareSize
+}
+
+// SetFeeFunc sets the function used to get the fees of a namespace's messages.
+// Messages added before the function was set have no fee.
+func (mp *Mempool) SetFeeFunc(namespace [namespaceSize]byte, fn FeeFunc) {
+    mp.feeFuncs[namespace] = fn
+}
+
+// SetSenderFunc sets the function used to get the senders and nonces of a namespace's messages.
+// Messages added before the function was set have no sender.
+func (mp *Mempool) SetSenderFunc(namespace [namespaceSize]byte, fn SenderFunc) {
+    mp.senderFuncs[namespace] = fn
+}
+
+// AddMessage adds a message to the mempool.
+func (mp *Mempool) AddMessage(message Message) error {
+    // A padded message needs two bytes for its size, as in MarshalPadded.
+    if len(message.Marshal()) + 2 > mp.shareSize {
+        return ErrMessageTooLarge
+    }
+    hash := message.Hash()
+    if mp.hashes[string(hash)] {
+        return ErrMessageKnown
+    }
+    var fee uint64
+    if fn, ok := mp.feeFuncs[message.Namespace()]; ok {
+        fee = fn(message)
+    }
+    entry := &mempoolEntry{
+        message: message,
+        hash: hash,
+        fee: fee,
+        sequence: mp.sequence,
+    }
+    if fn, ok := mp.senderFuncs[message.Namespace()]; ok {
+        if sender, nonce, ok := fn(message); ok {
+            entry.sender = sender
+            entry.nonce = nonce
+        }
+    }
+    mp.entries = append(mp.entries, entry)
+    mp.sequence++
+    mp.hashes[string(hash)] = true
+    return nil
+}
+
+// Has returns true if a message with a hash is in the mempool.
+func (mp *Mempool) Has(hash []byte) bool {
+    return mp.hashes[string(hash)]
+}
+
+// Size returns the number of messages in the mempool.
+func (mp *Mempool) Size() int {
+    return len(mp.entries)
+}
+
+// Messages returns the messages in the mempool, ordered by namespace, then by fee from highest to lowest.
+// A sender's messages are ordered by nonce, so a message is only ranked by its fee once the sender's earlier messages are.
+// Messages with equal fees keep the order they were added in.
+func (mp *Mempool) Messages() []Message {
+    entries := mp.sortedEntries()
+    messages := make([]Message, len(entries))
+    for i, entry := range entries {
+        messages[i] = entry.message
+    }
+    return messages
+}
+
+func (mp *Mempool) sortedEntries() []*mempoolEntry {
+    entries := append([]*mempoolEntry(nil), mp.entries...)
+    sort.Slice(entries, func(i, j int) bool {
+        a := entries[i].message.Namespace()
+        b := entries[j].message.Namespace()
+        if c := bytes.Compare(a[:], b[:]); c != 0 {
+            return c < 0
+        }
+        return entries[i].sequence < entries[j].sequence
+    })
+    var sorted []*mempoolEntry
+    for start := 0; start < len(entries); {
+        end := start + 1
+        for end < len(entries) && entries[end].message.Namespace() == entries[start].message.Namespace() {
+            end++
+        }
+        sorted = append(sorted, rankEntries(entries[start:end])...)
+        start = end
+    }
+    return sorted
+}
+
+// rankEntries orders the entries of a namespace, given in the order they were added. Each sender's entries form a queue in nonce order,
+// and the entry with the highest fee among the heads of the queues is taken next. Entries without a sender are queues of their own.
+func rankEntries(entries []*mempoolEntry) []*mempoolEntry {
+    var queues [][]*mempoolEntry
+    senderQueues := make(map[string]int)
+    for _, entry := range entries {
+        if entry.sender == nil {
+            queues = append(queues, []*mempoolEntry{entry})
+            continue
+        }
+        i, ok := senderQueues[string(entry.sender)]
+        if !ok {
+            i = len(queues)
+            senderQueues[string(entry.sender)] = i
+            queues = append(queues, nil)
+        }
+        queues[i] = append(queues[i], entry)
+    }
+    for _, queue := range queues {
+        sort.SliceStable(queue, func(i, j int) bool {
+            return queue[i].nonce < queue[j].nonce
+        })
+    }
+
+    ranked := make([]*mempoolEntry, 0, len(entries))
+    for len(ranked) < len(entries) {
+        best := -1
+        for i, queue := range queues {
+            if len(queue) == 0 {
+                continue
+            }
+            if best < 0 || queue[0].fee > queues[best][0].fee || (queue[0].fee == queues[best][0].fee && queue[0].sequence < queues[best][0].sequence) {
+                best = i
+            }
+        }
+        ranked = append(ranked, queues[best][0])
+        queues[best] = queues[best][1:]
+    }
+    return ranked
+}
+
+// Remove removes messages from the mempool, if they are in it.
+func (mp *Mempool) Remove(messages []Message) {
+    removed := make(map[string]bool)
+    for _, message := range messages {
+        removed[string(message.Hash())] = true
+    }
+    var kept []*mempoolEntry
+    for _, entry := range mp.entries {
+        if removed[string(entry.hash)] {
+            delete(mp.hashes, string(entry.hash))
+        } else {
+            kept = append(kept, entry)
+        }
+    }
+    mp.entries = kept
+}
+
+// RemoveBlock removes the messages of a block from the mempool, once the block has been processed.
+func (mp *Mempool) RemoveBlock(block Block) {
+    mp.Remove(block.Messages())
+}
+
+// BlockBuilder fills blocks with messages from a mempool.
+// Messages stay in the mempool until the block is processed and removed with RemoveBlock.
+type BlockBuilder struct {
+    mempool *Mempool
+    maxSize int
+}
+
+// NewBlockBuilder returns a block builder that fills blocks from a mempool with at most maxSize bytes of messages.
+func NewBlockBuilder(mempool *Mempool, maxSize int) *BlockBuilder {
+    return &BlockBuilder{
+        mempool: mempool,
+        maxSize: maxSize,
+    }
+}
+
+// BuildSimpleBlock builds a simple block on prevHash, whose marshalled messages take at most the maximum size.
+func (bb *BlockBuilder) BuildSimpleBlock(prevHash []byte) Block {
+    block := NewSimpleBlock(prevHash)
+    size := 0
+    // Once a sender's message is left out, its later messages are too, since they would fail on the nonce.
+    skipped := make(map[string]bool)
+    for _, entry := range bb.mempool.sortedEntries() {
+        if entry.sender != nil && skipped[string(entry.sender)] {
+            continue
+        }
+        messageSize := len(entry.message.Marshal())
+        if size + messageSize > bb.maxSize {
+            if entry.sender != nil {
+                skipped[string(entry.sender)] = true
+            }
+            continue
+        }
+        size += messageSize
+        block.AddMessage(entry.message)
+    }
+    return block
+}
+
+// BuildProbabilisticBlock builds a probabilistic block on prevHash, whose original data square takes at most the maximum size.
+// Every message takes one share of the mempool's share size, and the square is padded to a square number of shares.
+func (bb *BlockBuilder) BuildProbabilisticBlock(prevHash []byte) Block {
+    block := NewProbabilisticBlock(prevHash, bb.mempool.shareSize)
+    shares := 0
+    if bb.mempool.shareSize > 0 {
+        shares = bb.maxSize / bb.mempool.shareSize
+    }
+    width := 0
+    for (width + 1) * (width + 1) <= shares {
+        width++
+    }
+    maxMessages := width * width
+    for i, entry := range bb.mempool.sortedEntries() {
+        if i >= maxMessages {
+            break
+        }
+        block.AddMessage(entry.message)
+    }
+    return block
+}
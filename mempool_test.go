@@ -0,0 +1,120 @@
+package lazyledger
+
+import (
+    "bytes"
+    "crypto/rand"
+    "testing"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestMempool(t *testing.T) {
+    mp := NewMempool(64)
+
+    var nsA, nsB [namespaceSize]byte
+    copy(nsA[:], []byte("a"))
+    copy(nsB[:], []byte("b"))
+
+    if mp.AddMessage(*NewMessage(nsA, make([]byte, 64 - namespaceSize - 1))) != ErrMessageTooLarge {
+        t.Error("added a message that doesn't fit in a share")
+    }
+    if mp.AddMessage(*NewMessage(nsB, []byte("first"))) != nil || mp.AddMessage(*NewMessage(nsA, []byte("second"))) != nil {
+        t.Fatal("failed to add messages")
+    }
+    if mp.AddMessage(*NewMessage(nsB, []byte("first"))) != ErrMessageKnown {
+        t.Error("added a duplicate message")
+    }
+    if mp.Size() != 2 {
+        t.Errorf("expected 2 messages, got %d", mp.Size())
+    }
+
+    messages := mp.Messages()
+    if messages[0].Namespace() != nsA || messages[1].Namespace() != nsB {
+        t.Error("messages not ordered by namespace")
+    }
+
+    mp.Remove(messages[:1])
+    if mp.Size() != 1 || mp.Has(messages[0].Hash()) {
+        t.Error("message not removed")
+    }
+    if mp.AddMessage(messages[0]) != nil {
+        t.Error("removed message can't be added again")
+    }
+}
+
+func TestMempoolFees(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+    app := NewCurrency(NewSimpleMap(), b)
+    currency := app.(*Currency)
+
+    mp := NewMempool(512)
+    mp.SetFeeFunc(currency.Namespace(), currency.MessageFee)
+    mp.SetSenderFunc(currency.Namespace(), currency.MessageSender)
+
+    privA, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    _, pubC, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    low := currency.GenerateTransactionWithFee(privA, pubC, 10, 1, 0, nil)
+    high := currency.GenerateTransactionWithFee(privB, pubC, 10, 5, 0, nil)
+    none := currency.GenerateTransactionWithFee(privA, pubC, 10, 0, 1, nil)
+    mp.AddMessage(low)
+    mp.AddMessage(none)
+    mp.AddMessage(high)
+
+    messages := mp.Messages()
+    if bytes.Compare(messages[0].Hash(), high.Hash()) != 0 || bytes.Compare(messages[1].Hash(), low.Hash()) != 0 || bytes.Compare(messages[2].Hash(), none.Hash()) != 0 {
+        t.Error("messages not ordered by fee")
+    }
+
+    // A sender's later message pays a higher fee, but can't be ranked before the sender's earlier ones.
+    privD, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privE, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    first := currency.GenerateTransactionWithFee(privD, pubC, 10, 1, 0, nil)
+    second := currency.GenerateTransactionWithFee(privD, pubC, 10, 10, 1, nil)
+    other := currency.GenerateTransactionWithFee(privE, pubC, 10, 5, 0, nil)
+    mp = NewMempool(512)
+    mp.SetFeeFunc(currency.Namespace(), currency.MessageFee)
+    mp.SetSenderFunc(currency.Namespace(), currency.MessageSender)
+    mp.AddMessage(second)
+    mp.AddMessage(first)
+    mp.AddMessage(other)
+
+    messages = mp.Messages()
+    if bytes.Compare(messages[0].Hash(), other.Hash()) != 0 || bytes.Compare(messages[1].Hash(), first.Hash()) != 0 || bytes.Compare(messages[2].Hash(), second.Hash()) != 0 {
+        t.Error("messages of a sender not ordered by nonce")
+    }
+}
+
+func TestBlockBuilder(t *testing.T) {
+    mp := NewMempool(64)
+    var namespace [namespaceSize]byte
+    for i := 0; i < 10; i++ {
+        mp.AddMessage(*NewMessage(namespace, []byte{byte(i), 0, 0, 0, 0, 0, 0, 0}))
+    }
+
+    bb := NewBlockBuilder(mp, 5 * 16)
+    sb := bb.BuildSimpleBlock([]byte{0})
+    if len(sb.Messages()) != 5 {
+        t.Errorf("expected 5 messages in simple block, got %d", len(sb.Messages()))
+    }
+
+    bb = NewBlockBuilder(mp, 5 * 64)
+    pb := bb.BuildProbabilisticBlock([]byte{0})
+    if len(pb.Messages()) != 4 {
+        t.Errorf("expected 4 messages in probabilistic block, got %d", len(pb.Messages()))
+    }
+    if pb.(*ProbabilisticBlock).SquareWidth() != 4 {
+        t.Error("wrong square width")
+    }
+
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+    if err := b.ProcessBlock(sb); err != nil {
+        t.Fatal(err)
+    }
+    mp.RemoveBlock(sb)
+    if mp.Size() != 5 {
+        t.Errorf("expected 5 messages left in mempool, got %d", mp.Size())
+    }
+}
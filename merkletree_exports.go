@@ -22,6 +22,13 @@ func leafSum(h hash.Hash, data []byte) []byte {
 	return sum(h, []byte{0x00}, data)
 }
 
+// nodeSum returns the hash created from two sibling nodes being combined into
+// a parent node. Node sums are calculated using:
+//		Hash(0x01 || left sibling sum || right sibling sum)
+func nodeSum(h hash.Hash, a, b []byte) []byte {
+	return sum(h, []byte{0x01}, a, b)
+}
+
 // nextSubtreeSize returns the size of the subtree adjacent to start that does
 // not overlap end.
 func nextSubtreeSize(start, end uint64) int {
@@ -1,6 +1,7 @@
 package lazyledger
 
 import (
+    "crypto/sha256"
     "encoding/binary"
 )
 
@@ -58,6 +59,12 @@ func (m *Message) MarshalPadded(messageSize int) []byte {
     return marshalled
 }
 
+// Hash returns the hash of a message.
+func (m *Message) Hash() []byte {
+    hash := sha256.Sum256(m.Marshal())
+    return hash[:]
+}
+
 // Namespace returns the namespace of a message.
 func (m *Message) Namespace() [namespaceSize]byte {
     return m.namespace;
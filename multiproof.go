@@ -0,0 +1,117 @@
+package lazyledger
+
+import (
+    "bytes"
+    "errors"
+    "hash"
+    "sort"
+)
+
+// ErrInvalidIndexes is returned when proving messages at indexes that are out of range, or when there are none.
+var ErrInvalidIndexes = errors.New("invalid message indexes")
+
+// MultiDependencyProof proves that a block contains several messages.
+// The messages are proven together, so that the sibling nodes their paths to the root share are only included once.
+type MultiDependencyProof struct {
+    Indexes []int
+    NumLeaves int // Number of messages in a SimpleBlock; unused for a ProbabilisticBlock, whose rows have a fixed width.
+    Proofs [][][]byte // One multiproof per tree the messages are in; a SimpleBlock has a single tree.
+}
+
+// largestPowerOfTwoBelow returns the largest power of two that is smaller than n, for n > 1.
+// A tree of n leaves is split into a perfect left subtree of that many leaves and a right subtree of the rest.
+func largestPowerOfTwoBelow(n int) int {
+    k := 1
+    for k * 2 < n {
+        k *= 2
+    }
+    return k
+}
+
+// subtreeRoot returns the root of the subtree of the leaves with hashes leafHashes[lo:hi].
+func subtreeRoot(h hash.Hash, leafHashes [][]byte, lo int, hi int) []byte {
+    if hi - lo == 1 {
+        return leafHashes[lo]
+    }
+    k := lo + largestPowerOfTwoBelow(hi - lo)
+    return nodeSum(h, subtreeRoot(h, leafHashes, lo, k), subtreeRoot(h, leafHashes, k, hi))
+}
+
+// buildMultiproof returns the roots of the largest subtrees that contain none of the leaves at the sorted indexes,
+// from left to right. Together with the hashes of those leaves, they are all that is needed to recompute the root.
+func buildMultiproof(h hash.Hash, leafHashes [][]byte, indexes []int) [][]byte {
+    var proof [][]byte
+    var build func(lo int, hi int, indexes []int)
+    build = func(lo int, hi int, indexes []int) {
+        if len(indexes) == 0 {
+            proof = append(proof, subtreeRoot(h, leafHashes, lo, hi))
+            return
+        }
+        if hi - lo == 1 {
+            return
+        }
+        k := lo + largestPowerOfTwoBelow(hi - lo)
+        split := sort.SearchInts(indexes, k)
+        build(lo, k, indexes[:split])
+        build(k, hi, indexes[split:])
+    }
+    build(0, len(leafHashes), indexes)
+    return proof
+}
+
+// verifyMultiproof checks that the leaves with hashes leafHashes are at the sorted indexes of a tree of numLeaves leaves with a root.
+func verifyMultiproof(h hash.Hash, root []byte, numLeaves int, indexes []int, leafHashes [][]byte, proof [][]byte) bool {
+    if len(indexes) == 0 || len(indexes) != len(leafHashes) || !indexesValid(indexes, numLeaves) {
+        return false
+    }
+    var compute func(lo int, hi int, indexes []int, leafHashes [][]byte) []byte
+    compute = func(lo int, hi int, indexes []int, leafHashes [][]byte) []byte {
+        if len(indexes) == 0 {
+            if len(proof) == 0 {
+                return nil
+            }
+            node := proof[0]
+            proof = proof[1:]
+            return node
+        }
+        if hi - lo == 1 {
+            return leafHashes[0]
+        }
+        k := lo + largestPowerOfTwoBelow(hi - lo)
+        split := sort.SearchInts(indexes, k)
+        left := compute(lo, k, indexes[:split], leafHashes[:split])
+        right := compute(k, hi, indexes[split:], leafHashes[split:])
+        if left == nil || right == nil {
+            return nil
+        }
+        return nodeSum(h, left, right)
+    }
+    computed := compute(0, numLeaves, indexes, leafHashes)
+    if computed == nil || len(proof) != 0 {
+        return false
+    }
+    return bytes.Compare(computed, root) == 0
+}
+
+// indexesValid checks that indexes are sorted, unique and smaller than numLeaves.
+func indexesValid(indexes []int, numLeaves int) bool {
+    for i, index := range indexes {
+        if index < 0 || index >= numLeaves || (i > 0 && indexes[i - 1] >= index) {
+            return false
+        }
+    }
+    return true
+}
+
+// sortedIndexes returns a sorted copy of indexes without duplicates.
+func sortedIndexes(indexes []int) []int {
+    sorted := append([]int(nil), indexes...)
+    sort.Ints(sorted)
+    unique := sorted[:0]
+    for i, index := range sorted {
+        if i == 0 || sorted[i - 1] != index {
+            unique = append(unique, index)
+        }
+    }
+    return unique
+}
@@ -0,0 +1,184 @@
+package lazyledger
+
+import (
+    "crypto/sha256"
+    "testing"
+)
+
+func TestSimpleBlockMultiproof(t *testing.T) {
+    // Multiproofs are checked against the messages root for every tree size and single index,
+    // which requires the proofs to follow the same tree shape as the Merkle tree.
+    for n := 1; n <= 12; n++ {
+        sb := NewSimpleBlock([]byte{0})
+        for i := 0; i < n; i++ {
+            sb.AddMessage(*NewMessage([namespaceSize]byte{byte(i)}, []byte("foo")))
+        }
+        for i := 0; i < n; i++ {
+            hashes, proof, err := sb.ProveDependencies([]int{i})
+            if err != nil || !sb.VerifyDependencies(hashes, proof) {
+                t.Fatalf("multiproof of index %d of %d messages failed to verify", i, n)
+            }
+        }
+    }
+
+    sb := NewSimpleBlock([]byte{0})
+    for i := 0; i < 7; i++ {
+        sb.AddMessage(*NewMessage([namespaceSize]byte{byte(i)}, []byte("foo")))
+    }
+    hashes, proof, err := sb.ProveDependencies([]int{5, 1, 3, 1})
+    if err != nil {
+        t.Fatal(err)
+    }
+    singleProofSize := 0
+    for i, index := range []int{1, 3, 5} {
+        hash, singleProof, _ := sb.ProveDependency(index)
+        if string(hash) != string(hashes[i]) {
+            t.Error("multiproof returned the wrong hashes")
+        }
+        singleProofSize += len(singleProof)
+    }
+    if len(proof.Proofs[0]) >= singleProofSize {
+        t.Error("multiproof is not smaller than separate proofs")
+    }
+
+    header := ImportSimpleBlockHeader(sb.PrevHash(), sb.(*SimpleBlock).MessagesRoot(), sb.(*SimpleBlock).NumMessages())
+    hashes[1][len(hashes[1]) - 1] ^= 0xFF
+    if header.VerifyDependencies(hashes, proof) || header.DependencyProven(hashes[0]) {
+        t.Error("multiproof with a wrong hash verified")
+    }
+    hashes[1][len(hashes[1]) - 1] ^= 0xFF
+    if !header.VerifyDependencies(hashes, proof) {
+        t.Error("multiproof failed to verify against the block header")
+    }
+    for _, hash := range hashes {
+        if !header.DependencyProven(hash) {
+            t.Error("dependency of a multiproof not recorded as proven")
+        }
+    }
+
+    if _, _, err := sb.ProveDependencies([]int{7}); err != ErrInvalidIndexes {
+        t.Error("proved a message out of range")
+    }
+}
+
+func TestSimpleBlockMultiproofNumLeaves(t *testing.T) {
+    sb := NewSimpleBlock([]byte{0})
+    for i := 0; i < 4; i++ {
+        sb.AddMessage(*NewMessage([namespaceSize]byte{byte(i)}, []byte("foo")))
+    }
+    fh := NewFlagHasher(NewNamespaceDummyFlagger(), sha256.New())
+    var leafHashes [][]byte
+    for _, message := range sb.Messages() {
+        leafHashes = append(leafHashes, leafSum(fh, message.Marshal()))
+    }
+    left := nodeSum(fh, leafHashes[0], leafHashes[1])
+    right := nodeSum(fh, leafHashes[2], leafHashes[3])
+
+    // Claiming the tree has two leaves would prove the inner node over the first two messages as a message.
+    forged := &MultiDependencyProof{
+        Indexes: []int{0},
+        NumLeaves: 2,
+        Proofs: [][][]byte{{right}},
+    }
+    header := ImportSimpleBlockHeader(sb.PrevHash(), sb.(*SimpleBlock).MessagesRoot(), sb.(*SimpleBlock).NumMessages())
+    if sb.VerifyDependencies([][]byte{left}, forged) || header.VerifyDependencies([][]byte{left}, forged) {
+        t.Error("multiproof with a tampered number of leaves verified")
+    }
+
+    // A header that lies about the number of messages doesn't have the block's digest.
+    lying := ImportSimpleBlockHeader(sb.PrevHash(), sb.(*SimpleBlock).MessagesRoot(), 2)
+    if string(lying.Digest()) == string(sb.Digest()) {
+        t.Error("digest does not commit to the number of messages")
+    }
+}
+
+func TestProbabilisticBlockMultiproof(t *testing.T) {
+    pb := NewProbabilisticBlock([]byte{0}, 512)
+    for i := 0; i < 8; i++ {
+        pb.AddMessage(*NewMessage([namespaceSize]byte{byte(i)}, []byte("foo")))
+    }
+    hashes, proof, err := pb.ProveDependencies([]int{0, 2, 3, 7})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for i, index := range []int{0, 2, 3, 7} {
+        hash, _, _ := pb.ProveDependency(index)
+        if string(hash) != string(hashes[i]) {
+            t.Error("multiproof returned the wrong hashes")
+        }
+    }
+
+    header := ImportProbabilisticBlockHeader(pb.PrevHash(), pb.(*ProbabilisticBlock).RowRoots(), pb.(*ProbabilisticBlock).ColumnRoots(), pb.(*ProbabilisticBlock).SquareWidth(), 512, true)
+    proof.Proofs[0][0][0] ^= 0xFF
+    if header.VerifyDependencies(hashes, proof) {
+        t.Error("tampered multiproof verified")
+    }
+    proof.Proofs[0][0][0] ^= 0xFF
+    if !header.VerifyDependencies(hashes, proof) {
+        t.Error("multiproof failed to verify against the block header")
+    }
+    for _, hash := range hashes {
+        if !header.DependencyProven(hash) {
+            t.Error("dependency of a multiproof not recorded as proven")
+        }
+    }
+}
+
+// multiDependencyApp is a DummyApp whose messages all depend on a fixed set of messages.
+type multiDependencyApp struct {
+    *DummyApp
+    refs []DependencyReference
+}
+
+func (app *multiDependencyApp) Dependencies(message Message) []DependencyReference {
+    return app.refs
+}
+
+// countingProofProvider counts the proofs requested from a provider.
+type countingProofProvider struct {
+    *BlockStoreProofProvider
+    single int
+    batched int
+}
+
+func (p *countingProofProvider) DependencyProof(ref DependencyReference) (int, [][]byte, error) {
+    p.single++
+    return p.BlockStoreProofProvider.DependencyProof(ref)
+}
+
+func (p *countingProofProvider) DependencyProofs(digest []byte, indexes []int) (*MultiDependencyProof, error) {
+    p.batched++
+    return p.BlockStoreProofProvider.DependencyProofs(digest, indexes)
+}
+
+func TestBlockchainBatchedDependencies(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+    provider := &countingProofProvider{BlockStoreProofProvider: NewBlockStoreProofProvider(bs)}
+    b.SetDependencyProvider(provider)
+
+    sb := NewSimpleBlock([]byte{0})
+    for i := 0; i < 4; i++ {
+        sb.AddMessage(*NewMessage([namespaceSize]byte{0}, []byte{byte(i)}))
+    }
+    b.ProcessBlock(sb)
+
+    var refs []DependencyReference
+    for _, index := range []int{0, 2, 3} {
+        hash, _, _ := sb.ProveDependency(index)
+        refs = append(refs, DependencyReference{Block: sb.Digest(), Index: index, Hash: hash})
+    }
+    var app Application = &multiDependencyApp{DummyApp: NewDummyApp(NewSimpleMap()).(*DummyApp), refs: refs}
+    b.RegisterApplication(&app)
+
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(app.(*multiDependencyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    b.ProcessBlock(sb)
+
+    if app.(*multiDependencyApp).Get("foo") != "bar" {
+        t.Error("message with several dependencies was not processed")
+    }
+    if provider.batched != 1 || provider.single != 0 {
+        t.Errorf("dependencies proven with %d multiproofs and %d single proofs, want 1 and 0", provider.batched, provider.single)
+    }
+}
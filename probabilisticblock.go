@@ -26,6 +26,7 @@ type ProbabilisticBlock struct {
     validated bool
     sampleRequest *SampleRequest
     provenDependencies map[string]bool
+    producerSignature *ProducerSignature
 }
 
 type SampleRequest struct {
@@ -272,15 +273,29 @@ func (pb *ProbabilisticBlock) ProcessSamplesResponse(response *SampleResponse) b
 func (pb *ProbabilisticBlock) Digest() []byte {
     hasher := sha256.New()
     hasher.Write(pb.prevHash)
-    for _, root := range pb.rowRoots {
+    for _, root := range pb.RowRoots() {
         hasher.Write(root)
     }
-    for _, root := range pb.columnRoots {
+    for _, root := range pb.ColumnRoots() {
         hasher.Write(root)
     }
+    // Unsigned blocks hash as they did before blocks had producers.
+    if pb.producerSignature != nil {
+        hasher.Write(pb.producerSignature.headerBytes())
+    }
     return hasher.Sum(nil)
 }
 
+// ProducerSignature returns the signature of the block's producer, or nil if the block is unsigned.
+func (pb *ProbabilisticBlock) ProducerSignature() *ProducerSignature {
+    return pb.producerSignature
+}
+
+// SetProducerSignature sets the signature of the block's producer.
+func (pb *ProbabilisticBlock) SetProducerSignature(signature *ProducerSignature) {
+    pb.producerSignature = signature
+}
+
 // Valid returns true if the block is valid.
 func (pb *ProbabilisticBlock) Valid() bool {
     return pb.validated
@@ -345,6 +360,17 @@ func (pb *ProbabilisticBlock) ApplicationProof(namespace [namespaceSize]byte) (i
         }
     }
 
+    if !found && !inRange && len(pb.messages) > 0 {
+        // The namespace is outside the range of the block's namespaces, so its absence is proven by the first or last message.
+        inRange = true
+        firstNs := pb.messages[0].Namespace()
+        if bytes.Compare(namespace[:], firstNs[:]) < 0 {
+            proofStart, proofEnd = 0, 1
+        } else {
+            proofStart, proofEnd = len(pb.messages) - 1, len(pb.messages)
+        }
+    }
+
     ndf := NewNamespaceDummyFlagger()
     fh := NewFlagHasher(ndf, sha256.New())
     var proofs [][][]byte
@@ -393,6 +419,10 @@ func (pb *ProbabilisticBlock) ApplicationProof(namespace [namespaceSize]byte) (i
 
 // VerifyApplicationProof verifies a Merkle proof for all of the messages in a block for an application namespace.
 func (pb *ProbabilisticBlock) VerifyApplicationProof(namespace [namespaceSize]byte, proofStart int, proofEnd int, proofs [][][]byte, messages *[]Message, hashes [][]byte) bool {
+    if !hashesOutsideNamespace(namespace, hashes) {
+        return false
+    }
+
     // Verify Merkle proofs
     ndf := NewNamespaceDummyFlagger()
     fh := NewFlagHasher(ndf, sha256.New())
@@ -426,6 +456,10 @@ func (pb *ProbabilisticBlock) VerifyApplicationProof(namespace [namespaceSize]by
                 endColumn = pb.SquareWidth() / 2
             }
 
+            if proofNum >= len(proofs) {
+                return false
+            }
+
             // Verify proof
             result, err := merkletree.VerifyRangeProof(lh, fh, startColumn, endColumn, proofs[proofNum], pb.RowRoots()[i])
             if !result || err != nil {
@@ -483,6 +517,9 @@ func (pb *ProbabilisticBlock) ProveDependency(index int) ([]byte, [][]byte, erro
 }
 
 func (pb *ProbabilisticBlock) VerifyDependency(index int, hash []byte, proof [][]byte) bool {
+    if index < 0 || index >= pb.SquareWidth() * pb.SquareWidth() / 4 {
+        return false
+    }
     ndf := NewNamespaceDummyFlagger()
     fh := NewFlagHasher(ndf, sha256.New())
     lh := NewHashLeafHasher([][]byte{hash})
@@ -495,9 +532,104 @@ func (pb *ProbabilisticBlock) VerifyDependency(index int, hash []byte, proof [][
     return false
 }
 
+// ProveDependencies creates a Merkle multiproof for the messages at several indexes, and returns their hashes.
+// The messages are proven against the roots of the rows they are in, with one multiproof per row.
+func (pb *ProbabilisticBlock) ProveDependencies(indexes []int) ([][]byte, *MultiDependencyProof, error) {
+    indexes = sortedIndexes(indexes)
+    if len(indexes) == 0 || !indexesValid(indexes, len(pb.messages)) {
+        return nil, nil, ErrInvalidIndexes
+    }
+    ndf := NewNamespaceDummyFlagger()
+    fh := NewFlagHasher(ndf, sha256.New())
+    var hashes [][]byte
+    proof := &MultiDependencyProof{
+        Indexes: indexes,
+    }
+    for _, row := range pb.dependencyRows(indexes) {
+        rowData := pb.eds().Row(uint(row.row))
+        leafHashes := make([][]byte, len(rowData))
+        for j, share := range rowData {
+            fh.(*flagDigest).setCodedMode(j >= pb.SquareWidth() / 2)
+            leafHashes[j] = leafSum(fh, share)
+        }
+        fh.(*flagDigest).setCodedMode(false)
+        for _, column := range row.columns {
+            hashes = append(hashes, leafHashes[column])
+        }
+        proof.Proofs = append(proof.Proofs, buildMultiproof(fh, leafHashes, row.columns))
+    }
+    return hashes, proof, nil
+}
+
+// VerifyDependencies verifies a Merkle multiproof for several messages, and records them as proven if it is valid.
+func (pb *ProbabilisticBlock) VerifyDependencies(hashes [][]byte, proof *MultiDependencyProof) bool {
+    if len(proof.Indexes) != len(hashes) || !indexesValid(proof.Indexes, pb.SquareWidth() * pb.SquareWidth() / 4) {
+        return false
+    }
+    rows := pb.dependencyRows(proof.Indexes)
+    if len(rows) != len(proof.Proofs) {
+        return false
+    }
+    ndf := NewNamespaceDummyFlagger()
+    fh := NewFlagHasher(ndf, sha256.New())
+    verified := 0
+    for i, row := range rows {
+        rowHashes := hashes[verified:verified + len(row.columns)]
+        if !verifyMultiproof(fh, pb.RowRoots()[row.row], pb.SquareWidth(), row.columns, rowHashes, proof.Proofs[i]) {
+            return false
+        }
+        verified += len(row.columns)
+    }
+    for _, hash := range hashes {
+        pb.provenDependencies[string(hash)] = true
+    }
+    return true
+}
+
+// dependencyRow is a row of the data square along with the columns of some messages in it.
+type dependencyRow struct {
+    row int
+    columns []int
+}
+
+// dependencyRows groups sorted message indexes by the row they are in.
+func (pb *ProbabilisticBlock) dependencyRows(indexes []int) []dependencyRow {
+    var rows []dependencyRow
+    for _, index := range indexes {
+        r, c := pb.indexToCoordinates(index)
+        if len(rows) == 0 || rows[len(rows) - 1].row != r {
+            rows = append(rows, dependencyRow{row: r})
+        }
+        rows[len(rows) - 1].columns = append(rows[len(rows) - 1].columns, c)
+    }
+    return rows
+}
+
 func (pb *ProbabilisticBlock) DependencyProven(hash []byte) bool {
     if value, ok := pb.provenDependencies[string(hash)]; ok {
         return value
     }
     return false
 }
+
+// ProveApplication creates an ApplicationProof for all of the messages in a block for an application namespace.
+func (pb *ProbabilisticBlock) ProveApplication(namespace [namespaceSize]byte) *ApplicationProof {
+    proofStart, proofEnd, proofs, messages, hashes := pb.ApplicationProof(namespace)
+    return &ApplicationProof{
+        Namespace: namespace,
+        ProofStart: proofStart,
+        ProofEnd: proofEnd,
+        Proofs: proofs,
+        Messages: messages,
+        Hashes: hashes,
+    }
+}
+
+// VerifyApplication verifies an ApplicationProof against the block's row roots.
+// The proof's range is of message indexes, which are positions in the original data square, so it must lie within it.
+func (pb *ProbabilisticBlock) VerifyApplication(proof *ApplicationProof) bool {
+    if !proof.proofRangeValid() || proof.ProofEnd > pb.SquareWidth() * pb.SquareWidth() / 4 {
+        return false
+    }
+    return pb.VerifyApplicationProof(proof.Namespace, proof.ProofStart, proof.ProofEnd, proof.Proofs, proof.Messages, proof.Hashes)
+}
@@ -0,0 +1,111 @@
+package lazyledger
+
+import (
+    "encoding/binary"
+    "errors"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ErrUnsignedBlock is returned when a block without a producer signature is processed by a blockchain with allowed producers.
+var ErrUnsignedBlock = errors.New("block is not signed by a producer")
+
+// ErrInvalidProducerSignature is returned when a block's producer signature doesn't match its digest.
+var ErrInvalidProducerSignature = errors.New("invalid producer signature")
+
+// ErrProducerNotAllowed is returned when a block is signed by a producer that isn't in the allowed set.
+var ErrProducerNotAllowed = errors.New("block producer not allowed")
+
+// ErrInvalidTimestamp is returned when a block's timestamp is earlier than its parent's.
+var ErrInvalidTimestamp = errors.New("block timestamp earlier than parent's")
+
+// ProducerSignature identifies the producer of a block and when it was produced, and signs the block's digest.
+// The digest commits to the producer and timestamp, but not to the signature itself.
+type ProducerSignature struct {
+    Producer []byte
+    Timestamp int64
+    Signature []byte
+}
+
+// headerBytes returns the fields of a producer signature that a block's digest commits to.
+func (ps *ProducerSignature) headerBytes() []byte {
+    timestampBytes := make([]byte, 8)
+    binary.BigEndian.PutUint64(timestampBytes, uint64(ps.Timestamp))
+    return append(append([]byte(nil), ps.Producer...), timestampBytes...)
+}
+
+// SignBlock sets the producer and timestamp of a block, and signs its digest with the producer's key.
+func SignBlock(block Block, privKey crypto.PrivKey, timestamp int64) error {
+    producer, err := privKey.GetPublic().Bytes()
+    if err != nil {
+        return err
+    }
+    signature := &ProducerSignature{
+        Producer: producer,
+        Timestamp: timestamp,
+    }
+    block.SetProducerSignature(signature)
+    signature.Signature, err = privKey.Sign(block.Digest())
+    return err
+}
+
+// VerifyBlockSignature checks that a block's producer signature is valid.
+func VerifyBlockSignature(block Block) error {
+    signature := block.ProducerSignature()
+    if signature == nil {
+        return ErrUnsignedBlock
+    }
+    producer, err := crypto.UnmarshalPublicKey(signature.Producer)
+    if err != nil {
+        return ErrInvalidProducerSignature
+    }
+    ok, err := producer.Verify(block.Digest(), signature.Signature)
+    if !ok || err != nil {
+        return ErrInvalidProducerSignature
+    }
+    return nil
+}
+
+// AddProducer allows a public key to produce blocks.
+// Once any producer is allowed, every block processed must be signed by an allowed producer.
+func (b *Blockchain) AddProducer(pubKey crypto.PubKey) error {
+    producer, err := pubKey.Bytes()
+    if err != nil {
+        return err
+    }
+    b.producers[string(producer)] = true
+    return nil
+}
+
+// RemoveProducer stops a public key from producing blocks.
+func (b *Blockchain) RemoveProducer(pubKey crypto.PubKey) error {
+    producer, err := pubKey.Bytes()
+    if err != nil {
+        return err
+    }
+    delete(b.producers, string(producer))
+    return nil
+}
+
+// verifyProducer checks a block's producer signature, and that its producer is allowed and its timestamp doesn't go back in time.
+// Unsigned blocks are only accepted if no producers are allowed.
+func (b *Blockchain) verifyProducer(block Block) error {
+    signature := block.ProducerSignature()
+    if signature == nil {
+        if len(b.producers) > 0 {
+            return ErrUnsignedBlock
+        }
+        return nil
+    }
+    if err := VerifyBlockSignature(block); err != nil {
+        return err
+    }
+    if len(b.producers) > 0 && !b.producers[string(signature.Producer)] {
+        return ErrProducerNotAllowed
+    }
+    parent, err := b.blockStore.Get(block.PrevHash())
+    if err == nil && parent.ProducerSignature() != nil && signature.Timestamp < parent.ProducerSignature().Timestamp {
+        return ErrInvalidTimestamp
+    }
+    return nil
+}
@@ -0,0 +1,86 @@
+package lazyledger
+
+import (
+    "bytes"
+    "crypto/rand"
+    "testing"
+
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestBlockchainProducers(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms := NewSimpleMap()
+    app := NewDummyApp(ms)
+    b.RegisterApplication(&app)
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+
+    unsigned := NewSimpleBlock([]byte{0})
+    if err := b.ProcessBlock(unsigned); err != nil {
+        t.Fatalf("unsigned block rejected without allowed producers: %v", err)
+    }
+
+    b.AddProducer(pubA)
+    sb1 := NewSimpleBlock(unsigned.Digest())
+    sb1.AddMessage(app.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    if err := b.ProcessBlock(sb1); err != ErrUnsignedBlock {
+        t.Errorf("expected ErrUnsignedBlock, got %v", err)
+    }
+    SignBlock(sb1, privB, 10)
+    if err := b.ProcessBlock(sb1); err != ErrProducerNotAllowed {
+        t.Errorf("expected ErrProducerNotAllowed, got %v", err)
+    }
+    SignBlock(sb1, privA, 10)
+    if err := b.ProcessBlock(sb1); err != nil {
+        t.Fatalf("signed block rejected: %v", err)
+    }
+    if app.(*DummyApp).Get("foo") != "bar" {
+        t.Error("signed block not processed")
+    }
+
+    tampered := NewSimpleBlock(sb1.Digest())
+    SignBlock(tampered, privA, 20)
+    tampered.AddMessage(app.(*DummyApp).GenerateTransaction(map[string]string{"foo": "baz"}))
+    if err := b.ProcessBlock(tampered); err != ErrInvalidProducerSignature {
+        t.Errorf("expected ErrInvalidProducerSignature, got %v", err)
+    }
+
+    early := NewSimpleBlock(sb1.Digest())
+    SignBlock(early, privA, 5)
+    if err := b.ProcessBlock(early); err != ErrInvalidTimestamp {
+        t.Errorf("expected ErrInvalidTimestamp, got %v", err)
+    }
+
+    // A header imported with its producer signature has the same digest as the block.
+    header := ImportSimpleBlockHeader(sb1.PrevHash(), sb1.(*SimpleBlock).MessagesRoot(), sb1.(*SimpleBlock).NumMessages())
+    header.SetProducerSignature(sb1.ProducerSignature())
+    if bytes.Compare(header.Digest(), sb1.Digest()) != 0 || VerifyBlockSignature(header) != nil {
+        t.Error("imported header does not match signed block")
+    }
+
+    b.RemoveProducer(pubA)
+    if err := b.ProcessBlock(NewSimpleBlock(sb1.Digest())); err != nil {
+        t.Errorf("unsigned block rejected after removing producers: %v", err)
+    }
+}
+
+func TestSignProbabilisticBlock(t *testing.T) {
+    privA, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    pb := NewProbabilisticBlock([]byte{0}, 512)
+    pb.AddMessage(*NewMessage([namespaceSize]byte{1}, []byte("foo")))
+    unsignedDigest := pb.Digest()
+
+    if VerifyBlockSignature(pb) != ErrUnsignedBlock {
+        t.Error("unsigned block verified")
+    }
+    if SignBlock(pb, privA, 1) != nil || VerifyBlockSignature(pb) != nil {
+        t.Error("failed to sign probabilistic block")
+    }
+    if bytes.Compare(pb.Digest(), unsignedDigest) == 0 {
+        t.Error("digest does not commit to producer")
+    }
+}
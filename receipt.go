@@ -0,0 +1,137 @@
+package lazyledger
+
+import (
+    "fmt"
+    "strconv"
+)
+
+// ReceiptCode is the result code of processing a message.
+type ReceiptCode int
+
+const (
+    // ReceiptOK means that the message was applied.
+    ReceiptOK ReceiptCode = iota
+    // ReceiptInvalidMessage means that the message could not be decoded.
+    ReceiptInvalidMessage
+    // ReceiptInvalidSignature means that a signature on the message was missing or invalid.
+    ReceiptInvalidSignature
+    // ReceiptInvalidNonce means that the message was out of sequence for its sender.
+    ReceiptInvalidNonce
+    // ReceiptInsufficientBalance means that the sender could not pay for the message.
+    ReceiptInsufficientBalance
+    // ReceiptUnauthorized means that the sender is not allowed to perform the message's action.
+    ReceiptUnauthorized
+    // ReceiptUnprovenDependency means that a dependency of the message has not been proven.
+    ReceiptUnprovenDependency
+    // ReceiptRejected means that the message was rejected by an application-specific rule.
+    ReceiptRejected
+    // ReceiptStorageError means that the application's state could not be updated.
+    ReceiptStorageError
+    // ReceiptOutOfGas means that the application ran out of gas while processing the message.
+    ReceiptOutOfGas
+)
+
+var receiptCodeNames = map[ReceiptCode]string{
+    ReceiptOK: "ok",
+    ReceiptInvalidMessage: "invalid message",
+    ReceiptInvalidSignature: "invalid signature",
+    ReceiptInvalidNonce: "invalid nonce",
+    ReceiptInsufficientBalance: "insufficient balance",
+    ReceiptUnauthorized: "unauthorized",
+    ReceiptUnprovenDependency: "unproven dependency",
+    ReceiptRejected: "rejected",
+    ReceiptStorageError: "storage error",
+    ReceiptOutOfGas: "out of gas",
+}
+
+func (c ReceiptCode) String() string {
+    if name, ok := receiptCodeNames[c]; ok {
+        return name
+    }
+    return fmt.Sprintf("ReceiptCode(%d)", int(c))
+}
+
+// EventAttribute is a key-value pair attached to an event.
+type EventAttribute struct {
+    Key string
+    Value []byte
+}
+
+// Event is a typed notification emitted by an application while processing a message.
+type Event struct {
+    Type string
+    Attributes []EventAttribute
+}
+
+// NewEvent returns a new event of a type with alternating attribute keys and values.
+func NewEvent(eventType string, attributes ...interface{}) Event {
+    event := Event{Type: eventType}
+    for i := 0; i + 1 < len(attributes); i += 2 {
+        var value []byte
+        switch v := attributes[i + 1].(type) {
+        case []byte:
+            value = v
+        case string:
+            value = []byte(v)
+        default:
+            value = []byte(fmt.Sprint(v))
+        }
+        event.Attributes = append(event.Attributes, EventAttribute{Key: attributes[i].(string), Value: value})
+    }
+    return event
+}
+
+// Attribute returns the value of an event's attribute, or nil if the event doesn't have it.
+func (e Event) Attribute(key string) []byte {
+    for _, attribute := range e.Attributes {
+        if attribute.Key == key {
+            return attribute.Value
+        }
+    }
+    return nil
+}
+
+// Uint64Attribute returns the value of a numeric attribute of an event.
+func (e Event) Uint64Attribute(key string) (uint64, error) {
+    value := e.Attribute(key)
+    if value == nil {
+        return 0, fmt.Errorf("event has no attribute %s", key)
+    }
+    return strconv.ParseUint(string(value), 10, 64)
+}
+
+// Receipt is the outcome of processing a message.
+type Receipt struct {
+    MessageHash []byte
+    Code ReceiptCode
+    Reason string
+    Events []Event
+}
+
+// NewReceipt returns a new receipt for a message that was applied, with the events it emitted.
+func NewReceipt(events ...Event) *Receipt {
+    return &Receipt{
+        Code: ReceiptOK,
+        Events: events,
+    }
+}
+
+// NewFailureReceipt returns a new receipt for a message that was not applied.
+func NewFailureReceipt(code ReceiptCode, reason string) *Receipt {
+    return &Receipt{
+        Code: code,
+        Reason: reason,
+    }
+}
+
+// Success returns true if the message was applied.
+func (r *Receipt) Success() bool {
+    return r.Code == ReceiptOK
+}
+
+func (r *Receipt) String() string {
+    if r.Reason == "" {
+        return r.Code.String()
+    }
+    return fmt.Sprintf("%s: %s", r.Code, r.Reason)
+}
@@ -0,0 +1,117 @@
+package lazyledger
+
+import (
+    "errors"
+    "reflect"
+)
+
+// ErrApplicationRegistered is returned when registering an application that is already registered.
+var ErrApplicationRegistered = errors.New("application already registered")
+
+// ErrApplicationNotRegistered is returned when unregistering an application that isn't registered.
+var ErrApplicationNotRegistered = errors.New("application not registered")
+
+// ErrStateConflict is returned when registering an application that keeps its state in the same store as a registered one.
+// Their writes during a block would be batched separately and overwrite each other.
+var ErrStateConflict = errors.New("application state store already used by a registered application")
+
+// applicationRegistry holds the registered applications, indexed by namespace.
+// Several applications can share a namespace, in which case each of them processes the namespace's messages.
+type applicationRegistry struct {
+    applications []*registration
+    namespaces map[[namespaceSize]byte][]*Application
+    subscriptions map[Subscription][]*registration
+}
+
+// registration is an application along with the namespace it was registered under,
+// and its messages that are waiting for their dependencies to be proven.
+type registration struct {
+    application *Application
+    namespace [namespaceSize]byte
+    deferred []*pendingMessage
+}
+
+func newApplicationRegistry() *applicationRegistry {
+    return &applicationRegistry{
+        namespaces: make(map[[namespaceSize]byte][]*Application),
+        subscriptions: make(map[Subscription][]*registration),
+    }
+}
+
+// register adds an application under its current namespace.
+func (r *applicationRegistry) register(application *Application) error {
+    for _, registered := range r.applications {
+        if sameValue(*registered.application, *application) {
+            return ErrApplicationRegistered
+        }
+        sa1, ok1 := (*registered.application).(StatefulApplication)
+        sa2, ok2 := (*application).(StatefulApplication)
+        if ok1 && ok2 && sameValue(sa1.State(), sa2.State()) {
+            return ErrStateConflict
+        }
+    }
+    namespace := (*application).Namespace()
+    registered := &registration{application: application, namespace: namespace}
+    r.applications = append(r.applications, registered)
+    r.namespaces[namespace] = append(r.namespaces[namespace], application)
+    if consumer, ok := (*application).(EventConsumer); ok {
+        for _, subscription := range consumer.Subscriptions() {
+            r.subscriptions[subscription] = append(r.subscriptions[subscription], registered)
+        }
+    }
+    return nil
+}
+
+// unregister removes an application.
+func (r *applicationRegistry) unregister(application *Application) error {
+    for i, registered := range r.applications {
+        if !sameValue(*registered.application, *application) {
+            continue
+        }
+        r.applications = append(r.applications[:i:i], r.applications[i + 1:]...)
+        var applications []*Application
+        for _, a := range r.namespaces[registered.namespace] {
+            if a != registered.application {
+                applications = append(applications, a)
+            }
+        }
+        if len(applications) == 0 {
+            delete(r.namespaces, registered.namespace)
+        } else {
+            r.namespaces[registered.namespace] = applications
+        }
+        for subscription, subscribers := range r.subscriptions {
+            var remaining []*registration
+            for _, subscriber := range subscribers {
+                if subscriber != registered {
+                    remaining = append(remaining, subscriber)
+                }
+            }
+            if len(remaining) == 0 {
+                delete(r.subscriptions, subscription)
+            } else {
+                r.subscriptions[subscription] = remaining
+            }
+        }
+        return nil
+    }
+    return ErrApplicationNotRegistered
+}
+
+// lookup returns the applications registered under a namespace, in the order they were registered.
+func (r *applicationRegistry) lookup(namespace [namespaceSize]byte) []*Application {
+    return r.namespaces[namespace]
+}
+
+// subscribers returns the applications subscribed to a type of event of a namespace, in the order they were registered.
+func (r *applicationRegistry) subscribers(subscription Subscription) []*registration {
+    return r.subscriptions[subscription]
+}
+
+// sameValue reports whether two interface values hold the same comparable value, such as the same pointer.
+func sameValue(a interface{}, b interface{}) bool {
+    if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+        return false
+    }
+    return a == b
+}
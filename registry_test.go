@@ -0,0 +1,102 @@
+package lazyledger
+
+import (
+    "testing"
+)
+
+func TestApplicationRegistry(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    ms1 := NewSimpleMap()
+    app1 := NewDummyApp(ms1)
+    ms2 := NewSimpleMap()
+    app2 := NewDummyApp(ms2)
+    if b.RegisterApplication(&app1) != nil || b.RegisterApplication(&app2) != nil {
+        t.Fatal("failed to register two applications in the same namespace")
+    }
+    if len(b.Applications(app1.Namespace())) != 2 {
+        t.Error("wrong number of applications in namespace")
+    }
+
+    duplicate := app1
+    if b.RegisterApplication(&duplicate) != ErrApplicationRegistered {
+        t.Error("registered the same application twice")
+    }
+    sharedState := NewDummyApp(ms1)
+    if b.RegisterApplication(&sharedState) != ErrStateConflict {
+        t.Error("registered two applications with the same state store")
+    }
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(app1.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    b.ProcessBlock(sb)
+
+    if app1.(*DummyApp).Get("foo") != "bar" || app2.(*DummyApp).Get("foo") != "bar" {
+        t.Error("message not processed by every application in its namespace")
+    }
+
+    if b.UnregisterApplication(&app2) != nil {
+        t.Fatal("failed to unregister application")
+    }
+    if b.UnregisterApplication(&app2) != ErrApplicationNotRegistered {
+        t.Error("unregistered an application that isn't registered")
+    }
+
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(app1.(*DummyApp).GenerateTransaction(map[string]string{"foo": "baz"}))
+    b.ProcessBlock(sb)
+
+    if app1.(*DummyApp).Get("foo") != "baz" || app2.(*DummyApp).Get("foo") != "bar" {
+        t.Error("unregistered application still processes messages")
+    }
+    if len(b.BlockReceipts(sb.Digest())) != 1 {
+        t.Error("wrong number of receipts after unregistering")
+    }
+}
+
+func TestBlockchainCommitRollback(t *testing.T) {
+    b := NewBlockchain(NewSimpleBlockStore())
+
+    ms := NewSimpleMap()
+    app1 := NewDummyApp(ms)
+    fm := &failingMap{sm: NewSimpleMap(), failingKey: dummyKey("foo")}
+    app2 := NewDummyApp(fm)
+    b.RegisterApplication(&app1)
+    b.RegisterApplication(&app2)
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(app1.(*DummyApp).GenerateTransaction(map[string]string{"goo": "tar"}))
+    if err := b.ProcessBlock(sb); err != nil {
+        t.Fatal(err)
+    }
+
+    // The first application's batch is committed before the second's fails, and must be rolled back.
+    head := sb.Digest()
+    sb = NewSimpleBlock(sb.Digest())
+    sb.AddMessage(app1.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar", "goo": "baz"}))
+    if err := b.ProcessBlock(sb); err == nil {
+        t.Fatal("block processed although a store failed to commit")
+    }
+    if app1.(*DummyApp).Get("foo") != "" || app1.(*DummyApp).Get("goo") != "tar" || string(app1.BlockHead()) != string(head) {
+        t.Error("writes of a block that failed to commit were not rolled back")
+    }
+    if app2.(*DummyApp).Get("goo") != "tar" {
+        t.Error("failing store was partially written")
+    }
+
+    // The block is neither stored nor the head, so it can be processed again once the store recovers.
+    if _, err := b.Block(sb.Digest()); err == nil {
+        t.Error("block that failed to commit was stored")
+    }
+    if _, err := b.Height(sb.Digest()); err != ErrBlockNotProcessed {
+        t.Error("block that failed to commit has a height")
+    }
+    fm.failingKey = nil
+    if err := b.ProcessBlock(sb); err != nil {
+        t.Fatal(err)
+    }
+    if app2.(*DummyApp).Get("foo") != "bar" || string(app1.BlockHead()) != string(sb.Digest()) || string(app2.BlockHead()) != string(sb.Digest()) {
+        t.Error("block not processed as the head after the store recovered")
+    }
+}
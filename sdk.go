@@ -0,0 +1,226 @@
+package lazyledger
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "reflect"
+
+    "github.com/golang/protobuf/proto"
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ErrHandlerRegistered is returned when a handler is registered for a transaction type that already has one.
+var ErrHandlerRegistered = errors.New("handler already registered for transaction type")
+
+// TransactionHandler processes a decoded transaction of the type it was registered for.
+type TransactionHandler = func(transaction proto.Message) *Receipt
+
+// BaseApplication implements the plumbing that every stateful application needs: its namespace,
+// head tracking, typed state accessors, namespace-bound signatures and routing of typed transactions to handlers.
+// Embed it in an application and register a handler for each transaction type the application accepts.
+type BaseApplication struct {
+    namespace [namespaceSize]byte
+    state MapStore
+    handlers map[string]handlerRoute
+}
+
+type handlerRoute struct {
+    transactionType reflect.Type
+    handler TransactionHandler
+}
+
+// NewBaseApplication creates a base application for a namespace, keeping its state in a MapStore.
+func NewBaseApplication(namespace [namespaceSize]byte, state MapStore) *BaseApplication {
+    return &BaseApplication{
+        namespace: namespace,
+        state: state,
+        handlers: make(map[string]handlerRoute),
+    }
+}
+
+// Handle registers a handler for transactions of the same proto type as prototype.
+func (app *BaseApplication) Handle(prototype proto.Message, handler TransactionHandler) error {
+    name := proto.MessageName(prototype)
+    if _, ok := app.handlers[name]; ok {
+        return ErrHandlerRegistered
+    }
+    app.handlers[name] = handlerRoute{
+        transactionType: reflect.TypeOf(prototype).Elem(),
+        handler: handler,
+    }
+    return nil
+}
+
+// ProcessMessage decodes a typed transaction and passes it to the handler registered for its type.
+func (app *BaseApplication) ProcessMessage(message Message) *Receipt {
+    typed := &TypedTransaction{}
+    err := proto.Unmarshal(message.Data(), typed)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    route, ok := app.handlers[typed.GetType()]
+    if !ok {
+        return NewFailureReceipt(ReceiptInvalidMessage, fmt.Sprintf("no handler for transaction type %s", typed.GetType()))
+    }
+    transaction := reflect.New(route.transactionType).Interface().(proto.Message)
+    err = proto.Unmarshal(typed.Data, transaction)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    receipt := route.handler(transaction)
+    if receipt == nil {
+        return NewReceipt()
+    }
+    return receipt
+}
+
+// GenerateMessage wraps a transaction in a message that routes it to the handler for its type.
+func (app *BaseApplication) GenerateMessage(transaction proto.Message) Message {
+    data, _ := proto.Marshal(transaction)
+    typed := &TypedTransaction{
+        Type: proto.String(proto.MessageName(transaction)),
+        Data: data,
+    }
+    typedData, _ := proto.Marshal(typed)
+    return *NewMessage(app.namespace, typedData)
+}
+
+func (app *BaseApplication) Namespace() [namespaceSize]byte {
+    return app.namespace
+}
+
+func (app *BaseApplication) SetBlockHead(hash []byte) {
+    app.state.Put([]byte("__head__"), hash)
+}
+
+func (app *BaseApplication) BlockHead() []byte {
+    head, _ := app.state.Get([]byte("__head__"))
+    return head
+}
+
+func (app *BaseApplication) State() MapStore {
+    return app.state
+}
+
+func (app *BaseApplication) SetState(state MapStore) {
+    app.state = state
+}
+
+func (app *BaseApplication) StorageSize() int {
+    return app.state.storageSize()
+}
+
+// GetUint64 gets a uint64 value, or 0 if the key isn't set.
+func (app *BaseApplication) GetUint64(key []byte) uint64 {
+    value, err := app.state.Get(key)
+    if err != nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(value)
+}
+
+// PutUint64 sets a uint64 value.
+func (app *BaseApplication) PutUint64(key []byte, value uint64) error {
+    valueBytes := make([]byte, binary.MaxVarintLen64)
+    binary.BigEndian.PutUint64(valueBytes, value)
+    return app.state.Put(key, valueBytes)
+}
+
+// GetBytes gets a value, or nil if the key isn't set.
+func (app *BaseApplication) GetBytes(key []byte) []byte {
+    value, err := app.state.Get(key)
+    if err != nil {
+        return nil
+    }
+    return value
+}
+
+// PutBytes sets a value.
+func (app *BaseApplication) PutBytes(key []byte, value []byte) error {
+    return app.state.Put(key, value)
+}
+
+// GetProto decodes a stored proto message into value. It returns false if the key isn't set.
+func (app *BaseApplication) GetProto(key []byte, value proto.Message) (bool, error) {
+    data, err := app.state.Get(key)
+    if err != nil {
+        return false, nil
+    }
+    return true, proto.Unmarshal(data, value)
+}
+
+// PutProto stores a proto message.
+func (app *BaseApplication) PutProto(key []byte, value proto.Message) error {
+    data, err := proto.Marshal(value)
+    if err != nil {
+        return err
+    }
+    return app.state.Put(key, data)
+}
+
+// Delete deletes a key; deleting a key that isn't set is not an error.
+func (app *BaseApplication) Delete(key []byte) error {
+    if _, err := app.state.Get(key); err != nil {
+        return nil
+    }
+    return app.state.Del(key)
+}
+
+// SignedData returns the bytes that are signed for a transaction's signed fields.
+// They are prefixed with the namespace, so a signature can't be replayed to another application.
+func (app *BaseApplication) SignedData(signed proto.Message) ([]byte, error) {
+    data, err := proto.Marshal(signed)
+    if err != nil {
+        return nil, err
+    }
+    return append(append([]byte(nil), app.namespace[:]...), data...), nil
+}
+
+// Sign signs a transaction's signed fields for the application's namespace.
+func (app *BaseApplication) Sign(privKey crypto.PrivKey, signed proto.Message) ([]byte, error) {
+    signedData, err := app.SignedData(signed)
+    if err != nil {
+        return nil, err
+    }
+    return privKey.Sign(signedData)
+}
+
+// VerifySignature checks that a signature over a transaction's signed fields was made by an address.
+// The address's public key must either be known already or revealed by the transaction; a revealed key is stored.
+// It returns nil if the signature is valid, or else a failure receipt.
+func (app *BaseApplication) VerifySignature(address []byte, revealed []byte, signed proto.Message, signature []byte) *Receipt {
+    known := app.PublicKey(address)
+    key, err := keyForAddress(address, known, revealed)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, err.Error())
+    }
+    signedData, err := app.SignedData(signed)
+    if err != nil {
+        return NewFailureReceipt(ReceiptInvalidMessage, err.Error())
+    }
+    ok, err := key.Verify(signedData, signature)
+    if !ok || err != nil {
+        return NewFailureReceipt(ReceiptInvalidSignature, "signature does not match signer")
+    }
+    if known == nil {
+        err := app.state.Put(append([]byte("pubkey__"), address...), revealed)
+        if err != nil {
+            return NewFailureReceipt(ReceiptStorageError, err.Error())
+        }
+    }
+    return nil
+}
+
+// PublicKey returns the public key revealed for an address, or nil if it hasn't been revealed.
+func (app *BaseApplication) PublicKey(address []byte) crypto.PubKey {
+    pubKeyBytes, err := app.state.Get(append([]byte("pubkey__"), address...))
+    if err != nil {
+        return nil
+    }
+    pubKey, err := crypto.UnmarshalPublicKey(pubKeyBytes)
+    if err != nil {
+        return nil
+    }
+    return pubKey
+}
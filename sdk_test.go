@@ -0,0 +1,151 @@
+package lazyledger
+
+import (
+    "crypto/rand"
+    "testing"
+
+    "github.com/golang/protobuf/proto"
+    "github.com/libp2p/go-libp2p-crypto"
+)
+
+// sdkCounter is a minimal application built on BaseApplication: signed withdrawals add to a per-address counter.
+type sdkCounter struct {
+    *BaseApplication
+}
+
+func newSDKCounter(state MapStore) Application {
+    var namespace [namespaceSize]byte
+    copy(namespace[:], []byte("counter"))
+    app := &sdkCounter{NewBaseApplication(namespace, state)}
+    app.Handle(&WithdrawTransaction{}, app.processWithdraw)
+    return app
+}
+
+func (app *sdkCounter) processWithdraw(transaction proto.Message) *Receipt {
+    withdraw := transaction.(*WithdrawTransaction)
+    signed := &WithdrawTransactionMessage{
+        Amount: withdraw.Amount,
+        Nonce: withdraw.Nonce,
+    }
+    if receipt := app.VerifySignature(withdraw.Address, withdraw.PublicKey, signed, withdraw.Signature); receipt != nil {
+        return receipt
+    }
+    nonceKey := append([]byte("nonce__"), withdraw.Address...)
+    if app.GetUint64(nonceKey) != withdraw.GetNonce() {
+        return NewFailureReceipt(ReceiptInvalidNonce, "wrong nonce")
+    }
+    counterKey := append([]byte("counter__"), withdraw.Address...)
+    app.PutUint64(nonceKey, withdraw.GetNonce() + 1)
+    app.PutUint64(counterKey, app.GetUint64(counterKey) + withdraw.GetAmount())
+    return nil
+}
+
+func (app *sdkCounter) generateWithdraw(privKey crypto.PrivKey, amount uint64, nonce uint64) Message {
+    pubKeyBytes, _ := privKey.GetPublic().Bytes()
+    signature, _ := app.Sign(privKey, &WithdrawTransactionMessage{
+        Amount: &amount,
+        Nonce: &nonce,
+    })
+    return app.GenerateMessage(&WithdrawTransaction{
+        Address: Address(privKey.GetPublic()),
+        Amount: &amount,
+        Nonce: &nonce,
+        Signature: signature,
+        PublicKey: pubKeyBytes,
+    })
+}
+
+func TestBaseApplication(t *testing.T) {
+    bs := NewSimpleBlockStore()
+    b := NewBlockchain(bs)
+
+    app := newSDKCounter(NewSimpleMap())
+    b.RegisterApplication(&app)
+    counter := app.(*sdkCounter)
+
+    if counter.Handle(&WithdrawTransaction{}, counter.processWithdraw) != ErrHandlerRegistered {
+        t.Error("registered two handlers for the same transaction type")
+    }
+
+    privA, pubA, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    privB, _, _ := crypto.GenerateSecp256k1Key(rand.Reader)
+    valid := counter.generateWithdraw(privA, 5, 0)
+    replayed := counter.generateWithdraw(privA, 5, 0)
+    forged := &WithdrawTransaction{}
+    typed := &TypedTransaction{}
+    forgedMessage := counter.generateWithdraw(privB, 7, 0)
+    proto.Unmarshal(forgedMessage.Data(), typed)
+    proto.Unmarshal(typed.Data, forged)
+    forged.Address = Address(pubA)
+    unrouted, _ := proto.Marshal(&TypedTransaction{Type: proto.String("lazyledger.Unknown"), Data: []byte{}})
+
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(valid)
+    sb.AddMessage(replayed)
+    sb.AddMessage(counter.GenerateMessage(forged))
+    sb.AddMessage(*NewMessage(counter.Namespace(), unrouted))
+    b.ProcessBlock(sb)
+
+    if counter.GetUint64(append([]byte("counter__"), Address(pubA)...)) != 5 {
+        t.Error("counter has wrong value")
+    }
+    if counter.PublicKey(Address(pubA)) == nil {
+        t.Error("revealed public key not stored")
+    }
+    if string(counter.BlockHead()) != string(sb.Digest()) {
+        t.Error("block head not tracked")
+    }
+    expected := []ReceiptCode{ReceiptOK, ReceiptInvalidNonce, ReceiptInvalidSignature, ReceiptInvalidMessage}
+    receipts := b.BlockReceipts(sb.Digest())
+    if len(receipts) != len(expected) {
+        t.Fatalf("expected %d receipts, got %d", len(expected), len(receipts))
+    }
+    for i, receipt := range receipts {
+        if receipt.Code != expected[i] {
+            t.Errorf("receipt %d: expected %s, got %s", i, expected[i], receipt.Code)
+        }
+    }
+
+    // A signature made for another namespace must not verify.
+    var otherNamespace [namespaceSize]byte
+    copy(otherNamespace[:], []byte("other"))
+    other := NewBaseApplication(otherNamespace, NewSimpleMap())
+    amount, nonce := uint64(1), uint64(1)
+    signed := &WithdrawTransactionMessage{Amount: &amount, Nonce: &nonce}
+    signature, _ := other.Sign(privA, signed)
+    if counter.VerifySignature(Address(pubA), nil, signed, signature) == nil {
+        t.Error("signature verified across namespaces")
+    }
+}
+
+func TestBaseApplicationState(t *testing.T) {
+    var namespace [namespaceSize]byte
+    app := NewBaseApplication(namespace, NewSimpleMap())
+
+    if app.GetUint64([]byte("missing")) != 0 || app.GetBytes([]byte("missing")) != nil {
+        t.Error("missing keys should read as zero values")
+    }
+    app.PutUint64([]byte("n"), 42)
+    if app.GetUint64([]byte("n")) != 42 {
+        t.Error("wrong uint64 value")
+    }
+    app.PutBytes([]byte("b"), []byte("bar"))
+    if string(app.GetBytes([]byte("b"))) != "bar" {
+        t.Error("wrong bytes value")
+    }
+
+    record := &TypedRecord{Type: RecordType_TEXT.Enum(), Value: []byte("hello")}
+    app.PutProto([]byte("p"), record)
+    decoded := &TypedRecord{}
+    found, err := app.GetProto([]byte("p"), decoded)
+    if !found || err != nil || decoded.GetType() != RecordType_TEXT || string(decoded.Value) != "hello" {
+        t.Error("wrong proto value")
+    }
+
+    if app.Delete([]byte("p")) != nil || app.Delete([]byte("p")) != nil {
+        t.Error("delete failed")
+    }
+    if found, _ := app.GetProto([]byte("p"), decoded); found {
+        t.Error("deleted key still set")
+    }
+}
@@ -3,6 +3,7 @@ package lazyledger
 import (
     "bytes"
     "crypto/sha256"
+    "encoding/binary"
 
     "gitlab.com/NebulousLabs/merkletree"
 )
@@ -12,7 +13,9 @@ type SimpleBlock struct {
     prevHash []byte
     messages []Message
     messagesRoot []byte
+    numMessages int
     provenDependencies map[string]bool
+    producerSignature *ProducerSignature
 }
 
 // NewSimpleBlock returns a new simple block.
@@ -24,10 +27,11 @@ func NewSimpleBlock(prevHash []byte) Block {
 }
 
 // ImportSimpleBlockHeader imports a received simple block without the messages.
-func ImportSimpleBlockHeader(prevHash []byte, messagesRoot []byte) Block {
+func ImportSimpleBlockHeader(prevHash []byte, messagesRoot []byte, numMessages int) Block {
     return &SimpleBlock{
         prevHash: prevHash,
         messagesRoot: messagesRoot,
+        numMessages: numMessages,
         provenDependencies: make(map[string]bool),
     }
 }
@@ -37,6 +41,7 @@ func ImportSimpleBlock(prevHash []byte, messages []Message) Block {
     return &SimpleBlock{
         prevHash: prevHash,
         messages: messages,
+        numMessages: len(messages),
         provenDependencies: make(map[string]bool),
     }
 }
@@ -44,6 +49,7 @@ func ImportSimpleBlock(prevHash []byte, messages []Message) Block {
 // AddMessage adds a message to the block.
 func (sb *SimpleBlock) AddMessage(message Message) {
     sb.messages = append(sb.messages, message)
+    sb.numMessages++
 
     // Force recompututation of messagesRoot
     sb.messagesRoot = nil
@@ -64,14 +70,37 @@ func (sb *SimpleBlock) MessagesRoot() []byte {
     return sb.messagesRoot
 }
 
+// NumMessages returns the number of messages in the block, which its digest commits to.
+func (sb *SimpleBlock) NumMessages() int {
+    return sb.numMessages
+}
+
 // Digest computes the hash of the block.
 func (sb *SimpleBlock) Digest() []byte {
     hasher := sha256.New()
     hasher.Write(sb.prevHash)
     hasher.Write(sb.MessagesRoot())
+    // The number of messages fixes the shape of the tree, so that an inner node can't be proven as a message.
+    numMessagesBytes := make([]byte, 8)
+    binary.BigEndian.PutUint64(numMessagesBytes, uint64(sb.numMessages))
+    hasher.Write(numMessagesBytes)
+    // Unsigned blocks hash as they did before blocks had producers.
+    if sb.producerSignature != nil {
+        hasher.Write(sb.producerSignature.headerBytes())
+    }
     return hasher.Sum(nil)
 }
 
+// ProducerSignature returns the signature of the block's producer, or nil if the block is unsigned.
+func (sb *SimpleBlock) ProducerSignature() *ProducerSignature {
+    return sb.producerSignature
+}
+
+// SetProducerSignature sets the signature of the block's producer.
+func (sb *SimpleBlock) SetProducerSignature(signature *ProducerSignature) {
+    sb.producerSignature = signature
+}
+
 // Valid returns true if the block is valid.
 func (sb *SimpleBlock) Valid() bool {
     if sb.messages == nil {
@@ -137,6 +166,17 @@ func (sb *SimpleBlock) ApplicationProof(namespace [namespaceSize]byte) (int, int
         }
     }
 
+    if !found && !inRange && len(sb.messages) > 0 {
+        // The namespace is outside the range of the block's namespaces, so its absence is proven by the first or last message.
+        inRange = true
+        firstNs := sb.messages[0].Namespace()
+        if bytes.Compare(namespace[:], firstNs[:]) < 0 {
+            proofStart, proofEnd = 0, 1
+        } else {
+            proofStart, proofEnd = len(sb.messages) - 1, len(sb.messages)
+        }
+    }
+
     ndf := NewNamespaceDummyFlagger()
     fh := NewFlagHasher(ndf, sha256.New())
     var proof [][]byte
@@ -161,6 +201,10 @@ func (sb *SimpleBlock) ApplicationProof(namespace [namespaceSize]byte) (int, int
 
 // VerifyApplicationProof verifies a Merkle proof for all of the messages in a block for an application namespace.
 func (sb *SimpleBlock) VerifyApplicationProof(namespace [namespaceSize]byte, proofStart int, proofEnd int, proof [][]byte, messages *[]Message, hashes [][]byte) bool {
+    if !hashesOutsideNamespace(namespace, hashes) {
+        return false
+    }
+
     // Verify Merkle proof
     ndf := NewNamespaceDummyFlagger()
     fh := NewFlagHasher(ndf, sha256.New())
@@ -220,6 +264,9 @@ func (sb *SimpleBlock) ProveDependency(index int) ([]byte, [][]byte, error) {
 }
 
 func (sb *SimpleBlock) VerifyDependency(index int, hash []byte, proof [][]byte) bool {
+    if index < 0 || index >= sb.numMessages {
+        return false
+    }
     ndf := NewNamespaceDummyFlagger()
     fh := NewFlagHasher(ndf, sha256.New())
     lh := NewHashLeafHasher([][]byte{hash})
@@ -231,9 +278,73 @@ func (sb *SimpleBlock) VerifyDependency(index int, hash []byte, proof [][]byte)
     return false
 }
 
+// ProveDependencies creates a Merkle multiproof for the messages at several indexes, and returns their hashes.
+func (sb *SimpleBlock) ProveDependencies(indexes []int) ([][]byte, *MultiDependencyProof, error) {
+    indexes = sortedIndexes(indexes)
+    if len(indexes) == 0 || !indexesValid(indexes, len(sb.messages)) {
+        return nil, nil, ErrInvalidIndexes
+    }
+    ndf := NewNamespaceDummyFlagger()
+    fh := NewFlagHasher(ndf, sha256.New())
+    leafHashes := make([][]byte, len(sb.messages))
+    for index, message := range sb.messages {
+        leafHashes[index] = leafSum(fh, message.Marshal())
+    }
+    var hashes [][]byte
+    for _, index := range indexes {
+        hashes = append(hashes, leafHashes[index])
+    }
+    return hashes, &MultiDependencyProof{
+        Indexes: indexes,
+        NumLeaves: len(sb.messages),
+        Proofs: [][][]byte{buildMultiproof(fh, leafHashes, indexes)},
+    }, nil
+}
+
+// VerifyDependencies verifies a Merkle multiproof for several messages, and records them as proven if it is valid.
+func (sb *SimpleBlock) VerifyDependencies(hashes [][]byte, proof *MultiDependencyProof) bool {
+    if len(proof.Proofs) != 1 || proof.NumLeaves != sb.numMessages {
+        return false
+    }
+    ndf := NewNamespaceDummyFlagger()
+    fh := NewFlagHasher(ndf, sha256.New())
+    if !verifyMultiproof(fh, sb.MessagesRoot(), proof.NumLeaves, proof.Indexes, hashes, proof.Proofs[0]) {
+        return false
+    }
+    for _, hash := range hashes {
+        sb.provenDependencies[string(hash)] = true
+    }
+    return true
+}
+
 func (sb *SimpleBlock) DependencyProven(hash []byte) bool {
     if value, ok := sb.provenDependencies[string(hash)]; ok {
         return value
     }
     return false
 }
+
+// ProveApplication creates an ApplicationProof for all of the messages in a block for an application namespace.
+func (sb *SimpleBlock) ProveApplication(namespace [namespaceSize]byte) *ApplicationProof {
+    proofStart, proofEnd, proof, messages, hashes := sb.ApplicationProof(namespace)
+    return &ApplicationProof{
+        Namespace: namespace,
+        ProofStart: proofStart,
+        ProofEnd: proofEnd,
+        Proofs: [][][]byte{proof},
+        Messages: messages,
+        Hashes: hashes,
+    }
+}
+
+// VerifyApplication verifies an ApplicationProof against the block's messages root.
+func (sb *SimpleBlock) VerifyApplication(proof *ApplicationProof) bool {
+    if sb.MessagesRoot() == nil {
+        // The block is empty.
+        return proof.ProofStart == proof.ProofEnd && proof.Messages == nil
+    }
+    if !proof.proofRangeValid() || len(proof.Proofs) != 1 {
+        return false
+    }
+    return sb.VerifyApplicationProof(proof.Namespace, proof.ProofStart, proof.ProofEnd, proof.Proofs[0], proof.Messages, proof.Hashes)
+}
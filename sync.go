@@ -0,0 +1,101 @@
+package lazyledger
+
+import (
+    "bytes"
+    "errors"
+)
+
+// ErrLazySyncUnsupported is returned when syncing a block header of a type that can't prove the messages of a namespace.
+var ErrLazySyncUnsupported = errors.New("block type does not support application proofs")
+
+// ErrInvalidApplicationProof is returned when a provider's proof of the messages of a namespace does not verify against a block header.
+var ErrInvalidApplicationProof = errors.New("invalid application proof")
+
+// ApplicationProof proves that a range of a block's messages contains all of the messages of a namespace.
+// If the namespace has no messages in the block, the range holds the hashes of the messages around where they would be.
+// The range is of message indexes, as used by dependency references; in a ProbabilisticBlock, message i is the i-th share
+// of the original data square in row-major order, and the proofs are of the rows of the original square that the range spans.
+type ApplicationProof struct {
+    Namespace [namespaceSize]byte
+    ProofStart int
+    ProofEnd int
+    Proofs [][][]byte // One range proof per row the messages span; a SimpleBlock has a single row.
+    Messages *[]Message
+    Hashes [][]byte
+}
+
+// NamespaceMessages returns the messages of the proof's namespace.
+func (ap *ApplicationProof) NamespaceMessages() []Message {
+    var messages []Message
+    if ap.Messages == nil {
+        return nil
+    }
+    for _, message := range *ap.Messages {
+        if message.Namespace() == ap.Namespace {
+            messages = append(messages, message)
+        }
+    }
+    return messages
+}
+
+// ApplicationProver is implemented by blocks that can prove the messages of a namespace,
+// and verify such proofs against their header alone.
+type ApplicationProver interface {
+    ProveApplication(namespace [namespaceSize]byte) *ApplicationProof
+    VerifyApplication(proof *ApplicationProof) bool
+}
+
+// ApplicationProofProvider provides the proven messages of a namespace in a block, such as a full node that stores whole blocks.
+type ApplicationProofProvider interface {
+    ApplicationProof(digest []byte, namespace [namespaceSize]byte) (*ApplicationProof, error)
+}
+
+// BlockStoreProofProvider provides application proofs from the whole blocks in a BlockStore.
+type BlockStoreProofProvider struct {
+    blockStore BlockStore
+}
+
+// NewBlockStoreProofProvider returns a new provider of application proofs for the blocks in a store.
+func NewBlockStoreProofProvider(blockStore BlockStore) *BlockStoreProofProvider {
+    return &BlockStoreProofProvider{
+        blockStore: blockStore,
+    }
+}
+
+// ApplicationProof returns the proof of the messages of a namespace in the block with a digest.
+func (p *BlockStoreProofProvider) ApplicationProof(digest []byte, namespace [namespaceSize]byte) (*ApplicationProof, error) {
+    block, err := p.blockStore.Get(digest)
+    if err != nil {
+        return nil, err
+    }
+    prover, ok := block.(ApplicationProver)
+    if !ok {
+        return nil, ErrLazySyncUnsupported
+    }
+    return prover.ProveApplication(namespace), nil
+}
+
+// proofRangeValid checks that a proof covers a non-empty range with one message or hash per index.
+func (ap *ApplicationProof) proofRangeValid() bool {
+    if ap.ProofStart < 0 || ap.ProofEnd <= ap.ProofStart {
+        return false
+    }
+    if ap.Messages != nil {
+        return len(*ap.Messages) == ap.ProofEnd - ap.ProofStart
+    }
+    return len(ap.Hashes) == ap.ProofEnd - ap.ProofStart
+}
+
+// hashesOutsideNamespace checks that none of the leaf hashes given to prove the absence of a namespace are flagged with it.
+func hashesOutsideNamespace(namespace [namespaceSize]byte, hashes [][]byte) bool {
+    for _, hash := range hashes {
+        if len(hash) < flagSize {
+            return false
+        }
+        min, max := dummyNamespacesFromFlag(hash)
+        if bytes.Compare(min, namespace[:]) <= 0 && bytes.Compare(namespace[:], max) <= 0 {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,167 @@
+package lazyledger
+
+import (
+    "crypto/sha256"
+    "testing"
+)
+
+// tamperingProofProvider modifies the proofs of another provider.
+type tamperingProofProvider struct {
+    provider ApplicationProofProvider
+    tamper func(proof *ApplicationProof)
+}
+
+func (p *tamperingProofProvider) ApplicationProof(digest []byte, namespace [namespaceSize]byte) (*ApplicationProof, error) {
+    proof, err := p.provider.ApplicationProof(digest, namespace)
+    if err != nil {
+        return nil, err
+    }
+    p.tamper(proof)
+    return proof, nil
+}
+
+func TestSyncBlockHeader(t *testing.T) {
+    // A full node processes whole blocks.
+    fullBlockStore := NewSimpleBlockStore()
+    full := NewBlockchain(fullBlockStore)
+    fullDummyApp := NewDummyApp(NewSimpleMap())
+    full.RegisterApplication(&fullDummyApp)
+
+    otherNamespace := [namespaceSize]byte{'o', 't', 'h', 'e', 'r'}
+    sb := NewSimpleBlock([]byte{0})
+    sb.AddMessage(*NewMessage([namespaceSize]byte{'a'}, []byte("foo")))
+    sb.AddMessage(fullDummyApp.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    sb.AddMessage(fullDummyApp.(*DummyApp).GenerateOperationsTransaction(NewPutOperation("goo", "tar")))
+    sb.AddMessage(*NewMessage(otherNamespace, []byte("foo")))
+    full.ProcessBlock(sb)
+    provider := NewBlockStoreProofProvider(fullBlockStore)
+
+    // A light node only syncs the namespaces of its applications, including ones that are absent from the block.
+    light := NewBlockchain(NewSimpleBlockStore())
+    dummyApp := NewDummyApp(NewSimpleMap())
+    light.RegisterApplication(&dummyApp)
+    for _, namespace := range []string{"0", "b", "pet", "zzz"} {
+        petitionApp := NewPetitionApp(NewSimpleMap())
+        var ns [namespaceSize]byte
+        copy(ns[:], namespace)
+        petitionApp.(*PetitionApp).SetNamespace(ns)
+        light.RegisterApplication(&petitionApp)
+    }
+
+    header := ImportSimpleBlockHeader(sb.PrevHash(), sb.(*SimpleBlock).MessagesRoot(), sb.(*SimpleBlock).NumMessages())
+
+    dropMessage := &tamperingProofProvider{provider: provider, tamper: func(proof *ApplicationProof) {
+        if proof.Messages != nil {
+            messages := (*proof.Messages)[1:]
+            proof.Messages = &messages
+            proof.ProofStart += 1
+        }
+    }}
+    if light.SyncBlockHeader(header, dropMessage) != ErrInvalidApplicationProof {
+        t.Error("synced a proof with a missing message")
+    }
+    hideNamespace := &tamperingProofProvider{provider: provider, tamper: func(proof *ApplicationProof) {
+        if proof.Messages != nil {
+            for _, message := range *proof.Messages {
+                proof.Hashes = append(proof.Hashes, leafSum(NewFlagHasher(NewNamespaceDummyFlagger(), sha256.New()), message.Marshal()))
+            }
+            proof.Messages = nil
+        }
+    }}
+    if light.SyncBlockHeader(header, hideNamespace) != ErrInvalidApplicationProof {
+        t.Error("synced a proof that hides the namespace's messages")
+    }
+    if dummyApp.(*DummyApp).Get("foo") != "" {
+        t.Error("state changed by a block that failed to sync")
+    }
+
+    err := light.SyncBlockHeader(header, provider)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if dummyApp.(*DummyApp).Get("foo") != "bar" || dummyApp.(*DummyApp).Get("goo") != "tar" {
+        t.Error("synced application state does not match the full node's")
+    }
+    if len(light.BlockReceipts(header.Digest())) != 2 {
+        t.Error("wrong number of receipts for synced block")
+    }
+}
+
+func TestSyncProbabilisticBlockHeader(t *testing.T) {
+    fullBlockStore := NewSimpleBlockStore()
+    full := NewBlockchain(fullBlockStore)
+    fullDummyApp := NewDummyApp(NewSimpleMap())
+    full.RegisterApplication(&fullDummyApp)
+
+    pb := NewProbabilisticBlock([]byte{0}, 512)
+    pb.AddMessage(*NewMessage([namespaceSize]byte{'a'}, []byte("foo")))
+    pb.AddMessage(fullDummyApp.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    pb.AddMessage(*NewMessage([namespaceSize]byte{'z'}, []byte("foo")))
+    full.ProcessBlock(pb)
+
+    light := NewBlockchain(NewSimpleBlockStore())
+    dummyApp := NewDummyApp(NewSimpleMap())
+    light.RegisterApplication(&dummyApp)
+
+    header := ImportProbabilisticBlockHeader(pb.PrevHash(), pb.(*ProbabilisticBlock).RowRoots(), pb.(*ProbabilisticBlock).ColumnRoots(), pb.(*ProbabilisticBlock).SquareWidth(), 512, true)
+    err := light.SyncBlockHeader(header, NewBlockStoreProofProvider(fullBlockStore))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if dummyApp.(*DummyApp).Get("foo") != "bar" {
+        t.Error("synced application state does not match the full node's")
+    }
+}
+
+func TestSyncProbabilisticBlockIndexes(t *testing.T) {
+    fullBlockStore := NewSimpleBlockStore()
+    full := NewBlockchain(fullBlockStore)
+    fullDummyApp := NewDummyApp(NewSimpleMap())
+    full.RegisterApplication(&fullDummyApp)
+
+    // The dummy messages span two rows of the original square, which is 3 shares wide.
+    pb := NewProbabilisticBlock([]byte{0}, 512)
+    pb.AddMessage(*NewMessage([namespaceSize]byte{'a'}, []byte("foo")))
+    pb.AddMessage(*NewMessage([namespaceSize]byte{'a'}, []byte("bar")))
+    pb.AddMessage(fullDummyApp.(*DummyApp).GenerateTransaction(map[string]string{"foo": "bar"}))
+    pb.AddMessage(fullDummyApp.(*DummyApp).GenerateTransaction(map[string]string{"goo": "tar"}))
+    pb.AddMessage(*NewMessage([namespaceSize]byte{'z'}, []byte("foo")))
+    full.ProcessBlock(pb)
+    provider := NewBlockStoreProofProvider(fullBlockStore)
+
+    light := NewBlockchain(NewSimpleBlockStore())
+    light.SetDependencyProvider(provider)
+    dummyApp := NewDummyApp(NewSimpleMap())
+    light.RegisterApplication(&dummyApp)
+    consumer := &putLogApp{PetitionApp: NewPetitionApp(NewSimpleMap()).(*PetitionApp)}
+    var consumerApp Application = consumer
+    light.RegisterApplication(&consumerApp)
+
+    header := ImportProbabilisticBlockHeader(pb.PrevHash(), pb.(*ProbabilisticBlock).RowRoots(), pb.(*ProbabilisticBlock).ColumnRoots(), pb.(*ProbabilisticBlock).SquareWidth(), 512, true)
+
+    // A range past the end of the original square has no rows to prove it against.
+    outOfSquare := &tamperingProofProvider{provider: provider, tamper: func(proof *ApplicationProof) {
+        proof.ProofStart += 9
+        proof.ProofEnd += 9
+        proof.Proofs = nil
+    }}
+    if light.SyncBlockHeader(header, outOfSquare) != ErrInvalidApplicationProof {
+        t.Error("synced a proof of messages outside the original square")
+    }
+
+    err := light.SyncBlockHeader(header, provider)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(consumer.events) != 2 {
+        t.Fatalf("consumed %d events, want 2", len(consumer.events))
+    }
+    for i, event := range consumer.events {
+        if event.Source.Index != 2 + i {
+            t.Errorf("event %d refers to message %d, want %d", i, event.Source.Index, 2 + i)
+        }
+        if !light.VerifyEvent(event) {
+            t.Errorf("event %d failed to verify", i)
+        }
+    }
+}
@@ -0,0 +1,744 @@
+package wasm
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "math/bits"
+)
+
+// ErrOutOfGas is returned when execution uses more gas than its limit.
+var ErrOutOfGas = errors.New("out of gas")
+
+// ErrExportNotFound is returned when calling a function that the module doesn't export.
+var ErrExportNotFound = errors.New("export not found")
+
+// ErrImportNotFound is returned when a module imports a function that the host doesn't provide.
+var ErrImportNotFound = errors.New("import not found")
+
+// Trap is returned when execution aborts, for example on an out of bounds memory access.
+type Trap struct {
+    Reason string
+}
+
+func (t *Trap) Error() string {
+    return "trap: " + t.Reason
+}
+
+// Gas costs. Every instruction costs instructionGas, and growing memory costs memoryPageGas per page.
+const (
+    instructionGas = 1
+    memoryPageGas = 1024
+)
+
+// Default limits used when a Config leaves them unset.
+const (
+    defaultMaxMemoryPages = 16
+    defaultMaxCallDepth = 256
+)
+
+// HostFunction is a function provided by the host, which a module can import.
+type HostFunction struct {
+    Type FunctionType
+    Call func(instance *Instance, args []uint64) ([]uint64, error)
+}
+
+// Imports maps module names and function names to host functions.
+type Imports map[string]map[string]HostFunction
+
+// Config bounds the resources that an instance can use.
+type Config struct {
+    GasLimit uint64
+    MaxMemoryPages uint32
+    MaxCallDepth int
+}
+
+// Instance is a module instantiated with its own memory, globals and gas meter.
+type Instance struct {
+    module *Module
+    host []HostFunction
+    memory []byte
+    maxMemoryPages uint32
+    globals []uint64
+    table []int64
+    gasLimit uint64
+    gasUsed uint64
+    maxCallDepth int
+    depth int
+}
+
+// Instantiate creates an instance of a module, resolving its imports and running its start function.
+func Instantiate(module *Module, imports Imports, config Config) (*Instance, error) {
+    i := &Instance{
+        module: module,
+        maxMemoryPages: config.MaxMemoryPages,
+        gasLimit: config.GasLimit,
+        maxCallDepth: config.MaxCallDepth,
+    }
+    if i.maxMemoryPages == 0 {
+        i.maxMemoryPages = defaultMaxMemoryPages
+    }
+    if i.maxCallDepth == 0 {
+        i.maxCallDepth = defaultMaxCallDepth
+    }
+    for _, imp := range module.Imports {
+        fn, ok := imports[imp.Module][imp.Name]
+        if !ok {
+            return nil, fmt.Errorf("%v: %s.%s", ErrImportNotFound, imp.Module, imp.Name)
+        }
+        if !sameType(fn.Type, module.Types[imp.Type]) {
+            return nil, fmt.Errorf("%v: %s.%s has the wrong type", ErrImportNotFound, imp.Module, imp.Name)
+        }
+        i.host = append(i.host, fn)
+    }
+    if module.Memory != nil {
+        if module.Memory.HasMax && module.Memory.Max < i.maxMemoryPages {
+            i.maxMemoryPages = module.Memory.Max
+        }
+        if module.Memory.Min > i.maxMemoryPages {
+            return nil, &Trap{"initial memory exceeds limit"}
+        }
+        i.memory = make([]byte, int(module.Memory.Min) * PageSize)
+    }
+    for _, global := range module.Globals {
+        i.globals = append(i.globals, global.Init)
+    }
+    if module.Table != nil {
+        if module.Table.Min > maxTableSize {
+            return nil, &Trap{"initial table exceeds limit"}
+        }
+        i.table = make([]int64, module.Table.Min)
+        for j := range i.table {
+            i.table[j] = -1
+        }
+    }
+    for _, e := range module.elements {
+        if uint64(e.offset) + uint64(len(e.functions)) > uint64(len(i.table)) {
+            return nil, &Trap{"element segment out of bounds"}
+        }
+        for j, index := range e.functions {
+            i.table[int(e.offset) + j] = int64(index)
+        }
+    }
+    for _, d := range module.data {
+        if uint64(d.offset) + uint64(len(d.data)) > uint64(len(i.memory)) {
+            return nil, &Trap{"data segment out of bounds"}
+        }
+        copy(i.memory[d.offset:], d.data)
+    }
+    if module.start != nil {
+        if _, err := i.invoke(*module.start, nil); err != nil {
+            return nil, err
+        }
+    }
+    return i, nil
+}
+
+// maxTableSize bounds the table that a module can declare.
+const maxTableSize = 65536
+
+// Call calls an exported function.
+func (i *Instance) Call(name string, args ...uint64) ([]uint64, error) {
+    export, ok := i.module.Exports[name]
+    if !ok || export.Kind != ExternalFunction {
+        return nil, ErrExportNotFound
+    }
+    if len(args) != len(i.module.functionType(export.Index).Params) {
+        return nil, &Trap{"wrong number of arguments"}
+    }
+    return i.invoke(export.Index, args)
+}
+
+// invoke calls a function, converting traps raised during execution into errors.
+func (i *Instance) invoke(index uint32, args []uint64) (results []uint64, err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            if e, ok := r.(error); ok && (e == ErrOutOfGas || isTrap(e)) {
+                results, err = nil, e
+                i.depth = 0
+                return
+            }
+            panic(r)
+        }
+    }()
+    return i.call(index, args), nil
+}
+
+func isTrap(err error) bool {
+    _, ok := err.(*Trap)
+    return ok
+}
+
+func trap(reason string) {
+    panic(&Trap{reason})
+}
+
+// UseGas charges gas, and fails if the limit is exceeded. Host functions use it to charge for their work.
+func (i *Instance) UseGas(gas uint64) error {
+    if i.gasUsed + gas < i.gasUsed || i.gasUsed + gas > i.gasLimit {
+        i.gasUsed = i.gasLimit
+        return ErrOutOfGas
+    }
+    i.gasUsed += gas
+    return nil
+}
+
+func (i *Instance) useGas(gas uint64) {
+    if err := i.UseGas(gas); err != nil {
+        panic(err)
+    }
+}
+
+// GasUsed returns the gas used by the instance so far.
+func (i *Instance) GasUsed() uint64 {
+    return i.gasUsed
+}
+
+// ReadMemory returns a copy of a range of linear memory.
+func (i *Instance) ReadMemory(ptr uint32, length uint32) ([]byte, error) {
+    if uint64(ptr) + uint64(length) > uint64(len(i.memory)) {
+        return nil, &Trap{"memory access out of bounds"}
+    }
+    return append([]byte(nil), i.memory[ptr:ptr + length]...), nil
+}
+
+// WriteMemory copies data into linear memory.
+func (i *Instance) WriteMemory(ptr uint32, data []byte) error {
+    if uint64(ptr) + uint64(len(data)) > uint64(len(i.memory)) {
+        return &Trap{"memory access out of bounds"}
+    }
+    copy(i.memory[ptr:], data)
+    return nil
+}
+
+func sameType(a FunctionType, b FunctionType) bool {
+    if len(a.Params) != len(b.Params) || len(a.Results) != len(b.Results) {
+        return false
+    }
+    for j := range a.Params {
+        if a.Params[j] != b.Params[j] {
+            return false
+        }
+    }
+    for j := range a.Results {
+        if a.Results[j] != b.Results[j] {
+            return false
+        }
+    }
+    return true
+}
+
+func (i *Instance) call(index uint32, args []uint64) []uint64 {
+    t := i.module.functionType(index)
+    if int(index) < len(i.host) {
+        results, err := i.host[index].Call(i, args)
+        if err != nil {
+            if err == ErrOutOfGas || isTrap(err) {
+                panic(err)
+            }
+            trap(err.Error())
+        }
+        if len(results) != len(t.Results) {
+            trap("host function returned the wrong number of results")
+        }
+        return results
+    }
+    if i.depth >= i.maxCallDepth {
+        trap("call stack exhausted")
+    }
+    i.depth++
+    f := i.module.functions[int(index) - len(i.host)]
+    locals := make([]uint64, len(args) + len(f.locals))
+    copy(locals, args)
+    results := i.execute(f, t, locals)
+    i.depth--
+    return results
+}
+
+type label struct {
+    arity int
+    height int
+    continuation int
+    loop bool
+}
+
+type operandStack []uint64
+
+func (s *operandStack) push(value uint64) {
+    *s = append(*s, value)
+}
+
+func (s *operandStack) pop() uint64 {
+    if len(*s) == 0 {
+        trap("operand stack underflow")
+    }
+    value := (*s)[len(*s) - 1]
+    *s = (*s)[:len(*s) - 1]
+    return value
+}
+
+func (s *operandStack) pop32() uint32 {
+    return uint32(s.pop())
+}
+
+func (i *Instance) execute(f *function, t FunctionType, locals []uint64) []uint64 {
+    stack := make(operandStack, 0, 16)
+    labels := []label{{arity: len(t.Results), continuation: len(f.code)}}
+    r := &reader{data: f.code}
+
+    // branch unwinds to the label depth levels out, keeping its results on the stack.
+    branch := func(depth int) {
+        l := labels[len(labels) - 1 - depth]
+        arity := l.arity
+        if l.loop {
+            arity = 0
+        }
+        if len(stack) - arity < l.height {
+            trap("operand stack underflow")
+        }
+        copy(stack[l.height:], stack[len(stack) - arity:])
+        stack = stack[:l.height + arity]
+        if l.loop {
+            labels = labels[:len(labels) - depth]
+        } else {
+            labels = labels[:len(labels) - 1 - depth]
+        }
+        r.pos = l.continuation
+    }
+
+    for len(labels) > 0 {
+        i.useGas(instructionGas)
+        pc := r.pos
+        op := r.byte()
+        switch op {
+        case opUnreachable:
+            trap("unreachable")
+        case opNop:
+        case opBlock, opIf:
+            r.byte()
+            info := f.blocks[pc]
+            if op == opIf && stack.pop32() == 0 {
+                if info.elsePC == -1 {
+                    r.pos = info.endPC + 1
+                    break
+                }
+                r.pos = info.elsePC + 1
+            }
+            labels = append(labels, label{arity: info.arity, height: len(stack), continuation: info.endPC + 1})
+        case opLoop:
+            r.byte()
+            labels = append(labels, label{arity: f.blocks[pc].arity, height: len(stack), continuation: r.pos, loop: true})
+        case opElse:
+            branch(0)
+        case opEnd:
+            l := labels[len(labels) - 1]
+            if len(stack) - l.arity < l.height {
+                trap("operand stack underflow")
+            }
+            labels = labels[:len(labels) - 1]
+        case opBr:
+            branch(int(r.u32()))
+        case opBrIf:
+            depth := int(r.u32())
+            if stack.pop32() != 0 {
+                branch(depth)
+            }
+        case opBrTable:
+            n := r.u32()
+            targets := make([]uint32, n + 1)
+            for j := range targets {
+                targets[j] = r.u32()
+            }
+            index := stack.pop32()
+            if index > n {
+                index = n
+            }
+            branch(int(targets[index]))
+        case opReturn:
+            branch(len(labels) - 1)
+        case opCall:
+            index := r.u32()
+            i.callFrom(&stack, index)
+        case opCallIndirect:
+            typeIndex := r.u32()
+            r.byte()
+            element := stack.pop32()
+            if int(element) >= len(i.table) || i.table[element] < 0 {
+                trap("undefined table element")
+            }
+            index := uint32(i.table[element])
+            if !sameType(i.module.functionType(index), i.module.Types[typeIndex]) {
+                trap("indirect call type mismatch")
+            }
+            i.callFrom(&stack, index)
+        case opDrop:
+            stack.pop()
+        case opSelect, opSelectTyped:
+            if op == opSelectTyped {
+                r.u32()
+                r.byte()
+            }
+            condition := stack.pop32()
+            b := stack.pop()
+            a := stack.pop()
+            if condition != 0 {
+                stack.push(a)
+            } else {
+                stack.push(b)
+            }
+        case opLocalGet:
+            stack.push(locals[r.u32()])
+        case opLocalSet:
+            index := r.u32()
+            locals[index] = stack.pop()
+        case opLocalTee:
+            index := r.u32()
+            value := stack.pop()
+            locals[index] = value
+            stack.push(value)
+        case opGlobalGet:
+            stack.push(i.globals[r.u32()])
+        case opGlobalSet:
+            index := r.u32()
+            i.globals[index] = stack.pop()
+        case opMemorySize:
+            r.byte()
+            stack.push(uint64(len(i.memory) / PageSize))
+        case opMemoryGrow:
+            r.byte()
+            delta := stack.pop32()
+            pages := uint32(len(i.memory) / PageSize)
+            if uint64(pages) + uint64(delta) > uint64(i.maxMemoryPages) {
+                stack.push(uint64(0xffffffff))
+                break
+            }
+            i.useGas(uint64(delta) * memoryPageGas)
+            i.memory = append(i.memory, make([]byte, int(delta) * PageSize)...)
+            stack.push(uint64(pages))
+        case opI32Const:
+            stack.push(uint64(uint32(r.s32())))
+        case opI64Const:
+            stack.push(uint64(r.s64()))
+        default:
+            switch {
+            case isLoad(op):
+                r.u32()
+                offset := r.u32()
+                stack.push(i.load(op, stack.pop32(), offset))
+            case isStore(op):
+                r.u32()
+                offset := r.u32()
+                value := stack.pop()
+                i.store(op, stack.pop32(), offset, value)
+            default:
+                numeric(op, &stack)
+            }
+        }
+    }
+
+    if len(stack) < len(t.Results) {
+        trap("operand stack underflow")
+    }
+    return append([]uint64(nil), stack[len(stack) - len(t.Results):]...)
+}
+
+func (i *Instance) callFrom(stack *operandStack, index uint32) {
+    t := i.module.functionType(index)
+    if len(*stack) < len(t.Params) {
+        trap("operand stack underflow")
+    }
+    args := append([]uint64(nil), (*stack)[len(*stack) - len(t.Params):]...)
+    *stack = (*stack)[:len(*stack) - len(t.Params)]
+    for _, result := range i.call(index, args) {
+        stack.push(result)
+    }
+}
+
+func (i *Instance) address(base uint32, offset uint32, size uint64) uint64 {
+    address := uint64(base) + uint64(offset)
+    if address + size > uint64(len(i.memory)) {
+        trap("memory access out of bounds")
+    }
+    return address
+}
+
+func (i *Instance) load(op byte, base uint32, offset uint32) uint64 {
+    switch op {
+    case opI32Load:
+        a := i.address(base, offset, 4)
+        return uint64(binary.LittleEndian.Uint32(i.memory[a:]))
+    case opI64Load:
+        a := i.address(base, offset, 8)
+        return binary.LittleEndian.Uint64(i.memory[a:])
+    case opI32Load8S:
+        a := i.address(base, offset, 1)
+        return uint64(uint32(int32(int8(i.memory[a]))))
+    case opI32Load8U, opI64Load8U:
+        a := i.address(base, offset, 1)
+        return uint64(i.memory[a])
+    case opI32Load16S:
+        a := i.address(base, offset, 2)
+        return uint64(uint32(int32(int16(binary.LittleEndian.Uint16(i.memory[a:])))))
+    case opI32Load16U, opI64Load16U:
+        a := i.address(base, offset, 2)
+        return uint64(binary.LittleEndian.Uint16(i.memory[a:]))
+    case opI64Load8S:
+        a := i.address(base, offset, 1)
+        return uint64(int64(int8(i.memory[a])))
+    case opI64Load16S:
+        a := i.address(base, offset, 2)
+        return uint64(int64(int16(binary.LittleEndian.Uint16(i.memory[a:]))))
+    case opI64Load32S:
+        a := i.address(base, offset, 4)
+        return uint64(int64(int32(binary.LittleEndian.Uint32(i.memory[a:]))))
+    case opI64Load32U:
+        a := i.address(base, offset, 4)
+        return uint64(binary.LittleEndian.Uint32(i.memory[a:]))
+    }
+    trap("unsupported load")
+    return 0
+}
+
+func (i *Instance) store(op byte, base uint32, offset uint32, value uint64) {
+    switch op {
+    case opI32Store, opI64Store32:
+        a := i.address(base, offset, 4)
+        binary.LittleEndian.PutUint32(i.memory[a:], uint32(value))
+    case opI64Store:
+        a := i.address(base, offset, 8)
+        binary.LittleEndian.PutUint64(i.memory[a:], value)
+    case opI32Store8, opI64Store8:
+        a := i.address(base, offset, 1)
+        i.memory[a] = byte(value)
+    case opI32Store16, opI64Store16:
+        a := i.address(base, offset, 2)
+        binary.LittleEndian.PutUint16(i.memory[a:], uint16(value))
+    default:
+        trap("unsupported store")
+    }
+}
+
+func boolValue(b bool) uint64 {
+    if b {
+        return 1
+    }
+    return 0
+}
+
+// numeric executes a comparison, arithmetic or conversion instruction.
+func numeric(op byte, stack *operandStack) {
+    switch op {
+    case opI32Eqz:
+        stack.push(boolValue(stack.pop32() == 0))
+        return
+    case opI64Eqz:
+        stack.push(boolValue(stack.pop() == 0))
+        return
+    case opI32Clz:
+        stack.push(uint64(bits.LeadingZeros32(stack.pop32())))
+        return
+    case opI32Ctz:
+        stack.push(uint64(bits.TrailingZeros32(stack.pop32())))
+        return
+    case opI32Popcnt:
+        stack.push(uint64(bits.OnesCount32(stack.pop32())))
+        return
+    case opI64Clz:
+        stack.push(uint64(bits.LeadingZeros64(stack.pop())))
+        return
+    case opI64Ctz:
+        stack.push(uint64(bits.TrailingZeros64(stack.pop())))
+        return
+    case opI64Popcnt:
+        stack.push(uint64(bits.OnesCount64(stack.pop())))
+        return
+    case opI32WrapI64:
+        stack.push(uint64(stack.pop32()))
+        return
+    case opI64ExtendI32S:
+        stack.push(uint64(int64(int32(stack.pop32()))))
+        return
+    case opI64ExtendI32U:
+        stack.push(uint64(stack.pop32()))
+        return
+    case opI32Extend8S:
+        stack.push(uint64(uint32(int32(int8(stack.pop())))))
+        return
+    case opI32Extend16S:
+        stack.push(uint64(uint32(int32(int16(stack.pop())))))
+        return
+    case opI64Extend8S:
+        stack.push(uint64(int64(int8(stack.pop()))))
+        return
+    case opI64Extend16S:
+        stack.push(uint64(int64(int16(stack.pop()))))
+        return
+    case opI64Extend32S:
+        stack.push(uint64(int64(int32(stack.pop()))))
+        return
+    }
+
+    if (op >= opI32Eq && op <= opI32GeU) || (op >= opI32Add && op <= opI32Rotr) {
+        b := stack.pop32()
+        a := stack.pop32()
+        stack.push(uint64(binary32(op, a, b)))
+        return
+    }
+    if (op >= opI64Eq && op <= opI64GeU) || (op >= opI64Add && op <= opI64Rotr) {
+        b := stack.pop()
+        a := stack.pop()
+        stack.push(binary64(op, a, b))
+        return
+    }
+    trap(fmt.Sprintf("unsupported opcode 0x%02x", op))
+}
+
+func binary32(op byte, a uint32, b uint32) uint32 {
+    switch op {
+    case opI32Eq:
+        return uint32(boolValue(a == b))
+    case opI32Ne:
+        return uint32(boolValue(a != b))
+    case opI32LtS:
+        return uint32(boolValue(int32(a) < int32(b)))
+    case opI32LtU:
+        return uint32(boolValue(a < b))
+    case opI32GtS:
+        return uint32(boolValue(int32(a) > int32(b)))
+    case opI32GtU:
+        return uint32(boolValue(a > b))
+    case opI32LeS:
+        return uint32(boolValue(int32(a) <= int32(b)))
+    case opI32LeU:
+        return uint32(boolValue(a <= b))
+    case opI32GeS:
+        return uint32(boolValue(int32(a) >= int32(b)))
+    case opI32GeU:
+        return uint32(boolValue(a >= b))
+    case opI32Add:
+        return a + b
+    case opI32Sub:
+        return a - b
+    case opI32Mul:
+        return a * b
+    case opI32DivS:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        if int32(a) == -1 << 31 && int32(b) == -1 {
+            trap("integer overflow")
+        }
+        return uint32(int32(a) / int32(b))
+    case opI32DivU:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        return a / b
+    case opI32RemS:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        if int32(b) == -1 {
+            return 0
+        }
+        return uint32(int32(a) % int32(b))
+    case opI32RemU:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        return a % b
+    case opI32And:
+        return a & b
+    case opI32Or:
+        return a | b
+    case opI32Xor:
+        return a ^ b
+    case opI32Shl:
+        return a << (b % 32)
+    case opI32ShrS:
+        return uint32(int32(a) >> (b % 32))
+    case opI32ShrU:
+        return a >> (b % 32)
+    case opI32Rotl:
+        return bits.RotateLeft32(a, int(b % 32))
+    case opI32Rotr:
+        return bits.RotateLeft32(a, -int(b % 32))
+    }
+    trap("unsupported opcode")
+    return 0
+}
+
+func binary64(op byte, a uint64, b uint64) uint64 {
+    switch op {
+    case opI64Eq:
+        return boolValue(a == b)
+    case opI64Ne:
+        return boolValue(a != b)
+    case opI64LtS:
+        return boolValue(int64(a) < int64(b))
+    case opI64LtU:
+        return boolValue(a < b)
+    case opI64GtS:
+        return boolValue(int64(a) > int64(b))
+    case opI64GtU:
+        return boolValue(a > b)
+    case opI64LeS:
+        return boolValue(int64(a) <= int64(b))
+    case opI64LeU:
+        return boolValue(a <= b)
+    case opI64GeS:
+        return boolValue(int64(a) >= int64(b))
+    case opI64GeU:
+        return boolValue(a >= b)
+    case opI64Add:
+        return a + b
+    case opI64Sub:
+        return a - b
+    case opI64Mul:
+        return a * b
+    case opI64DivS:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        if int64(a) == -1 << 63 && int64(b) == -1 {
+            trap("integer overflow")
+        }
+        return uint64(int64(a) / int64(b))
+    case opI64DivU:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        return a / b
+    case opI64RemS:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        if int64(b) == -1 {
+            return 0
+        }
+        return uint64(int64(a) % int64(b))
+    case opI64RemU:
+        if b == 0 {
+            trap("integer divide by zero")
+        }
+        return a % b
+    case opI64And:
+        return a & b
+    case opI64Or:
+        return a | b
+    case opI64Xor:
+        return a ^ b
+    case opI64Shl:
+        return a << (b % 64)
+    case opI64ShrS:
+        return uint64(int64(a) >> (b % 64))
+    case opI64ShrU:
+        return a >> (b % 64)
+    case opI64Rotl:
+        return bits.RotateLeft64(a, int(b % 64))
+    case opI64Rotr:
+        return bits.RotateLeft64(a, -int(b % 64))
+    }
+    trap("unsupported opcode")
+    return 0
+}
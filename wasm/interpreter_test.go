@@ -0,0 +1,205 @@
+package wasm
+
+import (
+    "bytes"
+    "testing"
+)
+
+func uleb(v uint64) []byte {
+    var out []byte
+    for {
+        b := byte(v & 0x7f)
+        v >>= 7
+        if v != 0 {
+            out = append(out, b | 0x80)
+            continue
+        }
+        return append(out, b)
+    }
+}
+
+func vec(items ...[]byte) []byte {
+    out := uleb(uint64(len(items)))
+    for _, item := range items {
+        out = append(out, item...)
+    }
+    return out
+}
+
+func name(s string) []byte {
+    return append(uleb(uint64(len(s))), s...)
+}
+
+func section(id byte, contents []byte) []byte {
+    return append(append([]byte{id}, uleb(uint64(len(contents)))...), contents...)
+}
+
+func body(locals []byte, code ...byte) []byte {
+    contents := append(locals, code...)
+    return append(uleb(uint64(len(contents))), contents...)
+}
+
+func cat(parts ...[]byte) []byte {
+    var out []byte
+    for _, part := range parts {
+        out = append(out, part...)
+    }
+    return out
+}
+
+func testModule() []byte {
+    types := vec(
+        []byte{0x60, 0x01, 0x7e, 0x01, 0x7e},
+        []byte{0x60, 0x01, 0x7f, 0x01, 0x7f},
+        []byte{0x60, 0x02, 0x7f, 0x7e, 0x00},
+        []byte{0x60, 0x01, 0x7f, 0x01, 0x7e},
+        []byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f},
+    )
+    imports := vec(cat(name("env"), name("add"), []byte{0x00, 0x04}))
+    functions := vec([]byte{0}, []byte{1}, []byte{2}, []byte{3}, []byte{1}, []byte{1}, []byte{4}, []byte{1})
+    memory := vec([]byte{0x01, 0x01, 0x02})
+    exports := vec(
+        cat(name("fac"), []byte{0x00, 0x01}),
+        cat(name("sum"), []byte{0x00, 0x02}),
+        cat(name("store"), []byte{0x00, 0x03}),
+        cat(name("load"), []byte{0x00, 0x04}),
+        cat(name("grow"), []byte{0x00, 0x05}),
+        cat(name("add10"), []byte{0x00, 0x06}),
+        cat(name("div"), []byte{0x00, 0x07}),
+        cat(name("switch"), []byte{0x00, 0x08}),
+    )
+    noLocals := []byte{0x00}
+    code := vec(
+        // fac(n) = n == 0 ? 1 : n * fac(n - 1)
+        body(noLocals, 0x20, 0x00, 0x50, 0x04, 0x7e, 0x42, 0x01, 0x05, 0x20, 0x00, 0x20, 0x00, 0x42, 0x01, 0x7d, 0x10, 0x01, 0x7e, 0x0b, 0x0b),
+        // sum(n) = n + (n - 1) + ... + 1, with a loop
+        body([]byte{0x01, 0x01, 0x7f}, 0x02, 0x40, 0x03, 0x40, 0x20, 0x00, 0x45, 0x0d, 0x01, 0x20, 0x01, 0x20, 0x00, 0x6a, 0x21, 0x01, 0x20, 0x00, 0x41, 0x01, 0x6b, 0x21, 0x00, 0x0c, 0x00, 0x0b, 0x0b, 0x20, 0x01, 0x0b),
+        body(noLocals, 0x20, 0x00, 0x20, 0x01, 0x37, 0x03, 0x00, 0x0b),
+        body(noLocals, 0x20, 0x00, 0x29, 0x03, 0x00, 0x0b),
+        body(noLocals, 0x20, 0x00, 0x40, 0x00, 0x0b),
+        body(noLocals, 0x20, 0x00, 0x41, 0x0a, 0x10, 0x00, 0x0b),
+        body(noLocals, 0x20, 0x00, 0x20, 0x01, 0x6d, 0x0b),
+        // switch(i) returns 10, 20 or 30, with a br_table
+        body(noLocals, 0x02, 0x40, 0x02, 0x40, 0x02, 0x40, 0x20, 0x00, 0x0e, 0x02, 0x00, 0x01, 0x02, 0x0b, 0x41, 0x0a, 0x0f, 0x0b, 0x41, 0x14, 0x0f, 0x0b, 0x41, 0x1e, 0x0b),
+    )
+    data := vec(cat([]byte{0x00, 0x41, 0x10, 0x0b}, name("hello")))
+    return cat(wasmMagic, section(1, types), section(2, imports), section(3, functions), section(5, memory), section(7, exports), section(10, code), section(11, data))
+}
+
+var testImports = Imports{
+    "env": {
+        "add": HostFunction{
+            Type: FunctionType{Params: []ValueType{I32, I32}, Results: []ValueType{I32}},
+            Call: func(instance *Instance, args []uint64) ([]uint64, error) {
+                return []uint64{uint64(uint32(args[0]) + uint32(args[1]))}, nil
+            },
+        },
+    },
+}
+
+func instantiate(t *testing.T, gasLimit uint64) *Instance {
+    module, err := Parse(testModule())
+    if err != nil {
+        t.Fatalf("failed to parse module: %v", err)
+    }
+    instance, err := Instantiate(module, testImports, Config{GasLimit: gasLimit})
+    if err != nil {
+        t.Fatalf("failed to instantiate module: %v", err)
+    }
+    return instance
+}
+
+func TestInterpreter(t *testing.T) {
+    instance := instantiate(t, 1000000)
+
+    calls := []struct {
+        name string
+        args []uint64
+        result uint64
+    }{
+        {"fac", []uint64{20}, 2432902008176640000},
+        {"sum", []uint64{100}, 5050},
+        {"add10", []uint64{5}, 15},
+        {"div", []uint64{uint64(uint32(0xfffffff6)), 3}, uint64(uint32(0xfffffffd))},
+        {"switch", []uint64{0}, 10},
+        {"switch", []uint64{1}, 20},
+        {"switch", []uint64{7}, 30},
+    }
+    for _, c := range calls {
+        results, err := instance.Call(c.name, c.args...)
+        if err != nil || len(results) != 1 || results[0] != c.result {
+            t.Errorf("%s%v: expected %d, got %v (%v)", c.name, c.args, c.result, results, err)
+        }
+    }
+
+    if _, err := instance.Call("div", 1, 0); !isTrap(err) {
+        t.Error("division by zero did not trap")
+    }
+    if _, err := instance.Call("missing"); err != ErrExportNotFound {
+        t.Error("called a missing export")
+    }
+}
+
+func TestInterpreterMemory(t *testing.T) {
+    instance := instantiate(t, 1000000)
+
+    data, err := instance.ReadMemory(16, 5)
+    if err != nil || bytes.Compare(data, []byte("hello")) != 0 {
+        t.Error("data segment not loaded")
+    }
+    if _, err := instance.Call("store", 100, 0x0102030405060708); err != nil {
+        t.Fatal(err)
+    }
+    results, err := instance.Call("load", 100)
+    if err != nil || results[0] != 0x0102030405060708 {
+        t.Error("wrong value loaded from memory")
+    }
+    if _, err := instance.Call("load", PageSize - 4); !isTrap(err) {
+        t.Error("out of bounds load did not trap")
+    }
+
+    results, _ = instance.Call("grow", 1)
+    if results[0] != 1 {
+        t.Error("memory did not grow")
+    }
+    results, _ = instance.Call("grow", 1)
+    if uint32(results[0]) != 0xffffffff {
+        t.Error("memory grew past its maximum")
+    }
+    if _, err := instance.Call("load", PageSize - 4); err != nil {
+        t.Error("grown memory not addressable")
+    }
+}
+
+func TestInterpreterGas(t *testing.T) {
+    first := instantiate(t, 1000000)
+    first.Call("sum", 100)
+    second := instantiate(t, 1000000)
+    second.Call("sum", 100)
+    if first.GasUsed() == 0 || first.GasUsed() != second.GasUsed() {
+        t.Error("gas use is not deterministic")
+    }
+
+    limited := instantiate(t, 100)
+    if _, err := limited.Call("sum", 1000000); err != ErrOutOfGas {
+        t.Errorf("expected out of gas, got %v", err)
+    }
+    if limited.GasUsed() != 100 {
+        t.Error("gas used should be capped at the limit")
+    }
+}
+
+func TestParseRejectsFloats(t *testing.T) {
+    types := vec([]byte{0x60, 0x00, 0x00})
+    functions := vec([]byte{0})
+    // f32.const 0; drop
+    code := vec(body([]byte{0x00}, 0x43, 0x00, 0x00, 0x00, 0x00, 0x1a, 0x0b))
+    _, err := Parse(cat(wasmMagic, section(1, types), section(3, functions), section(10, code)))
+    if err == nil {
+        t.Error("parsed a module that uses floating point")
+    }
+
+    if _, err := Parse([]byte("not wasm")); err != ErrInvalidModule {
+        t.Error("parsed an invalid module")
+    }
+}
@@ -0,0 +1,673 @@
+// Package wasm is a small deterministic WebAssembly interpreter for running application code.
+// It supports the integer subset of WebAssembly 1.0: modules that use floating point instructions are rejected,
+// since their results can differ between platforms.
+package wasm
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+)
+
+// ValueType is the type of a WebAssembly value.
+type ValueType byte
+
+const (
+    I32 ValueType = 0x7f
+    I64 ValueType = 0x7e
+)
+
+// External kinds of imports and exports.
+const (
+    ExternalFunction byte = 0x00
+    ExternalTable byte = 0x01
+    ExternalMemory byte = 0x02
+    ExternalGlobal byte = 0x03
+)
+
+// PageSize is the size of a page of linear memory.
+const PageSize = 65536
+
+// maxPages is the number of pages that can be addressed with 32-bit pointers.
+const maxPages = 65536
+
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// ErrInvalidModule is returned when a module can't be decoded.
+var ErrInvalidModule = errors.New("invalid module")
+
+// ErrUnsupported is returned when a module uses a feature that the interpreter doesn't support.
+var ErrUnsupported = errors.New("unsupported module feature")
+
+// FunctionType is the signature of a function.
+type FunctionType struct {
+    Params []ValueType
+    Results []ValueType
+}
+
+// Import is a function imported from the host.
+type Import struct {
+    Module string
+    Name string
+    Type uint32
+}
+
+// Export is a function, table, memory or global exported by a module.
+type Export struct {
+    Kind byte
+    Index uint32
+}
+
+// Limits are the initial and maximum sizes of a table or memory.
+type Limits struct {
+    Min uint32
+    Max uint32
+    HasMax bool
+}
+
+// Global is a global variable defined by a module.
+type Global struct {
+    Type ValueType
+    Mutable bool
+    Init uint64
+}
+
+type function struct {
+    typeIndex uint32
+    locals []ValueType
+    code []byte
+    blocks map[int]*blockInfo
+}
+
+// blockInfo locates the else and end instructions of a block, loop or if.
+type blockInfo struct {
+    arity int
+    elsePC int
+    endPC int
+}
+
+type element struct {
+    offset uint32
+    functions []uint32
+}
+
+type dataSegment struct {
+    offset uint32
+    data []byte
+}
+
+// Module is a decoded and validated WebAssembly module.
+type Module struct {
+    Types []FunctionType
+    Imports []Import
+    Exports map[string]Export
+    Memory *Limits
+    Table *Limits
+    Globals []Global
+
+    functions []*function
+    start *uint32
+    elements []element
+    data []dataSegment
+}
+
+// Parse decodes and validates a WebAssembly module in the binary format.
+func Parse(code []byte) (*Module, error) {
+    if len(code) < len(wasmMagic) || bytes.Compare(code[:len(wasmMagic)], wasmMagic) != 0 {
+        return nil, ErrInvalidModule
+    }
+    m := &Module{
+        Exports: make(map[string]Export),
+    }
+    r := &reader{data: code, pos: len(wasmMagic)}
+    var functionTypes []uint32
+    for !r.done() {
+        id := r.byte()
+        size := r.u32()
+        if r.err != nil {
+            return nil, r.err
+        }
+        if uint64(r.pos) + uint64(size) > uint64(len(r.data)) {
+            return nil, ErrInvalidModule
+        }
+        section := &reader{data: r.data[r.pos:r.pos + int(size)]}
+        r.pos += int(size)
+        var err error
+        switch id {
+        case 0, 12:
+            // Custom and data count sections don't affect execution.
+        case 1:
+            err = m.parseTypes(section)
+        case 2:
+            err = m.parseImports(section)
+        case 3:
+            functionTypes, err = parseFunctions(section, len(m.Types))
+        case 4:
+            err = m.parseTable(section)
+        case 5:
+            err = m.parseMemory(section)
+        case 6:
+            err = m.parseGlobals(section)
+        case 7:
+            err = m.parseExports(section)
+        case 8:
+            index := section.u32()
+            m.start = &index
+        case 9:
+            err = m.parseElements(section)
+        case 10:
+            err = m.parseCode(section, functionTypes)
+        case 11:
+            err = m.parseData(section)
+        default:
+            err = ErrInvalidModule
+        }
+        if err == nil {
+            err = section.err
+        }
+        if err != nil {
+            return nil, err
+        }
+        if id != 0 && !section.done() {
+            return nil, ErrInvalidModule
+        }
+    }
+    if len(functionTypes) != len(m.functions) {
+        return nil, ErrInvalidModule
+    }
+    return m, m.validate()
+}
+
+func (m *Module) parseTypes(r *reader) error {
+    count := r.u32()
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        if r.byte() != 0x60 {
+            return ErrInvalidModule
+        }
+        params, err := r.valueTypes()
+        if err != nil {
+            return err
+        }
+        results, err := r.valueTypes()
+        if err != nil {
+            return err
+        }
+        if len(results) > 1 {
+            return ErrUnsupported
+        }
+        m.Types = append(m.Types, FunctionType{Params: params, Results: results})
+    }
+    return nil
+}
+
+func (m *Module) parseImports(r *reader) error {
+    count := r.u32()
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        module := r.name()
+        name := r.name()
+        if r.byte() != ExternalFunction {
+            return ErrUnsupported
+        }
+        typeIndex := r.u32()
+        if int(typeIndex) >= len(m.Types) {
+            return ErrInvalidModule
+        }
+        m.Imports = append(m.Imports, Import{Module: module, Name: name, Type: typeIndex})
+    }
+    return nil
+}
+
+func parseFunctions(r *reader, numTypes int) ([]uint32, error) {
+    count := r.u32()
+    var types []uint32
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        typeIndex := r.u32()
+        if int(typeIndex) >= numTypes {
+            return nil, ErrInvalidModule
+        }
+        types = append(types, typeIndex)
+    }
+    return types, nil
+}
+
+func (m *Module) parseTable(r *reader) error {
+    if r.u32() != 1 || r.byte() != 0x70 {
+        return ErrUnsupported
+    }
+    limits := r.limits()
+    m.Table = &limits
+    return nil
+}
+
+func (m *Module) parseMemory(r *reader) error {
+    if r.u32() != 1 {
+        return ErrUnsupported
+    }
+    limits := r.limits()
+    if limits.Min > maxPages || (limits.HasMax && (limits.Max > maxPages || limits.Max < limits.Min)) {
+        return ErrInvalidModule
+    }
+    m.Memory = &limits
+    return nil
+}
+
+func (m *Module) parseGlobals(r *reader) error {
+    count := r.u32()
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        valueType := ValueType(r.byte())
+        if valueType != I32 && valueType != I64 {
+            return ErrUnsupported
+        }
+        mutable := r.byte()
+        if mutable > 1 {
+            return ErrInvalidModule
+        }
+        init, err := m.constExpr(r, valueType)
+        if err != nil {
+            return err
+        }
+        m.Globals = append(m.Globals, Global{Type: valueType, Mutable: mutable == 1, Init: init})
+    }
+    return nil
+}
+
+func (m *Module) parseExports(r *reader) error {
+    count := r.u32()
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        name := r.name()
+        kind := r.byte()
+        index := r.u32()
+        if _, ok := m.Exports[name]; ok {
+            return ErrInvalidModule
+        }
+        m.Exports[name] = Export{Kind: kind, Index: index}
+    }
+    return nil
+}
+
+func (m *Module) parseElements(r *reader) error {
+    count := r.u32()
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        if r.u32() != 0 {
+            return ErrUnsupported
+        }
+        offset, err := m.constExpr(r, I32)
+        if err != nil {
+            return err
+        }
+        n := r.u32()
+        var functions []uint32
+        for j := uint32(0); j < n && r.err == nil; j++ {
+            functions = append(functions, r.u32())
+        }
+        m.elements = append(m.elements, element{offset: uint32(offset), functions: functions})
+    }
+    return nil
+}
+
+func (m *Module) parseCode(r *reader, functionTypes []uint32) error {
+    count := r.u32()
+    if int(count) != len(functionTypes) {
+        return ErrInvalidModule
+    }
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        size := r.u32()
+        if r.err != nil || uint64(r.pos) + uint64(size) > uint64(len(r.data)) {
+            return ErrInvalidModule
+        }
+        body := &reader{data: r.data[r.pos:r.pos + int(size)]}
+        r.pos += int(size)
+
+        f := &function{typeIndex: functionTypes[i]}
+        groups := body.u32()
+        for j := uint32(0); j < groups && body.err == nil; j++ {
+            n := body.u32()
+            valueType := ValueType(body.byte())
+            if valueType != I32 && valueType != I64 {
+                return ErrUnsupported
+            }
+            if uint64(len(f.locals)) + uint64(n) > maxLocals {
+                return ErrUnsupported
+            }
+            for k := uint32(0); k < n; k++ {
+                f.locals = append(f.locals, valueType)
+            }
+        }
+        if body.err != nil {
+            return body.err
+        }
+        f.code = body.data[body.pos:]
+        m.functions = append(m.functions, f)
+    }
+    return nil
+}
+
+func (m *Module) parseData(r *reader) error {
+    count := r.u32()
+    for i := uint32(0); i < count && r.err == nil; i++ {
+        if r.u32() != 0 {
+            return ErrUnsupported
+        }
+        offset, err := m.constExpr(r, I32)
+        if err != nil {
+            return err
+        }
+        n := r.u32()
+        data := r.bytes(n)
+        m.data = append(m.data, dataSegment{offset: uint32(offset), data: data})
+    }
+    return nil
+}
+
+// constExpr evaluates a constant initializer expression.
+func (m *Module) constExpr(r *reader, valueType ValueType) (uint64, error) {
+    var value uint64
+    switch r.byte() {
+    case opI32Const:
+        if valueType != I32 {
+            return 0, ErrInvalidModule
+        }
+        value = uint64(uint32(r.s32()))
+    case opI64Const:
+        if valueType != I64 {
+            return 0, ErrInvalidModule
+        }
+        value = uint64(r.s64())
+    case opGlobalGet:
+        index := r.u32()
+        if int(index) >= len(m.Globals) || m.Globals[index].Type != valueType {
+            return 0, ErrInvalidModule
+        }
+        value = m.Globals[index].Init
+    default:
+        return 0, ErrUnsupported
+    }
+    if r.byte() != opEnd {
+        return 0, ErrInvalidModule
+    }
+    return value, r.err
+}
+
+func (m *Module) numFunctions() int {
+    return len(m.Imports) + len(m.functions)
+}
+
+func (m *Module) functionType(index uint32) FunctionType {
+    if int(index) < len(m.Imports) {
+        return m.Types[m.Imports[index].Type]
+    }
+    return m.Types[m.functions[int(index) - len(m.Imports)].typeIndex]
+}
+
+// validate checks the indexes used by the module, and locates the blocks of each function body.
+func (m *Module) validate() error {
+    for name, export := range m.Exports {
+        var ok bool
+        switch export.Kind {
+        case ExternalFunction:
+            ok = int(export.Index) < m.numFunctions()
+        case ExternalTable:
+            ok = export.Index == 0 && m.Table != nil
+        case ExternalMemory:
+            ok = export.Index == 0 && m.Memory != nil
+        case ExternalGlobal:
+            ok = int(export.Index) < len(m.Globals)
+        }
+        if !ok {
+            return fmt.Errorf("%v: export %s", ErrInvalidModule, name)
+        }
+    }
+    if m.start != nil {
+        if int(*m.start) >= m.numFunctions() {
+            return ErrInvalidModule
+        }
+        t := m.functionType(*m.start)
+        if len(t.Params) != 0 || len(t.Results) != 0 {
+            return ErrInvalidModule
+        }
+    }
+    for _, e := range m.elements {
+        if m.Table == nil {
+            return ErrInvalidModule
+        }
+        for _, index := range e.functions {
+            if int(index) >= m.numFunctions() {
+                return ErrInvalidModule
+            }
+        }
+    }
+    if len(m.data) > 0 && m.Memory == nil {
+        return ErrInvalidModule
+    }
+    for i, f := range m.functions {
+        if err := m.scanBlocks(f); err != nil {
+            return fmt.Errorf("function %d: %v", len(m.Imports) + i, err)
+        }
+    }
+    return nil
+}
+
+// scanBlocks decodes a function body, checking its instructions and immediates,
+// and records where each block's else and end instructions are.
+func (m *Module) scanBlocks(f *function) error {
+    f.blocks = make(map[int]*blockInfo)
+    numLocals := uint32(len(m.Types[f.typeIndex].Params) + len(f.locals))
+    var open []int
+    r := &reader{data: f.code}
+    for !r.done() {
+        pc := r.pos
+        op := r.byte()
+        switch {
+        case op == opBlock || op == opLoop || op == opIf:
+            arity, err := blockArity(r)
+            if err != nil {
+                return err
+            }
+            f.blocks[pc] = &blockInfo{arity: arity, elsePC: -1}
+            open = append(open, pc)
+        case op == opElse:
+            if len(open) == 0 || f.code[open[len(open) - 1]] != opIf || f.blocks[open[len(open) - 1]].elsePC != -1 {
+                return ErrInvalidModule
+            }
+            f.blocks[open[len(open) - 1]].elsePC = pc
+        case op == opEnd:
+            if len(open) == 0 {
+                if !r.done() {
+                    return ErrInvalidModule
+                }
+                f.blocks[-1] = &blockInfo{endPC: pc}
+                return nil
+            }
+            f.blocks[open[len(open) - 1]].endPC = pc
+            open = open[:len(open) - 1]
+        case op == opBr || op == opBrIf:
+            if r.u32() > uint32(len(open)) {
+                return ErrInvalidModule
+            }
+        case op == opBrTable:
+            n := r.u32()
+            for i := uint32(0); i <= n && r.err == nil; i++ {
+                if r.u32() > uint32(len(open)) {
+                    return ErrInvalidModule
+                }
+            }
+        case op == opCall:
+            if int(r.u32()) >= m.numFunctions() {
+                return ErrInvalidModule
+            }
+        case op == opCallIndirect:
+            if int(r.u32()) >= len(m.Types) || r.byte() != 0 || m.Table == nil {
+                return ErrInvalidModule
+            }
+        case op == opLocalGet || op == opLocalSet || op == opLocalTee:
+            if r.u32() >= numLocals {
+                return ErrInvalidModule
+            }
+        case op == opGlobalGet:
+            if int(r.u32()) >= len(m.Globals) {
+                return ErrInvalidModule
+            }
+        case op == opGlobalSet:
+            index := r.u32()
+            if int(index) >= len(m.Globals) || !m.Globals[index].Mutable {
+                return ErrInvalidModule
+            }
+        case op == opSelectTyped:
+            if r.u32() != 1 {
+                return ErrInvalidModule
+            }
+            valueType := ValueType(r.byte())
+            if valueType != I32 && valueType != I64 {
+                return ErrUnsupported
+            }
+        case isLoad(op) || isStore(op):
+            if m.Memory == nil {
+                return ErrInvalidModule
+            }
+            r.u32()
+            r.u32()
+        case op == opMemorySize || op == opMemoryGrow:
+            if m.Memory == nil || r.byte() != 0 {
+                return ErrInvalidModule
+            }
+        case op == opI32Const:
+            r.s32()
+        case op == opI64Const:
+            r.s64()
+        case isSimple(op):
+        default:
+            return fmt.Errorf("%v: opcode 0x%02x", ErrUnsupported, op)
+        }
+        if r.err != nil {
+            return r.err
+        }
+    }
+    return ErrInvalidModule
+}
+
+func blockArity(r *reader) (int, error) {
+    switch blockType := r.byte(); ValueType(blockType) {
+    case 0x40:
+        return 0, nil
+    case I32, I64:
+        return 1, nil
+    }
+    return 0, ErrUnsupported
+}
+
+// maxLocals bounds the number of locals a function can declare, so a small module can't allocate huge frames.
+const maxLocals = 50000
+
+type reader struct {
+    data []byte
+    pos int
+    err error
+}
+
+func (r *reader) done() bool {
+    return r.err != nil || r.pos >= len(r.data)
+}
+
+func (r *reader) byte() byte {
+    if r.pos >= len(r.data) {
+        r.err = ErrInvalidModule
+        return 0
+    }
+    b := r.data[r.pos]
+    r.pos++
+    return b
+}
+
+func (r *reader) bytes(n uint32) []byte {
+    if uint64(r.pos) + uint64(n) > uint64(len(r.data)) {
+        r.err = ErrInvalidModule
+        return nil
+    }
+    b := r.data[r.pos:r.pos + int(n)]
+    r.pos += int(n)
+    return b
+}
+
+func (r *reader) name() string {
+    return string(r.bytes(r.u32()))
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+    n := r.u32()
+    var types []ValueType
+    for i := uint32(0); i < n && r.err == nil; i++ {
+        valueType := ValueType(r.byte())
+        if valueType != I32 && valueType != I64 {
+            return nil, ErrUnsupported
+        }
+        types = append(types, valueType)
+    }
+    return types, r.err
+}
+
+func (r *reader) limits() Limits {
+    var limits Limits
+    switch r.byte() {
+    case 0:
+        limits.Min = r.u32()
+    case 1:
+        limits.Min = r.u32()
+        limits.Max = r.u32()
+        limits.HasMax = true
+    default:
+        r.err = ErrInvalidModule
+    }
+    return limits
+}
+
+// u32 reads an unsigned LEB128 integer of at most 32 bits.
+func (r *reader) u32() uint32 {
+    var value uint64
+    for shift := uint(0); shift < 35; shift += 7 {
+        b := r.byte()
+        value |= uint64(b & 0x7f) << shift
+        if b & 0x80 == 0 {
+            if value > 0xffffffff {
+                r.err = ErrInvalidModule
+            }
+            return uint32(value)
+        }
+    }
+    r.err = ErrInvalidModule
+    return 0
+}
+
+func (r *reader) s32() int32 {
+    return int32(r.signed(32))
+}
+
+func (r *reader) s64() int64 {
+    return r.signed(64)
+}
+
+// signed reads a signed LEB128 integer of at most size bits.
+func (r *reader) signed(size uint) int64 {
+    var value int64
+    var shift uint
+    for {
+        b := r.byte()
+        if r.err != nil {
+            return 0
+        }
+        value |= int64(b & 0x7f) << shift
+        shift += 7
+        if b & 0x80 == 0 {
+            if shift < 64 && b & 0x40 != 0 {
+                value |= -1 << shift
+            }
+            if size < 64 && (value < -(1 << (size - 1)) || value >= 1 << (size - 1)) {
+                r.err = ErrInvalidModule
+            }
+            return value
+        }
+        if shift >= size + 7 {
+            r.err = ErrInvalidModule
+            return 0
+        }
+    }
+}